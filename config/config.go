@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"time"
 )
@@ -25,6 +26,12 @@ func GetEnv(key, fallback string) string {
 type Config struct {
 	Server     Server
 	MongoDB    MongoDB
+	Auth       Auth
+	Workflow   Workflow
+	Recurrence Recurrence
+	Retention  Retention
+	Cache      Cache
+	Reactions  Reactions
 	Pagination struct {
 		MaxLimit           int
 		MaxGetProfileLimit int
@@ -40,11 +47,107 @@ type Server struct {
 
 type MongoDB struct {
 	Collections struct {
-		Tasks    string
-		Profiles string
-		Comments string
+		Tasks       string
+		Profiles    string
+		Comments    string
+		Recurrences string
+		History     string
+		Mentions    string
+		Reactions   string
 	}
 	Timeout               time.Duration
 	DefaultContextTimeout time.Duration
 	AppName               string
 }
+
+type Auth struct {
+	Issuer    string
+	Audience  string
+	ClockSkew time.Duration
+	Keys      []AuthKey
+}
+
+// AuthKey is a locally configured key used to verify token signatures.
+// HS256 keys use Secret, or the environment variable named by SecretEnv so
+// that the secret stays out of config.yml. RS256 keys use PublicKey which is
+// a path to a PEM file.
+type AuthKey struct {
+	ID        string
+	Algorithm string
+	Secret    string
+	SecretEnv string
+	PublicKey string
+}
+
+// Workflow lists the task states and the transitions allowed between them.
+// The first state is the one new tasks start in. RequireSubtasksDone stops
+// a task from entering a terminal state while it has unfinished subtasks.
+type Workflow struct {
+	States              []WorkflowState
+	RequireSubtasksDone bool
+}
+
+// WorkflowState is a task status. Terminal states count as finished,
+// Transitions lists the ids of the states a task may move to.
+type WorkflowState struct {
+	ID          int
+	Name        string
+	Terminal    bool
+	Transitions []int
+}
+
+// Validate rejects settings the server cannot start with, such as a
+// background job interval or timeout of zero.
+func (c *Config) Validate() error {
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"recurrence.pollInterval", c.Recurrence.PollInterval},
+		{"recurrence.runTimeout", c.Recurrence.RunTimeout},
+		{"retention.pollInterval", c.Retention.PollInterval},
+		{"retention.runTimeout", c.Retention.RunTimeout},
+	}
+	for _, d := range durations {
+		if d.value <= 0 {
+			return fmt.Errorf("%v must be positive", d.name)
+		}
+	}
+	return nil
+}
+
+// Recurrence configures the scheduler creating the tasks of recurring series.
+// PollInterval and RunTimeout are in seconds.
+type Recurrence struct {
+	PollInterval time.Duration
+	RunTimeout   time.Duration
+}
+
+// Retention configures the purge of archived tasks and of the comments,
+// reactions and mentions of deleted tasks. ArchivedTasks is how long a task
+// stays archived before it is deleted, zero keeps archived tasks forever.
+// All durations are in seconds.
+type Retention struct {
+	ArchivedTasks time.Duration
+	PollInterval  time.Duration
+	RunTimeout    time.Duration
+}
+
+// Cache lists the Cache-Control policies of read endpoints, routes without
+// a policy send no Cache-Control header.
+type Cache struct {
+	Routes []CacheRoute
+}
+
+// CacheRoute is the Cache-Control value of a route, Path is the route as
+// registered, such as "/tasks/:taskId".
+type CacheRoute struct {
+	Path    string
+	Control string
+}
+
+// Reactions lists the emojis people can react with, by name, in the order
+// reaction counts are shown.
+type Reactions struct {
+	Emojis []string
+}
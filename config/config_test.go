@@ -0,0 +1,41 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ConfigTestSuite struct {
+	suite.Suite
+}
+
+func TestConfigTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfigTestSuite))
+}
+
+func (t *ConfigTestSuite) TestValidate() {
+	valid := func() Config {
+		var c Config
+		c.Recurrence = Recurrence{PollInterval: 60, RunTimeout: 30}
+		c.Retention = Retention{PollInterval: 3600, RunTimeout: 300}
+		return c
+	}
+
+	t.Run("validate accepts positive intervals and timeouts", func() {
+		c := valid()
+		t.NoError(c.Validate())
+	})
+
+	t.Run("validate rejects a zero poll interval", func() {
+		c := valid()
+		c.Recurrence.PollInterval = 0
+		t.EqualError(c.Validate(), "recurrence.pollInterval must be positive")
+	})
+
+	t.Run("validate rejects a zero run timeout", func() {
+		c := valid()
+		c.Retention.RunTimeout = 0
+		t.EqualError(c.Validate(), "retention.runTimeout must be positive")
+	})
+}
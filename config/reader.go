@@ -33,6 +33,12 @@ func Read(config interface{}) error {
 		fmt.Printf("cannot unmarshal configuration, %v\n", err)
 		return err
 	}
+	if c, ok := config.(*Config); ok {
+		if err := c.Validate(); err != nil {
+			fmt.Printf("invalid configuration, %v\n", err)
+			return err
+		}
+	}
 
 	return nil
 }
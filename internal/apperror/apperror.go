@@ -0,0 +1,100 @@
+package apperror
+
+// Kind classifies an error independently of the layer that produced it.
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindNotFound
+	KindForbidden
+	KindConflict
+	KindValidation
+	KindUnavailable
+	KindPrecondition
+)
+
+// Sentinels of each kind, errors.Is(err, ErrNotFound) matches every not found error.
+var (
+	ErrInternal    = New(KindInternal, "internal_error", "Internal server error")
+	ErrNotFound    = New(KindNotFound, "not_found", "Resource not found")
+	ErrForbidden   = New(KindForbidden, "forbidden", "Operation not allowed")
+	ErrConflict    = New(KindConflict, "conflict", "Resource state conflict")
+	ErrValidation  = New(KindValidation, "validation_error", "Invalid request")
+	ErrUnavailable = New(KindUnavailable, "service_unavailable", "Service temporarily unavailable")
+	// ErrPrecondition is a request made against a version of a resource that is no longer current.
+	ErrPrecondition = New(KindPrecondition, "precondition_failed", "Resource was modified")
+)
+
+var sentinels = map[Kind]*Error{
+	KindInternal:     ErrInternal,
+	KindNotFound:     ErrNotFound,
+	KindForbidden:    ErrForbidden,
+	KindConflict:     ErrConflict,
+	KindValidation:   ErrValidation,
+	KindUnavailable:  ErrUnavailable,
+	KindPrecondition: ErrPrecondition,
+}
+
+// Error carries a stable machine readable code and a message that is safe
+// to return to clients. The raw cause is kept in Err for logging only.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Err     error
+}
+
+func New(kind Kind, code string, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func NotFound(code string, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Forbidden(code string, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+func Conflict(code string, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+func Validation(code string, message string) *Error {
+	return New(KindValidation, code, message)
+}
+
+func Unavailable(code string, message string) *Error {
+	return New(KindUnavailable, code, message)
+}
+
+func Precondition(code string, message string) *Error {
+	return New(KindPrecondition, code, message)
+}
+
+// Wrap returns a copy of the error with cause attached.
+func (e *Error) Wrap(cause error) *Error {
+	wrapped := *e
+	wrapped.Err = cause
+	return &wrapped
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches the sentinel of the same kind or any error with the same code.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok || t.Kind != e.Kind {
+		return false
+	}
+	return t == sentinels[t.Kind] || t.Code == e.Code
+}
@@ -0,0 +1,51 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type AppErrorTestSuite struct {
+	suite.Suite
+}
+
+func TestAppErrorTestSuite(t *testing.T) {
+	suite.Run(t, new(AppErrorTestSuite))
+}
+
+func (t *AppErrorTestSuite) TestIs() {
+	errTaskNotFound := NotFound("task_not_found", "Task not found")
+
+	t.Run("error should match the sentinel of its kind", func() {
+		t.ErrorIs(errTaskNotFound, ErrNotFound)
+		t.NotErrorIs(errTaskNotFound, ErrConflict)
+	})
+
+	t.Run("wrapped error should match the original and the sentinel", func() {
+		err := fmt.Errorf("get task: %w", errTaskNotFound.Wrap(errors.New("mongo: no documents in result")))
+		t.ErrorIs(err, errTaskNotFound)
+		t.ErrorIs(err, ErrNotFound)
+	})
+
+	t.Run("errors with another code should not match", func() {
+		t.NotErrorIs(errTaskNotFound, NotFound("comment_not_found", "Comment not found"))
+	})
+}
+
+func (t *AppErrorTestSuite) TestWrap() {
+	cause := errors.New("connection reset by peer")
+	err := ErrUnavailable.Wrap(cause)
+
+	t.Run("wrap should keep the cause for logging", func() {
+		t.Equal("connection reset by peer", err.Error())
+		t.ErrorIs(err, cause)
+	})
+
+	t.Run("wrap should not modify the original error", func() {
+		t.Nil(ErrUnavailable.Err)
+		t.Equal("Service temporarily unavailable", ErrUnavailable.Error())
+	})
+}
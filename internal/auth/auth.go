@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"task-manager-api/config"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrMissingSubject = errors.New("token has no subject")
+	ErrUnknownKey     = errors.New("token is signed with an unknown key")
+)
+
+type key struct {
+	algorithm string
+	material  interface{}
+}
+
+// Verifier validates signed JWTs against the locally configured key set.
+type Verifier struct {
+	keys   map[string]key
+	parser *jwt.Parser
+}
+
+func NewVerifier(conf config.Auth) (*Verifier, error) {
+	if len(conf.Keys) == 0 {
+		return nil, errors.New("auth: no verification keys configured")
+	}
+
+	keys := make(map[string]key, len(conf.Keys))
+	methods := make([]string, 0, len(conf.Keys))
+	for _, k := range conf.Keys {
+		material, err := loadKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("auth: key %q: %w", k.ID, err)
+		}
+		keys[k.ID] = key{algorithm: k.Algorithm, material: material}
+		methods = append(methods, k.Algorithm)
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(conf.ClockSkew * time.Second),
+	}
+	if conf.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(conf.Issuer))
+	}
+	if conf.Audience != "" {
+		opts = append(opts, jwt.WithAudience(conf.Audience))
+	}
+
+	return &Verifier{keys: keys, parser: jwt.NewParser(opts...)}, nil
+}
+
+// Verify checks the token signature and registered claims and returns the subject.
+func (v *Verifier) Verify(tokenString string) (string, error) {
+	claims := new(jwt.RegisteredClaims)
+	if _, err := v.parser.ParseWithClaims(tokenString, claims, v.keyFunc); err != nil {
+		return "", err
+	}
+	if claims.Subject == "" {
+		return "", ErrMissingSubject
+	}
+	return claims.Subject, nil
+}
+
+func (v *Verifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	k, ok := v.keys[kid]
+	if !ok && kid == "" && len(v.keys) == 1 {
+		// tokens without kid are accepted only when there is a single key
+		for _, only := range v.keys {
+			k, ok = only, true
+		}
+	}
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	// never let the token header pick the algorithm for a key
+	if token.Method.Alg() != k.algorithm {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	return k.material, nil
+}
+
+func loadKey(k config.AuthKey) (interface{}, error) {
+	switch k.Algorithm {
+	case jwt.SigningMethodHS256.Alg():
+		secret := k.Secret
+		if k.SecretEnv != "" {
+			secret = os.Getenv(k.SecretEnv)
+			if secret == "" {
+				return nil, fmt.Errorf("environment variable %s is not set", k.SecretEnv)
+			}
+		}
+		if secret == "" {
+			return nil, errors.New("secret is required")
+		}
+		return []byte(secret), nil
+	case jwt.SigningMethodRS256.Alg():
+		pem, err := os.ReadFile(k.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		return jwt.ParseRSAPublicKeyFromPEM(pem)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", k.Algorithm)
+	}
+}
@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"task-manager-api/config"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/suite"
+)
+
+type AuthTestSuite struct {
+	suite.Suite
+	rsaKey   *rsa.PrivateKey
+	verifier *Verifier
+}
+
+func (t *AuthTestSuite) SetupTest() {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	t.Require().NoError(err)
+	t.rsaKey = rsaKey
+
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	t.Require().NoError(err)
+	pubPath := filepath.Join(t.T().TempDir(), "public.pem")
+	err = os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600)
+	t.Require().NoError(err)
+
+	t.verifier, err = NewVerifier(config.Auth{
+		Issuer:    "issuer",
+		Audience:  "audience",
+		ClockSkew: 5,
+		Keys: []config.AuthKey{
+			{ID: "hs", Algorithm: "HS256", Secret: "secret"},
+			{ID: "rs", Algorithm: "RS256", PublicKey: pubPath},
+		},
+	})
+	t.Require().NoError(err)
+}
+
+func TestAuthTestSuite(t *testing.T) {
+	suite.Run(t, new(AuthTestSuite))
+}
+
+func (t *AuthTestSuite) claims() jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Subject:   "1234",
+		Issuer:    "issuer",
+		Audience:  jwt.ClaimStrings{"audience"},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}
+}
+
+func (t *AuthTestSuite) sign(method jwt.SigningMethod, kid string, claims jwt.RegisteredClaims, key interface{}) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	t.Require().NoError(err)
+	return signed
+}
+
+func (t *AuthTestSuite) TestNewVerifier() {
+	t.Run("no keys should return error", func() {
+		_, err := NewVerifier(config.Auth{})
+		t.Error(err)
+	})
+
+	t.Run("unsupported algorithm should return error", func() {
+		_, err := NewVerifier(config.Auth{Keys: []config.AuthKey{{ID: "x", Algorithm: "none"}}})
+		t.Error(err)
+	})
+
+	t.Run("hs256 without secret should return error", func() {
+		_, err := NewVerifier(config.Auth{Keys: []config.AuthKey{{ID: "x", Algorithm: "HS256"}}})
+		t.Error(err)
+	})
+
+	t.Run("hs256 with unset secret env should return error", func() {
+		t.T().Setenv("AUTH_TEST_SECRET", "")
+		_, err := NewVerifier(config.Auth{Keys: []config.AuthKey{{ID: "x", Algorithm: "HS256", SecretEnv: "AUTH_TEST_SECRET"}}})
+		t.Error(err)
+	})
+
+	t.Run("hs256 with secret env should read the secret", func() {
+		t.T().Setenv("AUTH_TEST_SECRET", "from-env")
+		verifier, err := NewVerifier(config.Auth{Keys: []config.AuthKey{{ID: "x", Algorithm: "HS256", Secret: "ignored", SecretEnv: "AUTH_TEST_SECRET"}}})
+		t.Require().NoError(err)
+		claims := t.claims()
+		sub, err := verifier.Verify(t.sign(jwt.SigningMethodHS256, "x", claims, []byte("from-env")))
+		t.NoError(err)
+		t.Equal("1234", sub)
+	})
+}
+
+func (t *AuthTestSuite) TestVerify() {
+	t.Run("valid hs256 token should return subject", func() {
+		sub, err := t.verifier.Verify(t.sign(jwt.SigningMethodHS256, "hs", t.claims(), []byte("secret")))
+		t.NoError(err)
+		t.Equal("1234", sub)
+	})
+
+	t.Run("valid rs256 token should return subject", func() {
+		sub, err := t.verifier.Verify(t.sign(jwt.SigningMethodRS256, "rs", t.claims(), t.rsaKey))
+		t.NoError(err)
+		t.Equal("1234", sub)
+	})
+
+	t.Run("token signed with another secret should return error", func() {
+		_, err := t.verifier.Verify(t.sign(jwt.SigningMethodHS256, "hs", t.claims(), []byte("forged")))
+		t.ErrorIs(err, jwt.ErrTokenSignatureInvalid)
+	})
+
+	t.Run("algorithm not matching the key should return error", func() {
+		_, err := t.verifier.Verify(t.sign(jwt.SigningMethodHS256, "rs", t.claims(), []byte("secret")))
+		t.Error(err)
+	})
+
+	t.Run("unknown kid should return error", func() {
+		_, err := t.verifier.Verify(t.sign(jwt.SigningMethodHS256, "other", t.claims(), []byte("secret")))
+		t.ErrorIs(err, ErrUnknownKey)
+	})
+
+	t.Run("expired token beyond clock skew should return error", func() {
+		claims := t.claims()
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-10 * time.Second))
+		_, err := t.verifier.Verify(t.sign(jwt.SigningMethodHS256, "hs", claims, []byte("secret")))
+		t.ErrorIs(err, jwt.ErrTokenExpired)
+	})
+
+	t.Run("expired token within clock skew should return subject", func() {
+		claims := t.claims()
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-2 * time.Second))
+		sub, err := t.verifier.Verify(t.sign(jwt.SigningMethodHS256, "hs", claims, []byte("secret")))
+		t.NoError(err)
+		t.Equal("1234", sub)
+	})
+
+	t.Run("token without expiry should return error", func() {
+		claims := t.claims()
+		claims.ExpiresAt = nil
+		_, err := t.verifier.Verify(t.sign(jwt.SigningMethodHS256, "hs", claims, []byte("secret")))
+		t.Error(err)
+	})
+
+	t.Run("wrong issuer should return error", func() {
+		claims := t.claims()
+		claims.Issuer = "someone-else"
+		_, err := t.verifier.Verify(t.sign(jwt.SigningMethodHS256, "hs", claims, []byte("secret")))
+		t.ErrorIs(err, jwt.ErrTokenInvalidIssuer)
+	})
+
+	t.Run("wrong audience should return error", func() {
+		claims := t.claims()
+		claims.Audience = jwt.ClaimStrings{"other"}
+		_, err := t.verifier.Verify(t.sign(jwt.SigningMethodHS256, "hs", claims, []byte("secret")))
+		t.ErrorIs(err, jwt.ErrTokenInvalidAudience)
+	})
+
+	t.Run("token without subject should return error", func() {
+		claims := t.claims()
+		claims.Subject = ""
+		_, err := t.verifier.Verify(t.sign(jwt.SigningMethodHS256, "hs", claims, []byte("secret")))
+		t.ErrorIs(err, ErrMissingSubject)
+	})
+}
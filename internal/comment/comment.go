@@ -3,7 +3,13 @@ package comment
 import (
 	"context"
 	"errors"
+	"log"
+	"task-manager-api/internal/apperror"
+	"task-manager-api/internal/history"
+	"task-manager-api/internal/mention"
 	m "task-manager-api/internal/mongo"
+	"task-manager-api/internal/profile"
+	"task-manager-api/internal/taskmanager"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -15,9 +21,47 @@ import (
 //go:generate mockgen -source=./comment.go -destination=./mock/comment.go
 type IMongo interface {
 	InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error)
+	FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) m.SingleResult
+	UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
 	Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (m.Cursor, error)
+	CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error)
+	DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
+	Aggregate(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (m.Cursor, error)
+	CreateIndexes(ctx context.Context, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error)
 }
 
+type ITaskLookup interface {
+	FindTask(ctx context.Context, id string) (*taskmanager.TaskDoc, error)
+}
+
+type IHistory interface {
+	Record(ctx context.Context, changes ...history.Change) error
+}
+
+type IProfiles interface {
+	GetProfileList(ctx context.Context, ownerId []string) ([]profile.ProfileDoc, error)
+	GetProfilesByDisplayName(ctx context.Context, names []string) ([]profile.ProfileDoc, error)
+}
+
+type IMentions interface {
+	Notify(ctx context.Context, taskId string, commentId string, authorId string, ownerIds []string) error
+}
+
+var (
+	ErrTaskArchived     = apperror.Conflict("task_archived", "Task is archived")
+	ErrInvalidCommentID = apperror.Validation("invalid_comment_id", "Invalid comment id")
+	ErrCommentNotFound  = apperror.NotFound("comment_not_found", "Comment not found")
+	ErrNotAuthor        = apperror.Forbidden("not_comment_author", "Only the author can change this comment")
+	ErrCommentChanged   = apperror.Conflict("comment_changed", "Comment was modified, reload it and try again")
+	ErrReplyTooDeep     = apperror.Validation("reply_too_deep", "Replies cannot be nested deeper")
+)
+
+// MaxReplyDepth is how deep replies may nest, top-level comments are at depth 0.
+const MaxReplyDepth = 3
+
+// DeletedContent replaces the content of deleted comments in listings.
+const DeletedContent = "[deleted]"
+
 type CommentDoc struct {
 	ID         string `json:"id" bson:"_id,omitempty"`
 	OwnerId    string `json:"owner_id" bson:"owner_id"`
@@ -25,57 +69,494 @@ type CommentDoc struct {
 	Content    string `json:"content" bson:"content"`
 	CreateDate int64  `json:"create_date" bson:"create_date"`
 	UpdateDate *int64 `json:"update_date" bson:"update_date"`
+	// ParentID is the comment replied to, Depth counts the comments above it.
+	ParentID string `json:"parent_comment_id,omitempty" bson:"parent_comment_id,omitempty"`
+	Depth    int    `json:"depth,omitempty" bson:"depth,omitempty"`
+	// ReplyCount is the number of direct replies, only set when listing
+	// top-level comments or replies.
+	ReplyCount *int64 `json:"reply_count,omitempty" bson:"-"`
+	// Mentions holds the owner ids of the people mentioned in the content.
+	Mentions []string `json:"mentions,omitempty" bson:"mentions,omitempty"`
+	// DeleteDate is set on deleted comments, they stay as tombstones so that
+	// the conversation keeps its shape.
+	DeleteDate *int64 `json:"delete_date,omitempty" bson:"delete_date,omitempty"`
+	// Revisions holds the previous contents, only the author can see them.
+	Revisions []Revision `json:"-" bson:"revisions,omitempty"`
+}
+
+// Revision is a previous content of a comment and the date it was written.
+type Revision struct {
+	Content string `json:"content" bson:"content"`
+	Date    int64  `json:"date" bson:"date"`
 }
 
 type Comment struct {
-	mongo IMongo
-	time  func() time.Time
+	mongo    IMongo
+	tasks    ITaskLookup
+	history  IHistory
+	profiles IProfiles
+	mentions IMentions
+	time     func() time.Time
 }
 
-func NewCommentService(mongo IMongo) *Comment {
-	return &Comment{mongo: mongo}
+func NewCommentService(mongo IMongo, tasks ITaskLookup, history IHistory, profiles IProfiles, mentions IMentions) *Comment {
+	return &Comment{mongo: mongo, tasks: tasks, history: history, profiles: profiles, mentions: mentions}
+}
+
+// EnsureIndexes creates the indexes backing comment listing by task and by
+// parent comment in _id order.
+func (c *Comment) EnsureIndexes(ctx context.Context) error {
+	models := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "parent_comment_id", Value: 1}, {Key: "_id", Value: 1}}},
+	}
+	if _, err := c.mongo.CreateIndexes(ctx, models); err != nil {
+		return m.WrapError(err)
+	}
+	return nil
 }
 
-func (c *Comment) CreateComment(ctx context.Context, ownerId string, TaskId string, content string) (*CommentDoc, error) {
-	// TODO: validate topicID
+// CreateComment adds a comment to a task, or a reply when parentId is set.
+// Replies go to comments of the same task up to MaxReplyDepth.
+func (c *Comment) CreateComment(ctx context.Context, ownerId string, TaskId string, content string, parentId string) (*CommentDoc, error) {
+	// comments are only accepted on existing tasks that are not archived
+	task, err := c.tasks.FindTask(ctx, TaskId)
+	if err != nil {
+		return nil, err
+	}
+	if task.ArchiveDate != nil {
+		return nil, ErrTaskArchived
+	}
+	TaskId = task.ID
+	depth := 0
+	if parentId != "" {
+		parent, err := c.FindComment(ctx, TaskId, parentId)
+		if err != nil {
+			return nil, err
+		}
+		if parent.DeleteDate != nil {
+			return nil, ErrCommentNotFound
+		}
+		if parent.Depth >= MaxReplyDepth {
+			return nil, ErrReplyTooDeep
+		}
+		parentId = parent.ID
+		depth = parent.Depth + 1
+	}
+	mentions, err := c.resolveMentions(ctx, content)
+	if err != nil {
+		return nil, err
+	}
 	now := c.now().Unix()
 	result, err := c.mongo.InsertOne(ctx, CommentDoc{
 		OwnerId:    ownerId,
 		TaskId:     TaskId,
 		Content:    content,
 		CreateDate: now,
+		ParentID:   parentId,
+		Depth:      depth,
+		Mentions:   mentions,
 	})
 	if err != nil {
-		return nil, err
+		return nil, m.WrapError(err)
 	}
 	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		// the comment is stored, a failure to record it is only logged
+		if err := c.history.Record(ctx, history.Change{
+			TaskID: TaskId,
+			Actor:  ownerId,
+			Field:  history.FieldComment,
+			New:    oid.Hex(),
+		}); err != nil {
+			log.Printf("record history of task %v: %v", TaskId, err)
+		}
+		c.notify(ctx, TaskId, oid.Hex(), ownerId, mentions)
 		return &CommentDoc{
 			ID:         oid.Hex(),
 			TaskId:     TaskId,
 			Content:    content,
 			CreateDate: now,
 			OwnerId:    ownerId,
+			ParentID:   parentId,
+			Depth:      depth,
+			Mentions:   mentions,
 		}, nil
 	} else {
-		// TODO: log error
-		return nil, errors.New("cannot convert inserted id to object id")
+		return nil, apperror.ErrInternal.Wrap(errors.New("cannot convert inserted id to object id"))
 	}
 }
 
-func (c *Comment) GetTopicComments(ctx context.Context, TaskId string, page int, limit int) ([]CommentDoc, error) {
+// GetTopicComments lists the comments of a task. With topLevel only the
+// comments that are not replies are listed, along with their reply counts.
+func (c *Comment) GetTopicComments(ctx context.Context, TaskId string, topLevel bool, page m.Pagination) ([]CommentDoc, *m.PageInfo, error) {
 	// find all comment in topic with pagination
-	curr, err := c.mongo.Find(ctx, bson.M{
-		"task_id": TaskId,
-	}, m.NewMongoPaginate(limit, page).GetPaginatedOpts())
+	task, err := c.tasks.FindTask(ctx, TaskId)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	filter := bson.M{
+		"task_id": task.ID,
+	}
+	if topLevel {
+		// matches comments without a parent, using the parent index
+		filter["parent_comment_id"] = nil
+	}
+	return c.list(ctx, filter, topLevel, page)
+}
+
+// GetReplies lists the direct replies of a comment with their reply counts.
+func (c *Comment) GetReplies(ctx context.Context, taskId string, id string, page m.Pagination) ([]CommentDoc, *m.PageInfo, error) {
+	task, err := c.tasks.FindTask(ctx, taskId)
+	if err != nil {
+		return nil, nil, err
+	}
+	parent, err := c.FindComment(ctx, task.ID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.list(ctx, bson.M{
+		"task_id":           task.ID,
+		"parent_comment_id": parent.ID,
+	}, true, page)
+}
+
+// list pages through the comments matching filter, oldest first, deleted
+// comments show as tombstones. withReplies counts the direct replies of each
+// comment.
+func (c *Comment) list(ctx context.Context, filter bson.M, withReplies bool, page m.Pagination) ([]CommentDoc, *m.PageInfo, error) {
+	sort := bson.D{{Key: "_id", Value: 1}}
+	query, opts, err := page.Apply(filter, sort)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts.SetProjection(bson.M{"revisions": 0})
+	curr, err := c.mongo.Find(ctx, query, opts)
+	if err != nil {
+		return nil, nil, m.WrapError(err)
 	}
 
 	var comments = make([]CommentDoc, 0)
 	if err := curr.All(ctx, &comments); err != nil {
+		return nil, nil, m.WrapError(err)
+	}
+	for i := range comments {
+		if comments[i].DeleteDate != nil {
+			comments[i].Content = DeletedContent
+		}
+	}
+	comments, pageInfo, err := m.Paginate(page, sort, comments)
+	if err != nil {
+		return nil, nil, err
+	}
+	if withReplies {
+		if err := c.countReplies(ctx, filter["task_id"], comments); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if !page.SkipCount {
+		total, err := c.mongo.CountDocuments(ctx, filter)
+		if err != nil {
+			return nil, nil, m.WrapError(err)
+		}
+		pageInfo.Total = &total
+	}
+	return comments, pageInfo, nil
+}
+
+// countReplies sets the number of direct replies of each comment.
+func (c *Comment) countReplies(ctx context.Context, taskId interface{}, comments []CommentDoc) error {
+	if len(comments) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+	}
+	curr, err := c.mongo.Aggregate(ctx, []bson.M{
+		{"$match": bson.M{
+			"task_id":           taskId,
+			"parent_comment_id": bson.M{"$in": ids},
+		}},
+		{"$group": bson.M{
+			"_id":   "$parent_comment_id",
+			"count": bson.M{"$sum": 1},
+		}},
+	})
+	if err != nil {
+		return m.WrapError(err)
+	}
+	var results []struct {
+		ID    string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}
+	if err := curr.All(ctx, &results); err != nil {
+		return m.WrapError(err)
+	}
+	counts := make(map[string]int64, len(results))
+	for _, result := range results {
+		counts[result.ID] = result.Count
+	}
+	for i := range comments {
+		count := counts[comments[i].ID]
+		comments[i].ReplyCount = &count
+	}
+	return nil
+}
+
+// UpdateComment replaces the content of a comment of its author, keeping the
+// previous content as a revision.
+func (c *Comment) UpdateComment(ctx context.Context, ownerId string, taskId string, id string, content string) (*CommentDoc, error) {
+	comment, err := c.findOwnComment(ctx, ownerId, taskId, id)
+	if err != nil {
+		return nil, err
+	}
+	if comment.Content == content {
+		return comment, nil
+	}
+	mentions, err := c.resolveMentions(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	now := c.now().Unix()
+	if err := c.replace(ctx, comment, bson.M{
+		"$set": bson.M{
+			"content":     content,
+			"update_date": now,
+			"mentions":    mentions,
+		},
+		"$push": bson.M{
+			"revisions": Revision{Content: comment.Content, Date: lastWritten(comment)},
+		},
+	}); err != nil {
 		return nil, err
 	}
-	return comments, nil
+	// the comment is changed, a failure to record it is only logged
+	if err := c.history.Record(ctx, history.Change{
+		TaskID: comment.TaskId,
+		Actor:  ownerId,
+		Field:  history.FieldCommentEdited,
+		New:    comment.ID,
+	}); err != nil {
+		log.Printf("record history of task %v: %v", comment.TaskId, err)
+	}
+	// only the people added by the edit hear about it
+	var added []string
+	for _, ownerId := range mentions {
+		if !contains(comment.Mentions, ownerId) {
+			added = append(added, ownerId)
+		}
+	}
+	c.notify(ctx, comment.TaskId, comment.ID, ownerId, added)
+	comment.Content = content
+	comment.UpdateDate = &now
+	comment.Revisions = nil
+	comment.Mentions = mentions
+	return comment, nil
+}
+
+// DeleteComment soft deletes a comment of its author. The content is kept
+// as a revision and listings show a tombstone instead.
+func (c *Comment) DeleteComment(ctx context.Context, ownerId string, taskId string, id string) error {
+	comment, err := c.findOwnComment(ctx, ownerId, taskId, id)
+	if err != nil {
+		return err
+	}
+	now := c.now().Unix()
+	if err := c.replace(ctx, comment, bson.M{
+		"$set": bson.M{
+			"content":     "",
+			"update_date": now,
+			"delete_date": now,
+		},
+		"$push": bson.M{
+			"revisions": Revision{Content: comment.Content, Date: lastWritten(comment)},
+		},
+	}); err != nil {
+		return err
+	}
+	// the comment is deleted, a failure to record it is only logged
+	if err := c.history.Record(ctx, history.Change{
+		TaskID: comment.TaskId,
+		Actor:  ownerId,
+		Field:  history.FieldComment,
+		Old:    comment.ID,
+	}); err != nil {
+		log.Printf("record history of task %v: %v", comment.TaskId, err)
+	}
+	return nil
+}
+
+// GetCommentRevisions lists the previous contents of a comment of its
+// author, oldest first. The content of a deleted comment is its last revision.
+func (c *Comment) GetCommentRevisions(ctx context.Context, ownerId string, taskId string, id string) ([]Revision, error) {
+	comment, err := c.FindComment(ctx, taskId, id)
+	if err != nil {
+		return nil, err
+	}
+	if comment.OwnerId != ownerId {
+		return nil, ErrNotAuthor
+	}
+	if comment.Revisions == nil {
+		return []Revision{}, nil
+	}
+	return comment.Revisions, nil
+}
+
+// findOwnComment finds a comment that its author may still change, on a
+// task that is not archived.
+func (c *Comment) findOwnComment(ctx context.Context, ownerId string, taskId string, id string) (*CommentDoc, error) {
+	task, err := c.tasks.FindTask(ctx, taskId)
+	if err != nil {
+		return nil, err
+	}
+	if task.ArchiveDate != nil {
+		return nil, ErrTaskArchived
+	}
+	comment, err := c.FindComment(ctx, task.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	if comment.DeleteDate != nil {
+		return nil, ErrCommentNotFound
+	}
+	if comment.OwnerId != ownerId {
+		return nil, ErrNotAuthor
+	}
+	return comment, nil
+}
+
+// FindComment returns a comment of a task, deleted or not.
+func (c *Comment) FindComment(ctx context.Context, taskId string, id string) (*CommentDoc, error) {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidCommentID.Wrap(err)
+	}
+	var comment CommentDoc
+	err = c.mongo.FindOne(ctx, bson.M{
+		"_id":     objectId,
+		"task_id": taskId,
+	}).Decode(&comment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrCommentNotFound.Wrap(err)
+	}
+	if err != nil {
+		return nil, m.WrapError(err)
+	}
+	return &comment, nil
+}
+
+// replace applies update to a comment only if it still has the content it
+// was read with, so that a concurrent edit is not lost from the revisions.
+func (c *Comment) replace(ctx context.Context, comment *CommentDoc, update bson.M) error {
+	objectId, err := primitive.ObjectIDFromHex(comment.ID)
+	if err != nil {
+		return ErrInvalidCommentID.Wrap(err)
+	}
+	result, err := c.mongo.UpdateOne(ctx, bson.M{
+		"_id":         objectId,
+		"content":     comment.Content,
+		"delete_date": nil,
+	}, update)
+	if err != nil {
+		return m.WrapError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrCommentChanged
+	}
+	return nil
+}
+
+// resolveMentions returns the owner ids of the people mentioned in content,
+// in order of appearance. Mentions are matched on owner ids first, then on
+// display names, a display name shared by several people is ignored.
+func (c *Comment) resolveMentions(ctx context.Context, content string) ([]string, error) {
+	tokens := mention.Parse(content)
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	byId, err := c.profiles.GetProfileList(ctx, tokens)
+	if err != nil {
+		return nil, err
+	}
+	resolved := make(map[string]string, len(tokens))
+	for _, p := range byId {
+		resolved[p.OwnerId] = p.OwnerId
+	}
+	var names []string
+	for _, token := range tokens {
+		if _, ok := resolved[token]; !ok {
+			names = append(names, token)
+		}
+	}
+	if len(names) > 0 {
+		byName, err := c.profiles.GetProfilesByDisplayName(ctx, names)
+		if err != nil {
+			return nil, err
+		}
+		owners := make(map[string][]string, len(byName))
+		for _, p := range byName {
+			owners[p.DisplayName] = append(owners[p.DisplayName], p.OwnerId)
+		}
+		for name, ids := range owners {
+			if len(ids) == 1 {
+				resolved[name] = ids[0]
+			}
+		}
+	}
+
+	var mentions []string
+	for _, token := range tokens {
+		if ownerId, ok := resolved[token]; ok && !contains(mentions, ownerId) {
+			mentions = append(mentions, ownerId)
+		}
+	}
+	return mentions, nil
+}
+
+// notify tells the people mentioned in a stored comment, a failure is only logged.
+func (c *Comment) notify(ctx context.Context, taskId string, commentId string, authorId string, mentions []string) {
+	if len(mentions) == 0 {
+		return
+	}
+	if err := c.mentions.Notify(ctx, taskId, commentId, authorId, mentions); err != nil {
+		log.Printf("notify mentions of comment %v: %v", commentId, err)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// lastWritten is the date the current content of a comment was written.
+func lastWritten(comment *CommentDoc) int64 {
+	if comment.UpdateDate != nil {
+		return *comment.UpdateDate
+	}
+	return comment.CreateDate
+}
+
+// DeleteTaskComments removes the comments of deleted tasks. Nothing is
+// recorded as the deletion of the task already is.
+func (c *Comment) DeleteTaskComments(ctx context.Context, taskIds []string) (int64, error) {
+	result, err := c.mongo.DeleteMany(ctx, bson.M{
+		"task_id": bson.M{"$in": taskIds},
+	})
+	if err != nil {
+		return 0, m.WrapError(err)
+	}
+	return result.DeletedCount, nil
+}
+
+// TaskIDs returns, in order, up to limit ids of the tasks with comments that
+// sort after the given id.
+func (c *Comment) TaskIDs(ctx context.Context, after string, limit int64) ([]string, error) {
+	return m.DistinctAfter(ctx, c.mongo, "task_id", after, limit)
 }
 
 func (c *Comment) now() time.Time {
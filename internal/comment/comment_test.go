@@ -4,11 +4,15 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	m "task-manager-api/internal/mongo"
 	mock "task-manager-api/internal/mongo/mock"
 	"testing"
 	"time"
 
 	mock_comment "task-manager-api/internal/comment/mock"
+	"task-manager-api/internal/history"
+	"task-manager-api/internal/profile"
+	"task-manager-api/internal/taskmanager"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/suite"
@@ -22,6 +26,10 @@ type CommentTestSuite struct {
 	suite.Suite
 	ctrl         *gomock.Controller
 	mockMongo    *mock_comment.MockIMongo
+	taskLookup   *mock_comment.MockITaskLookup
+	history      *mock_comment.MockIHistory
+	profiles     *mock_comment.MockIProfiles
+	mentions     *mock_comment.MockIMentions
 	service      *Comment
 	singleResult *mock.MockSingleResult
 	cursor       *mock.MockCursor
@@ -30,7 +38,11 @@ type CommentTestSuite struct {
 func (t *CommentTestSuite) SetupTest() {
 	t.ctrl = gomock.NewController(t.T())
 	t.mockMongo = mock_comment.NewMockIMongo(t.ctrl)
-	t.service = NewCommentService(t.mockMongo)
+	t.taskLookup = mock_comment.NewMockITaskLookup(t.ctrl)
+	t.history = mock_comment.NewMockIHistory(t.ctrl)
+	t.profiles = mock_comment.NewMockIProfiles(t.ctrl)
+	t.mentions = mock_comment.NewMockIMentions(t.ctrl)
+	t.service = NewCommentService(t.mockMongo, t.taskLookup, t.history, t.profiles, t.mentions)
 	t.singleResult = mock.NewMockSingleResult(t.ctrl)
 	t.cursor = mock.NewMockCursor(t.ctrl)
 	t.service.time = func() time.Time {
@@ -42,6 +54,10 @@ func (t *CommentTestSuite) SetupTest() {
 func (t *CommentTestSuite) TearDownTest() {
 	t.ctrl.Finish()
 	t.mockMongo = nil
+	t.taskLookup = nil
+	t.history = nil
+	t.profiles = nil
+	t.mentions = nil
 	t.service = nil
 	t.singleResult = nil
 	t.cursor = nil
@@ -52,41 +68,59 @@ func TestCommentTestSuite(t *testing.T) {
 }
 
 func (t *CommentTestSuite) TestGetTopicComments() {
-	l := int64(10)
+	l := int64(11)
 	skip := int64(1*10 - 10)
-	fOpt := &options.FindOptions{Limit: &l, Skip: &skip}
+	fOpt := (&options.FindOptions{Limit: &l, Skip: &skip, Projection: bson.M{"revisions": 0}}).SetSort(bson.D{{Key: "_id", Value: 1}})
+	task := &taskmanager.TaskDoc{ID: "645b9183fcfbc11433e23ab3"}
+
+	t.Run("get topic comments but task not found should return error", func() {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab4").Return(nil, taskmanager.ErrTaskNotFound)
+		comments, _, err := t.service.GetTopicComments(context.Background(), "645b9183fcfbc11433e23ab4", false, m.Pagination{Page: 1, Limit: 10})
+		t.Nil(comments)
+		t.ErrorIs(err, taskmanager.ErrTaskNotFound)
+	})
+
+	t.Run("get topic comments but task id is malformed should return error", func() {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "topic_id").Return(nil, taskmanager.ErrInvalidTaskID)
+		comments, _, err := t.service.GetTopicComments(context.Background(), "topic_id", false, m.Pagination{Page: 1, Limit: 10})
+		t.Nil(comments)
+		t.ErrorIs(err, taskmanager.ErrInvalidTaskID)
+	})
 
 	t.Run("get topic comments but find has error should return error", func() {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
 		t.mockMongo.EXPECT().Find(context.Background(), bson.M{
-			"task_id": "topic_id",
+			"task_id": "645b9183fcfbc11433e23ab3",
 		}, fOpt).Return(nil, errors.New("find error"))
-		comments, err := t.service.GetTopicComments(context.Background(), "topic_id", 1, 10)
+		comments, _, err := t.service.GetTopicComments(context.Background(), "645b9183fcfbc11433e23ab3", false, m.Pagination{Page: 1, Limit: 10})
 		t.Error(err)
 		t.Nil(comments)
 		t.EqualError(err, "find error")
 	})
 	t.Run("get topic comments but decode has error should return error", func() {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
 		t.mockMongo.EXPECT().Find(context.Background(), bson.M{
-			"task_id": "topic_id",
+			"task_id": "645b9183fcfbc11433e23ab3",
 		}, fOpt).Return(t.cursor, nil)
 		t.cursor.EXPECT().All(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, result interface{}) error {
 			return errors.New("cursor decode error")
 		}).Times(1)
-		comments, err := t.service.GetTopicComments(context.Background(), "topic_id", 1, 10)
+		comments, _, err := t.service.GetTopicComments(context.Background(), "645b9183fcfbc11433e23ab3", false, m.Pagination{Page: 1, Limit: 10})
 		t.Error(err)
 		t.Nil(comments)
 		t.EqualError(err, "cursor decode error")
 	})
 
 	t.Run("get topic comments should return comments", func() {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
 		t.mockMongo.EXPECT().Find(context.Background(), bson.M{
-			"task_id": "topic_id",
+			"task_id": "645b9183fcfbc11433e23ab3",
 		}, fOpt).Return(t.cursor, nil)
 		var comments = make([]CommentDoc, 0)
 		t.cursor.EXPECT().All(context.Background(), &comments).DoAndReturn(func(ctx context.Context, result interface{}) error {
 			comments = append(comments, CommentDoc{
 				ID:         "comment_id",
-				TaskId:     "topic_id",
+				TaskId:     "645b9183fcfbc11433e23ab3",
 				Content:    "content",
 				CreateDate: 1569151351,
 				OwnerId:    "owner_id",
@@ -94,64 +128,536 @@ func (t *CommentTestSuite) TestGetTopicComments() {
 			reflect.ValueOf(result).Elem().Set(reflect.ValueOf(comments))
 			return nil
 		}).Times(1)
-		comments, err := t.service.GetTopicComments(context.Background(), "topic_id", 1, 10)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), bson.M{
+			"task_id": "645b9183fcfbc11433e23ab3",
+		}).Return(int64(1), nil)
+		comments, pageInfo, err := t.service.GetTopicComments(context.Background(), "645b9183fcfbc11433e23ab3", false, m.Pagination{Page: 1, Limit: 10})
 		t.NoError(err)
 		t.NotNil(comments)
+		t.Equal(int64(1), *pageInfo.Total)
 		t.Equal(1, len(comments))
 		t.Equal("comment_id", comments[0].ID)
-		t.Equal("topic_id", comments[0].TaskId)
+		t.Equal("645b9183fcfbc11433e23ab3", comments[0].TaskId)
 		t.Equal("content", comments[0].Content)
 		t.Equal(int64(1569151351), comments[0].CreateDate)
 		t.Equal("owner_id", comments[0].OwnerId)
 	})
 }
 
+func (t *CommentTestSuite) TestGetTopicCommentsKeyset() {
+	task := &taskmanager.TaskDoc{ID: "645b9183fcfbc11433e23ab3"}
+
+	t.Run("get topic comments keyset page should sort by _id and return no cursor on last page", func() {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		t.mockMongo.EXPECT().Find(context.Background(), bson.M{
+			"task_id": "645b9183fcfbc11433e23ab3",
+		}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(11).SetProjection(bson.M{"revisions": 0})).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).Return(nil)
+		comments, pageInfo, err := t.service.GetTopicComments(context.Background(), "645b9183fcfbc11433e23ab3", false, m.Pagination{Limit: 10, SkipCount: true})
+		t.NoError(err)
+		t.NotNil(comments)
+		t.Nil(pageInfo.Total)
+		t.False(pageInfo.HasNext)
+		t.Empty(pageInfo.NextCursor)
+	})
+}
+
 func (t *CommentTestSuite) TestCreateComment() {
+	task := &taskmanager.TaskDoc{ID: "645b9183fcfbc11433e23ab3"}
+
+	t.Run("create comment but task not found should return error", func() {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab4").Return(nil, taskmanager.ErrTaskNotFound)
+		comment, err := t.service.CreateComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab4", "content", "")
+		t.Nil(comment)
+		t.ErrorIs(err, taskmanager.ErrTaskNotFound)
+	})
+
+	t.Run("create comment but task is archived should return error", func() {
+		archiveDate := int64(1569130951)
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(&taskmanager.TaskDoc{
+			ID:          "645b9183fcfbc11433e23ab3",
+			ArchiveDate: &archiveDate,
+		}, nil)
+		comment, err := t.service.CreateComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "content", "")
+		t.Nil(comment)
+		t.ErrorIs(err, ErrTaskArchived)
+	})
+
+	t.Run("create comment but task id is malformed should return error", func() {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "topic_id").Return(nil, taskmanager.ErrInvalidTaskID)
+		comment, err := t.service.CreateComment(context.Background(), "owner_id", "topic_id", "content", "")
+		t.Nil(comment)
+		t.ErrorIs(err, taskmanager.ErrInvalidTaskID)
+	})
+
 	t.Run("create comment but insert has error should return error", func() {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
 		t.mockMongo.EXPECT().InsertOne(context.Background(), CommentDoc{
-			TaskId:     "topic_id",
+			TaskId:     "645b9183fcfbc11433e23ab3",
 			Content:    "content",
 			OwnerId:    "owner_id",
 			CreateDate: int64(1569130951),
 		}).Return(nil, errors.New("insert error"))
-		comment, err := t.service.CreateComment(context.Background(), "owner_id", "topic_id", "content")
+		comment, err := t.service.CreateComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "content", "")
 		t.Error(err)
 		t.Nil(comment)
 		t.EqualError(err, "insert error")
 	})
 
 	t.Run("create comment but can not convert _id", func() {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
 		t.mockMongo.EXPECT().InsertOne(context.Background(), CommentDoc{
-			TaskId:     "topic_id",
+			TaskId:     "645b9183fcfbc11433e23ab3",
 			Content:    "content",
 			OwnerId:    "owner_id",
 			CreateDate: int64(1569130951),
 		}).Return(&mongo.InsertOneResult{
 			InsertedID: "objId",
 		}, nil)
-		comment, err := t.service.CreateComment(context.Background(), "owner_id", "topic_id", "content")
+		comment, err := t.service.CreateComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "content", "")
 		t.Error(err)
 		t.Nil(comment)
 		t.EqualError(err, "cannot convert inserted id to object id")
 	})
 
+	t.Run("create comment should return comment even if history fails", func() {
+		objId, _ := primitive.ObjectIDFromHex("5ad9a913478c26d220afb681")
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		t.mockMongo.EXPECT().InsertOne(context.Background(), gomock.Any()).Return(&mongo.InsertOneResult{
+			InsertedID: objId,
+		}, nil)
+		t.history.EXPECT().Record(context.Background(), gomock.Any()).Return(errors.New("insert many error"))
+		comment, err := t.service.CreateComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "content", "")
+		t.NoError(err)
+		t.Equal("5ad9a913478c26d220afb681", comment.ID)
+	})
+
 	t.Run("create comment should return comment", func() {
 		objId, _ := primitive.ObjectIDFromHex("5ad9a913478c26d220afb681")
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
 		t.mockMongo.EXPECT().InsertOne(context.Background(), CommentDoc{
-			TaskId:     "topic_id",
+			TaskId:     "645b9183fcfbc11433e23ab3",
 			Content:    "content",
 			OwnerId:    "owner_id",
 			CreateDate: int64(1569130951),
 		}).Return(&mongo.InsertOneResult{
 			InsertedID: objId,
 		}, nil)
-		comment, err := t.service.CreateComment(context.Background(), "owner_id", "topic_id", "content")
+		t.history.EXPECT().Record(context.Background(), history.Change{
+			TaskID: "645b9183fcfbc11433e23ab3",
+			Actor:  "owner_id",
+			Field:  history.FieldComment,
+			New:    "5ad9a913478c26d220afb681",
+		}).Return(nil)
+		comment, err := t.service.CreateComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "content", "")
 		t.NoError(err)
 		t.NotNil(comment)
 		t.Equal("5ad9a913478c26d220afb681", comment.ID)
-		t.Equal("topic_id", comment.TaskId)
+		t.Equal("645b9183fcfbc11433e23ab3", comment.TaskId)
 		t.Equal("content", comment.Content)
 		t.Equal(int64(1569130951), comment.CreateDate)
 		t.Equal("owner_id", comment.OwnerId)
 	})
 }
+
+func (t *CommentTestSuite) TestGetTopicCommentsTombstone() {
+	t.Run("get topic comments should show deleted comments as tombstones", func() {
+		deleted := int64(1569151400)
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(&taskmanager.TaskDoc{ID: "645b9183fcfbc11433e23ab3"}, nil)
+		t.mockMongo.EXPECT().Find(context.Background(), gomock.Any(), gomock.Any()).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).SetArg(1, []CommentDoc{
+			{ID: "comment_1", Content: "kept"},
+			{ID: "comment_2", DeleteDate: &deleted},
+		}).Return(nil)
+		comments, _, err := t.service.GetTopicComments(context.Background(), "645b9183fcfbc11433e23ab3", false, m.Pagination{Limit: 10, SkipCount: true})
+		t.NoError(err)
+		t.Equal("kept", comments[0].Content)
+		t.Equal(DeletedContent, comments[1].Content)
+	})
+}
+
+func (t *CommentTestSuite) TestUpdateComment() {
+	task := &taskmanager.TaskDoc{ID: "645b9183fcfbc11433e23ab3"}
+	commentId, _ := primitive.ObjectIDFromHex("645b9183fcfbc11433e23ab5")
+	created := int64(1569151351)
+	expectComment := func(comment CommentDoc) {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		t.mockMongo.EXPECT().FindOne(context.Background(), bson.M{
+			"_id":     commentId,
+			"task_id": "645b9183fcfbc11433e23ab3",
+		}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).SetArg(0, comment).Return(nil)
+	}
+
+	t.Run("update comment with malformed id should return error", func() {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		_, err := t.service.UpdateComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "comment_id", "content")
+		t.ErrorIs(err, ErrInvalidCommentID)
+	})
+
+	t.Run("update comment but comment not found should return error", func() {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		t.mockMongo.EXPECT().FindOne(context.Background(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(mongo.ErrNoDocuments)
+		_, err := t.service.UpdateComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab5", "content")
+		t.ErrorIs(err, ErrCommentNotFound)
+	})
+
+	t.Run("update comment of someone else should return forbidden", func() {
+		expectComment(CommentDoc{ID: "645b9183fcfbc11433e23ab5", OwnerId: "author", Content: "content"})
+		_, err := t.service.UpdateComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab5", "edited")
+		t.ErrorIs(err, ErrNotAuthor)
+	})
+
+	t.Run("update deleted comment should return not found", func() {
+		deleted := int64(1569151400)
+		expectComment(CommentDoc{ID: "645b9183fcfbc11433e23ab5", OwnerId: "owner_id", DeleteDate: &deleted})
+		_, err := t.service.UpdateComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab5", "edited")
+		t.ErrorIs(err, ErrCommentNotFound)
+	})
+
+	t.Run("update comment changed concurrently should return conflict", func() {
+		expectComment(CommentDoc{ID: "645b9183fcfbc11433e23ab5", OwnerId: "owner_id", Content: "content", CreateDate: created})
+		t.mockMongo.EXPECT().UpdateOne(context.Background(), gomock.Any(), gomock.Any()).Return(&mongo.UpdateResult{MatchedCount: 0}, nil)
+		_, err := t.service.UpdateComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab5", "edited")
+		t.ErrorIs(err, ErrCommentChanged)
+	})
+
+	t.Run("update comment should keep the previous content as a revision", func() {
+		expectComment(CommentDoc{ID: "645b9183fcfbc11433e23ab5", OwnerId: "owner_id", TaskId: "645b9183fcfbc11433e23ab3", Content: "contnet", CreateDate: created})
+		t.mockMongo.EXPECT().UpdateOne(context.Background(), bson.M{
+			"_id":         commentId,
+			"content":     "contnet",
+			"delete_date": nil,
+		}, bson.M{
+			"$set": bson.M{
+				"content":     "content",
+				"update_date": t.service.now().Unix(),
+				"mentions":    []string(nil),
+			},
+			"$push": bson.M{
+				"revisions": Revision{Content: "contnet", Date: created},
+			},
+		}).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+		t.history.EXPECT().Record(context.Background(), history.Change{
+			TaskID: "645b9183fcfbc11433e23ab3",
+			Actor:  "owner_id",
+			Field:  history.FieldCommentEdited,
+			New:    "645b9183fcfbc11433e23ab5",
+		}).Return(nil)
+		comment, err := t.service.UpdateComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab5", "content")
+		t.NoError(err)
+		t.Equal("content", comment.Content)
+		t.Equal(t.service.now().Unix(), *comment.UpdateDate)
+	})
+
+	t.Run("update comment with the same content should not write", func() {
+		expectComment(CommentDoc{ID: "645b9183fcfbc11433e23ab5", OwnerId: "owner_id", Content: "content"})
+		comment, err := t.service.UpdateComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab5", "content")
+		t.NoError(err)
+		t.Equal("content", comment.Content)
+	})
+}
+
+func (t *CommentTestSuite) TestDeleteComment() {
+	task := &taskmanager.TaskDoc{ID: "645b9183fcfbc11433e23ab3"}
+	commentId, _ := primitive.ObjectIDFromHex("645b9183fcfbc11433e23ab5")
+	edited := int64(1569151400)
+
+	t.Run("delete comment on archived task should return error", func() {
+		archiveDate := int64(1569130951)
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(&taskmanager.TaskDoc{ID: "645b9183fcfbc11433e23ab3", ArchiveDate: &archiveDate}, nil)
+		err := t.service.DeleteComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab5")
+		t.ErrorIs(err, ErrTaskArchived)
+	})
+
+	t.Run("delete comment should leave a tombstone and record it", func() {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		t.mockMongo.EXPECT().FindOne(context.Background(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).SetArg(0, CommentDoc{
+			ID:         "645b9183fcfbc11433e23ab5",
+			OwnerId:    "owner_id",
+			TaskId:     "645b9183fcfbc11433e23ab3",
+			Content:    "content",
+			UpdateDate: &edited,
+		}).Return(nil)
+		t.mockMongo.EXPECT().UpdateOne(context.Background(), bson.M{
+			"_id":         commentId,
+			"content":     "content",
+			"delete_date": nil,
+		}, bson.M{
+			"$set": bson.M{
+				"content":     "",
+				"update_date": t.service.now().Unix(),
+				"delete_date": t.service.now().Unix(),
+			},
+			"$push": bson.M{
+				"revisions": Revision{Content: "content", Date: edited},
+			},
+		}).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+		t.history.EXPECT().Record(context.Background(), history.Change{
+			TaskID: "645b9183fcfbc11433e23ab3",
+			Actor:  "owner_id",
+			Field:  history.FieldComment,
+			Old:    "645b9183fcfbc11433e23ab5",
+		}).Return(nil)
+		t.NoError(t.service.DeleteComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab5"))
+	})
+}
+
+func (t *CommentTestSuite) TestGetCommentRevisions() {
+	t.Run("get comment revisions of someone else should return forbidden", func() {
+		t.mockMongo.EXPECT().FindOne(context.Background(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).SetArg(0, CommentDoc{OwnerId: "author"}).Return(nil)
+		_, err := t.service.GetCommentRevisions(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab5")
+		t.ErrorIs(err, ErrNotAuthor)
+	})
+
+	t.Run("get comment revisions without edits should return an empty list", func() {
+		t.mockMongo.EXPECT().FindOne(context.Background(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).SetArg(0, CommentDoc{OwnerId: "owner_id"}).Return(nil)
+		revisions, err := t.service.GetCommentRevisions(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab5")
+		t.NoError(err)
+		t.Equal([]Revision{}, revisions)
+	})
+
+	t.Run("get comment revisions should return them oldest first", func() {
+		t.mockMongo.EXPECT().FindOne(context.Background(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).SetArg(0, CommentDoc{
+			OwnerId:   "owner_id",
+			Revisions: []Revision{{Content: "first", Date: 1}, {Content: "second", Date: 2}},
+		}).Return(nil)
+		revisions, err := t.service.GetCommentRevisions(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab5")
+		t.NoError(err)
+		t.Equal([]Revision{{Content: "first", Date: 1}, {Content: "second", Date: 2}}, revisions)
+	})
+}
+
+func (t *CommentTestSuite) TestReplies() {
+	task := &taskmanager.TaskDoc{ID: "645b9183fcfbc11433e23ab3"}
+	parentId, _ := primitive.ObjectIDFromHex("645b9183fcfbc11433e23ab5")
+	expectParent := func(parent CommentDoc) {
+		t.mockMongo.EXPECT().FindOne(context.Background(), bson.M{
+			"_id":     parentId,
+			"task_id": "645b9183fcfbc11433e23ab3",
+		}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).SetArg(0, parent).Return(nil)
+	}
+
+	t.Run("reply to a comment of another task should return not found", func() {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		t.mockMongo.EXPECT().FindOne(context.Background(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(mongo.ErrNoDocuments)
+		_, err := t.service.CreateComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "content", "645b9183fcfbc11433e23ab5")
+		t.ErrorIs(err, ErrCommentNotFound)
+	})
+
+	t.Run("reply to a deleted comment should return not found", func() {
+		deleted := int64(1569130951)
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		expectParent(CommentDoc{ID: "645b9183fcfbc11433e23ab5", DeleteDate: &deleted})
+		_, err := t.service.CreateComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "content", "645b9183fcfbc11433e23ab5")
+		t.ErrorIs(err, ErrCommentNotFound)
+	})
+
+	t.Run("reply deeper than the limit should return error", func() {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		expectParent(CommentDoc{ID: "645b9183fcfbc11433e23ab5", Depth: MaxReplyDepth})
+		_, err := t.service.CreateComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "content", "645b9183fcfbc11433e23ab5")
+		t.ErrorIs(err, ErrReplyTooDeep)
+	})
+
+	t.Run("reply should be one level below its parent", func() {
+		objId, _ := primitive.ObjectIDFromHex("5ad9a913478c26d220afb681")
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		expectParent(CommentDoc{ID: "645b9183fcfbc11433e23ab5", Depth: 1})
+		t.mockMongo.EXPECT().InsertOne(context.Background(), CommentDoc{
+			TaskId:     "645b9183fcfbc11433e23ab3",
+			Content:    "content",
+			OwnerId:    "owner_id",
+			CreateDate: int64(1569130951),
+			ParentID:   "645b9183fcfbc11433e23ab5",
+			Depth:      2,
+		}).Return(&mongo.InsertOneResult{InsertedID: objId}, nil)
+		t.history.EXPECT().Record(context.Background(), gomock.Any()).Return(nil)
+		comment, err := t.service.CreateComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "content", "645b9183fcfbc11433e23ab5")
+		t.NoError(err)
+		t.Equal("645b9183fcfbc11433e23ab5", comment.ParentID)
+		t.Equal(2, comment.Depth)
+	})
+
+	t.Run("top-level comments should exclude replies and count them", func() {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		t.mockMongo.EXPECT().Find(context.Background(), bson.M{
+			"task_id":           "645b9183fcfbc11433e23ab3",
+			"parent_comment_id": nil,
+		}, gomock.Any()).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).SetArg(1, []CommentDoc{
+			{ID: "645b9183fcfbc11433e23ab5"},
+			{ID: "645b9183fcfbc11433e23ab6"},
+		}).Return(nil)
+		aggregate := mock.NewMockCursor(t.ctrl)
+		t.mockMongo.EXPECT().Aggregate(context.Background(), []bson.M{
+			{"$match": bson.M{
+				"task_id":           "645b9183fcfbc11433e23ab3",
+				"parent_comment_id": bson.M{"$in": []string{"645b9183fcfbc11433e23ab5", "645b9183fcfbc11433e23ab6"}},
+			}},
+			{"$group": bson.M{
+				"_id":   "$parent_comment_id",
+				"count": bson.M{"$sum": 1},
+			}},
+		}).Return(aggregate, nil)
+		aggregate.EXPECT().All(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, result interface{}) error {
+			return bson.UnmarshalExtJSON([]byte(`[{"_id":"645b9183fcfbc11433e23ab5","count":2}]`), false, result)
+		})
+		comments, _, err := t.service.GetTopicComments(context.Background(), "645b9183fcfbc11433e23ab3", true, m.Pagination{Limit: 10, SkipCount: true})
+		t.NoError(err)
+		t.Equal(int64(2), *comments[0].ReplyCount)
+		t.Equal(int64(0), *comments[1].ReplyCount)
+	})
+
+	t.Run("get replies of a missing comment should return not found", func() {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		t.mockMongo.EXPECT().FindOne(context.Background(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(mongo.ErrNoDocuments)
+		_, _, err := t.service.GetReplies(context.Background(), "645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab5", m.Pagination{Limit: 10})
+		t.ErrorIs(err, ErrCommentNotFound)
+	})
+
+	t.Run("get replies should list the direct replies oldest first", func() {
+		l, skip := int64(11), int64(0)
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		expectParent(CommentDoc{ID: "645b9183fcfbc11433e23ab5"})
+		t.mockMongo.EXPECT().Find(context.Background(), bson.M{
+			"task_id":           "645b9183fcfbc11433e23ab3",
+			"parent_comment_id": "645b9183fcfbc11433e23ab5",
+		}, (&options.FindOptions{Limit: &l, Skip: &skip, Projection: bson.M{"revisions": 0}}).SetSort(bson.D{{Key: "_id", Value: 1}})).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).Return(nil)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), gomock.Any()).Return(int64(0), nil)
+		replies, pageInfo, err := t.service.GetReplies(context.Background(), "645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab5", m.Pagination{Page: 1, Limit: 10})
+		t.NoError(err)
+		t.Empty(replies)
+		t.Equal(int64(0), *pageInfo.Total)
+	})
+}
+
+func (t *CommentTestSuite) TestMentions() {
+	task := &taskmanager.TaskDoc{ID: "645b9183fcfbc11433e23ab3"}
+	objId, _ := primitive.ObjectIDFromHex("5ad9a913478c26d220afb681")
+
+	t.Run("create comment should resolve mentions by owner id and display name", func() {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		t.profiles.EXPECT().GetProfileList(context.Background(), []string{"5678", "Jane Doe", "Sam", "nobody"}).Return([]profile.ProfileDoc{
+			{OwnerId: "5678"},
+		}, nil)
+		t.profiles.EXPECT().GetProfilesByDisplayName(context.Background(), []string{"Jane Doe", "Sam", "nobody"}).Return([]profile.ProfileDoc{
+			{OwnerId: "9012", DisplayName: "Jane Doe"},
+			{OwnerId: "3456", DisplayName: "Sam"},
+			{OwnerId: "7890", DisplayName: "Sam"},
+		}, nil)
+		t.mockMongo.EXPECT().InsertOne(context.Background(), CommentDoc{
+			TaskId:     "645b9183fcfbc11433e23ab3",
+			Content:    `@5678 and @"Jane Doe" please check, @Sam @nobody`,
+			OwnerId:    "owner_id",
+			CreateDate: int64(1569130951),
+			Mentions:   []string{"5678", "9012"},
+		}).Return(&mongo.InsertOneResult{InsertedID: objId}, nil)
+		t.history.EXPECT().Record(context.Background(), gomock.Any()).Return(nil)
+		t.mentions.EXPECT().Notify(context.Background(), "645b9183fcfbc11433e23ab3", "5ad9a913478c26d220afb681", "owner_id", []string{"5678", "9012"}).Return(nil)
+		comment, err := t.service.CreateComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", `@5678 and @"Jane Doe" please check, @Sam @nobody`, "")
+		t.NoError(err)
+		t.Equal([]string{"5678", "9012"}, comment.Mentions)
+	})
+
+	t.Run("create comment but profile lookup fails should return error", func() {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		t.profiles.EXPECT().GetProfileList(context.Background(), []string{"5678"}).Return(nil, errors.New("find error"))
+		_, err := t.service.CreateComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "@5678", "")
+		t.EqualError(err, "find error")
+	})
+
+	t.Run("create comment should return comment even if notify fails", func() {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		t.profiles.EXPECT().GetProfileList(context.Background(), []string{"5678"}).Return([]profile.ProfileDoc{{OwnerId: "5678"}}, nil)
+		t.mockMongo.EXPECT().InsertOne(context.Background(), gomock.Any()).Return(&mongo.InsertOneResult{InsertedID: objId}, nil)
+		t.history.EXPECT().Record(context.Background(), gomock.Any()).Return(nil)
+		t.mentions.EXPECT().Notify(context.Background(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("insert many error"))
+		comment, err := t.service.CreateComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "@5678", "")
+		t.NoError(err)
+		t.Equal("5ad9a913478c26d220afb681", comment.ID)
+	})
+
+	t.Run("update comment should only notify people added by the edit", func() {
+		t.taskLookup.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		t.mockMongo.EXPECT().FindOne(context.Background(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).SetArg(0, CommentDoc{
+			ID:       "645b9183fcfbc11433e23ab5",
+			OwnerId:  "owner_id",
+			TaskId:   "645b9183fcfbc11433e23ab3",
+			Content:  "@5678",
+			Mentions: []string{"5678"},
+		}).Return(nil)
+		t.profiles.EXPECT().GetProfileList(context.Background(), []string{"5678", "9012"}).Return([]profile.ProfileDoc{{OwnerId: "5678"}, {OwnerId: "9012"}}, nil)
+		t.mockMongo.EXPECT().UpdateOne(context.Background(), gomock.Any(), gomock.Any()).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+		t.history.EXPECT().Record(context.Background(), gomock.Any()).Return(nil)
+		t.mentions.EXPECT().Notify(context.Background(), "645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab5", "owner_id", []string{"9012"}).Return(nil)
+		comment, err := t.service.UpdateComment(context.Background(), "owner_id", "645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab5", "@5678 @9012")
+		t.NoError(err)
+		t.Equal([]string{"5678", "9012"}, comment.Mentions)
+	})
+}
+
+func (t *CommentTestSuite) TestDeleteTaskComments() {
+	t.Run("delete task comments but delete many got error should return error", func() {
+		t.mockMongo.EXPECT().DeleteMany(context.Background(), bson.M{
+			"task_id": bson.M{"$in": []string{"645b9183fcfbc11433e23ab3"}},
+		}).Return(nil, errors.New("delete many error"))
+		_, err := t.service.DeleteTaskComments(context.Background(), []string{"645b9183fcfbc11433e23ab3"})
+		t.EqualError(err, "delete many error")
+	})
+
+	t.Run("delete task comments success", func() {
+		t.mockMongo.EXPECT().DeleteMany(context.Background(), bson.M{
+			"task_id": bson.M{"$in": []string{"645b9183fcfbc11433e23ab3"}},
+		}).Return(&mongo.DeleteResult{DeletedCount: 2}, nil)
+		deleted, err := t.service.DeleteTaskComments(context.Background(), []string{"645b9183fcfbc11433e23ab3"})
+		t.NoError(err)
+		t.Equal(int64(2), deleted)
+	})
+}
+
+func (t *CommentTestSuite) TestTaskIDs() {
+	t.Run("task ids should list the distinct task ids after the given one", func() {
+		aggregate := mock.NewMockCursor(t.ctrl)
+		t.mockMongo.EXPECT().Aggregate(context.Background(), []bson.M{
+			{"$match": bson.M{"task_id": bson.M{"$gt": "645b9183fcfbc11433e23ab3"}}},
+			{"$group": bson.M{"_id": "$task_id"}},
+			{"$sort": bson.M{"_id": 1}},
+			{"$limit": int64(100)},
+		}).Return(aggregate, nil)
+		aggregate.EXPECT().All(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, result interface{}) error {
+			return bson.UnmarshalExtJSON([]byte(`[{"_id":"645b9183fcfbc11433e23ab4"},{"_id":"645b9183fcfbc11433e23ab5"}]`), false, result)
+		})
+		ids, err := t.service.TaskIDs(context.Background(), "645b9183fcfbc11433e23ab3", 100)
+		t.NoError(err)
+		t.Equal([]string{"645b9183fcfbc11433e23ab4", "645b9183fcfbc11433e23ab5"}, ids)
+	})
+
+	t.Run("task ids but aggregate has error should return error", func() {
+		t.mockMongo.EXPECT().Aggregate(context.Background(), gomock.Any()).Return(nil, errors.New("aggregate error"))
+		_, err := t.service.TaskIDs(context.Background(), "", 100)
+		t.EqualError(err, "aggregate error")
+	})
+}
+
+func (t *CommentTestSuite) TestEnsureIndexes() {
+	t.Run("ensure indexes but create got error should return error", func() {
+		t.mockMongo.EXPECT().CreateIndexes(context.Background(), gomock.Any()).Return(nil, errors.New("create indexes error"))
+		t.EqualError(t.service.EnsureIndexes(context.Background()), "create indexes error")
+	})
+
+	t.Run("ensure indexes success", func() {
+		t.mockMongo.EXPECT().CreateIndexes(context.Background(), gomock.Any()).Return([]string{"task_id_1__id_1"}, nil)
+		t.NoError(t.service.EnsureIndexes(context.Background()))
+	})
+}
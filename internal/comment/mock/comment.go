@@ -7,7 +7,10 @@ package mock_comment
 import (
 	context "context"
 	reflect "reflect"
+	history "task-manager-api/internal/history"
 	mongo0 "task-manager-api/internal/mongo"
+	profile "task-manager-api/internal/profile"
+	taskmanager "task-manager-api/internal/taskmanager"
 
 	gomock "github.com/golang/mock/gomock"
 	mongo "go.mongodb.org/mongo-driver/mongo"
@@ -37,6 +40,86 @@ func (m *MockIMongo) EXPECT() *MockIMongoMockRecorder {
 	return m.recorder
 }
 
+// Aggregate mocks base method.
+func (m *MockIMongo) Aggregate(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (mongo0.Cursor, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, pipeline}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Aggregate", varargs...)
+	ret0, _ := ret[0].(mongo0.Cursor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Aggregate indicates an expected call of Aggregate.
+func (mr *MockIMongoMockRecorder) Aggregate(ctx, pipeline interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, pipeline}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Aggregate", reflect.TypeOf((*MockIMongo)(nil).Aggregate), varargs...)
+}
+
+// CountDocuments mocks base method.
+func (m *MockIMongo) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, filter}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CountDocuments", varargs...)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountDocuments indicates an expected call of CountDocuments.
+func (mr *MockIMongoMockRecorder) CountDocuments(ctx, filter interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, filter}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountDocuments", reflect.TypeOf((*MockIMongo)(nil).CountDocuments), varargs...)
+}
+
+// CreateIndexes mocks base method.
+func (m *MockIMongo) CreateIndexes(ctx context.Context, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, models}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CreateIndexes", varargs...)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIndexes indicates an expected call of CreateIndexes.
+func (mr *MockIMongoMockRecorder) CreateIndexes(ctx, models interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, models}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIndexes", reflect.TypeOf((*MockIMongo)(nil).CreateIndexes), varargs...)
+}
+
+// DeleteMany mocks base method.
+func (m *MockIMongo) DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, filter}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteMany", varargs...)
+	ret0, _ := ret[0].(*mongo.DeleteResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteMany indicates an expected call of DeleteMany.
+func (mr *MockIMongoMockRecorder) DeleteMany(ctx, filter interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, filter}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMany", reflect.TypeOf((*MockIMongo)(nil).DeleteMany), varargs...)
+}
+
 // Find mocks base method.
 func (m *MockIMongo) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (mongo0.Cursor, error) {
 	m.ctrl.T.Helper()
@@ -57,6 +140,25 @@ func (mr *MockIMongoMockRecorder) Find(ctx, filter interface{}, opts ...interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockIMongo)(nil).Find), varargs...)
 }
 
+// FindOne mocks base method.
+func (m *MockIMongo) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) mongo0.SingleResult {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, filter}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "FindOne", varargs...)
+	ret0, _ := ret[0].(mongo0.SingleResult)
+	return ret0
+}
+
+// FindOne indicates an expected call of FindOne.
+func (mr *MockIMongoMockRecorder) FindOne(ctx, filter interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, filter}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOne", reflect.TypeOf((*MockIMongo)(nil).FindOne), varargs...)
+}
+
 // InsertOne mocks base method.
 func (m *MockIMongo) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
 	m.ctrl.T.Helper()
@@ -76,3 +178,193 @@ func (mr *MockIMongoMockRecorder) InsertOne(ctx, document interface{}, opts ...i
 	varargs := append([]interface{}{ctx, document}, opts...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertOne", reflect.TypeOf((*MockIMongo)(nil).InsertOne), varargs...)
 }
+
+// UpdateOne mocks base method.
+func (m *MockIMongo) UpdateOne(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, filter, update}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UpdateOne", varargs...)
+	ret0, _ := ret[0].(*mongo.UpdateResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateOne indicates an expected call of UpdateOne.
+func (mr *MockIMongoMockRecorder) UpdateOne(ctx, filter, update interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, filter, update}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOne", reflect.TypeOf((*MockIMongo)(nil).UpdateOne), varargs...)
+}
+
+// MockITaskLookup is a mock of ITaskLookup interface.
+type MockITaskLookup struct {
+	ctrl     *gomock.Controller
+	recorder *MockITaskLookupMockRecorder
+}
+
+// MockITaskLookupMockRecorder is the mock recorder for MockITaskLookup.
+type MockITaskLookupMockRecorder struct {
+	mock *MockITaskLookup
+}
+
+// NewMockITaskLookup creates a new mock instance.
+func NewMockITaskLookup(ctrl *gomock.Controller) *MockITaskLookup {
+	mock := &MockITaskLookup{ctrl: ctrl}
+	mock.recorder = &MockITaskLookupMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockITaskLookup) EXPECT() *MockITaskLookupMockRecorder {
+	return m.recorder
+}
+
+// FindTask mocks base method.
+func (m *MockITaskLookup) FindTask(ctx context.Context, id string) (*taskmanager.TaskDoc, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindTask", ctx, id)
+	ret0, _ := ret[0].(*taskmanager.TaskDoc)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindTask indicates an expected call of FindTask.
+func (mr *MockITaskLookupMockRecorder) FindTask(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindTask", reflect.TypeOf((*MockITaskLookup)(nil).FindTask), ctx, id)
+}
+
+// MockIHistory is a mock of IHistory interface.
+type MockIHistory struct {
+	ctrl     *gomock.Controller
+	recorder *MockIHistoryMockRecorder
+}
+
+// MockIHistoryMockRecorder is the mock recorder for MockIHistory.
+type MockIHistoryMockRecorder struct {
+	mock *MockIHistory
+}
+
+// NewMockIHistory creates a new mock instance.
+func NewMockIHistory(ctrl *gomock.Controller) *MockIHistory {
+	mock := &MockIHistory{ctrl: ctrl}
+	mock.recorder = &MockIHistoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIHistory) EXPECT() *MockIHistoryMockRecorder {
+	return m.recorder
+}
+
+// Record mocks base method.
+func (m *MockIHistory) Record(ctx context.Context, changes ...history.Change) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range changes {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Record", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockIHistoryMockRecorder) Record(ctx interface{}, changes ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, changes...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockIHistory)(nil).Record), varargs...)
+}
+
+// MockIProfiles is a mock of IProfiles interface.
+type MockIProfiles struct {
+	ctrl     *gomock.Controller
+	recorder *MockIProfilesMockRecorder
+}
+
+// MockIProfilesMockRecorder is the mock recorder for MockIProfiles.
+type MockIProfilesMockRecorder struct {
+	mock *MockIProfiles
+}
+
+// NewMockIProfiles creates a new mock instance.
+func NewMockIProfiles(ctrl *gomock.Controller) *MockIProfiles {
+	mock := &MockIProfiles{ctrl: ctrl}
+	mock.recorder = &MockIProfilesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIProfiles) EXPECT() *MockIProfilesMockRecorder {
+	return m.recorder
+}
+
+// GetProfileList mocks base method.
+func (m *MockIProfiles) GetProfileList(ctx context.Context, ownerId []string) ([]profile.ProfileDoc, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProfileList", ctx, ownerId)
+	ret0, _ := ret[0].([]profile.ProfileDoc)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProfileList indicates an expected call of GetProfileList.
+func (mr *MockIProfilesMockRecorder) GetProfileList(ctx, ownerId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfileList", reflect.TypeOf((*MockIProfiles)(nil).GetProfileList), ctx, ownerId)
+}
+
+// GetProfilesByDisplayName mocks base method.
+func (m *MockIProfiles) GetProfilesByDisplayName(ctx context.Context, names []string) ([]profile.ProfileDoc, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProfilesByDisplayName", ctx, names)
+	ret0, _ := ret[0].([]profile.ProfileDoc)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProfilesByDisplayName indicates an expected call of GetProfilesByDisplayName.
+func (mr *MockIProfilesMockRecorder) GetProfilesByDisplayName(ctx, names interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfilesByDisplayName", reflect.TypeOf((*MockIProfiles)(nil).GetProfilesByDisplayName), ctx, names)
+}
+
+// MockIMentions is a mock of IMentions interface.
+type MockIMentions struct {
+	ctrl     *gomock.Controller
+	recorder *MockIMentionsMockRecorder
+}
+
+// MockIMentionsMockRecorder is the mock recorder for MockIMentions.
+type MockIMentionsMockRecorder struct {
+	mock *MockIMentions
+}
+
+// NewMockIMentions creates a new mock instance.
+func NewMockIMentions(ctrl *gomock.Controller) *MockIMentions {
+	mock := &MockIMentions{ctrl: ctrl}
+	mock.recorder = &MockIMentionsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIMentions) EXPECT() *MockIMentionsMockRecorder {
+	return m.recorder
+}
+
+// Notify mocks base method.
+func (m *MockIMentions) Notify(ctx context.Context, taskId, commentId, authorId string, ownerIds []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Notify", ctx, taskId, commentId, authorId, ownerIds)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Notify indicates an expected call of Notify.
+func (mr *MockIMentionsMockRecorder) Notify(ctx, taskId, commentId, authorId, ownerIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Notify", reflect.TypeOf((*MockIMentions)(nil).Notify), ctx, taskId, commentId, authorId, ownerIds)
+}
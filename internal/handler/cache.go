@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"task-manager-api/config"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CacheControl sets the Cache-Control header configured for the route that
+// served a successful GET or HEAD request. Routes are matched on the path
+// they were registered with, such as "/tasks/:taskId".
+func CacheControl(conf config.Cache) fiber.Handler {
+	policies := make(map[string]string, len(conf.Routes))
+	for _, route := range conf.Routes {
+		policies[route.Path] = route.Control
+	}
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+			return nil
+		}
+		status := c.Response().StatusCode()
+		if status != fiber.StatusOK && status != fiber.StatusNotModified {
+			return nil
+		}
+		if policy := policies[c.Route().Path]; policy != "" {
+			c.Set(fiber.HeaderCacheControl, policy)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// dateLayouts are the accepted date formats without an explicit offset,
+// they are read in the timezone of the request.
+var dateLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// location returns the timezone named by tz, defaulting to UTC rather than
+// the server timezone so that results do not depend on where the API runs.
+func location(tz string) (*time.Location, error) {
+	tz = strings.TrimSpace(tz)
+	if tz == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil || tz == "Local" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid tz")
+	}
+	return loc, nil
+}
+
+// parseDate accepts unix seconds, RFC 3339 or a local date and time such as
+// "2023-05-10" or "2023-05-10T18:00" in loc, and returns unix seconds.
+func parseDate(key string, value string, loc *time.Location) (int64, error) {
+	value = strings.TrimSpace(value)
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return unix, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.Unix(), nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t.Unix(), nil
+		}
+	}
+	return 0, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Invalid %v", key))
+}
+
+// bodyDate parses an optional date of a request body, an empty string
+// clears the date and is returned as zero.
+func bodyDate(key string, value *string, loc *time.Location) (*int64, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if strings.TrimSpace(*value) == "" {
+		var zero int64
+		return &zero, nil
+	}
+	date, err := parseDate(key, *value, loc)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+// queryDate parses an optional date query parameter.
+func queryDate(c *fiber.Ctx, key string, loc *time.Location) (*int64, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	date, err := parseDate(key, value, loc)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"task-manager-api/internal/apperror"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+var errInvalidBody = apperror.Validation("invalid_body", "Invalid request body")
+
+var kindStatus = map[apperror.Kind]int{
+	apperror.KindInternal:     fiber.StatusInternalServerError,
+	apperror.KindNotFound:     fiber.StatusNotFound,
+	apperror.KindForbidden:    fiber.StatusForbidden,
+	apperror.KindConflict:     fiber.StatusConflict,
+	apperror.KindValidation:   fiber.StatusBadRequest,
+	apperror.KindUnavailable:  fiber.StatusServiceUnavailable,
+	apperror.KindPrecondition: fiber.StatusPreconditionFailed,
+}
+
+// ErrorHandler maps errors to an HTTP status, a stable error_code and a
+// message that is safe to expose. The raw cause is only logged.
+func ErrorHandler(ctx *fiber.Ctx, err error) error {
+	code := fiber.StatusInternalServerError
+	errorCode := apperror.ErrInternal.Code
+	msg := apperror.ErrInternal.Message
+
+	var appErr *apperror.Error
+	var fiberErr *fiber.Error
+	switch {
+	case errors.As(err, &appErr):
+		code = kindStatus[appErr.Kind]
+		errorCode = appErr.Code
+		msg = appErr.Message
+	case errors.As(err, &fiberErr):
+		code = fiberErr.Code
+		errorCode = statusCode(fiberErr.Code)
+		msg = fiberErr.Message
+	}
+
+	log.Printf("error: %s: %v", errorCode, err)
+	return ctx.Status(code).JSON(map[string]interface{}{
+		"status":     code,
+		"error_code": errorCode,
+		"error_msg":  msg,
+	})
+}
+
+// statusCode turns an HTTP status into a snake case code, 404 becomes "not_found".
+func statusCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(utils.StatusMessage(status)), " ", "_")
+}
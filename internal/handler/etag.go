@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// etag tags a task representation with its version, for If-Match, followed
+// by a hash of the body since progress and blocks are computed on read.
+func etag(version int64, body []byte) string {
+	return `"` + strconv.FormatInt(version, 10) + "-" + contentHash(body) + `"`
+}
+
+// contentTag is a strong entity tag over a response body.
+func contentTag(body []byte) string {
+	return `"` + contentHash(body) + `"`
+}
+
+func contentHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ifMatch returns the version required by the If-Match header, nil when the
+// header is absent or "*". Weak tags are accepted since versions only ever
+// identify one representation, as are bare versions such as "4". Only a
+// single tag is supported.
+func ifMatch(c *fiber.Ctx) (*int64, error) {
+	value := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+	tag := strings.TrimPrefix(value, "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid If-Match")
+	}
+	number, _, _ := strings.Cut(tag[1:len(tag)-1], "-")
+	version, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid If-Match")
+	}
+	return &version, nil
+}
+
+// lastModified is the update date of a document, or its creation date when
+// it was never updated.
+func lastModified(createDate int64, updateDate *int64) int64 {
+	if updateDate != nil && *updateDate > createDate {
+		return *updateDate
+	}
+	return createDate
+}
+
+// sendFresh sends a JSON body with its validators, or 304 Not Modified when
+// the conditional headers show the client copy is current. modified is in
+// unix seconds, zero sends no Last-Modified.
+func sendFresh(c *fiber.Ctx, body []byte, tag string, modified int64) error {
+	c.Set(fiber.HeaderETag, tag)
+	if modified > 0 {
+		c.Set(fiber.HeaderLastModified, time.Unix(modified, 0).UTC().Format(http.TimeFormat))
+	}
+	if notModified(c, tag, modified) {
+		c.Status(fiber.StatusNotModified)
+		return nil
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(body)
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when there is no
+// If-None-Match, as RFC 9110 orders them. Dates have a one second precision.
+func notModified(c *fiber.Ctx, tag string, modified int64) bool {
+	if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+		return false
+	}
+	if noneMatch := c.Get(fiber.HeaderIfNoneMatch); noneMatch != "" {
+		return matchTag(noneMatch, tag)
+	}
+	since := c.Get(fiber.HeaderIfModifiedSince)
+	if since == "" || modified == 0 {
+		return false
+	}
+	date, err := http.ParseTime(since)
+	if err != nil {
+		return false
+	}
+	return modified <= date.Unix()
+}
+
+// matchTag reports whether tag is in a list of entity tags using the weak
+// comparison, which ignores the W/ prefix.
+func matchTag(list string, tag string) bool {
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+	tag = strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(list, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -2,62 +2,234 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"task-manager-api/config"
 	"task-manager-api/internal/comment"
+	"task-manager-api/internal/history"
+	"task-manager-api/internal/markdown"
+	"task-manager-api/internal/mention"
+	m "task-manager-api/internal/mongo"
 	"task-manager-api/internal/profile"
+	"task-manager-api/internal/reaction"
+	"task-manager-api/internal/recurrence"
 	"task-manager-api/internal/taskmanager"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// LocalsSubject is the fiber.Ctx Locals key holding the verified token subject.
+const LocalsSubject = "subject"
+
 type response struct {
-	Data interface{} `json:"data"`
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	Meta       *meta       `json:"meta,omitempty"`
+	Links      *links      `json:"links,omitempty"`
+}
+
+// meta describes a page of a list, Page is omitted in cursor mode and Total
+// when counting was skipped with count=false.
+type meta struct {
+	Page    int    `json:"page,omitempty"`
+	Limit   int    `json:"limit"`
+	Total   *int64 `json:"total,omitempty"`
+	HasNext bool   `json:"has_next"`
+}
+
+type links struct {
+	Self string `json:"self"`
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
 }
 
 //go:generate mockgen -source=./handler.go -destination=./mock/handler_mock.go
 // https://github.com/golang/go/wiki/CodeReviewComments#interfaces
 // https://go.dev/doc/effective_go.html#interfaces_and_types
 type ITasks interface {
-	CreateTask(ctx context.Context, ownerId string, topic string, desc string) (*taskmanager.TaskDoc, error)
-	GetAllTask(ctx context.Context, page int, limit int) ([]taskmanager.TaskDoc, error)
-	ArchiveTask(ctx context.Context, ownerId string, id string) (int, error)
-	UpdateTaskStatus(ctx context.Context, ownerId string, id string, status int) error
+	CreateTask(ctx context.Context, ownerId string, task taskmanager.NewTask) (*taskmanager.TaskDoc, error)
+	GetAllTask(ctx context.Context, filter taskmanager.TaskFilter, page m.Pagination) ([]taskmanager.TaskDoc, *m.PageInfo, error)
+	ArchiveTask(ctx context.Context, ownerId string, id string, version *int64) (int, error)
+	UnarchiveTask(ctx context.Context, ownerId string, id string, version *int64) (int, error)
+	DeleteTask(ctx context.Context, ownerId string, id string) (*taskmanager.TaskDoc, error)
+	UpdateTaskStatus(ctx context.Context, ownerId string, id string, status int, force bool, version *int64) error
+	UpdateTask(ctx context.Context, ownerId string, id string, update taskmanager.TaskUpdate, version *int64) (int, error)
 	GetTask(ctx context.Context, id string) (*taskmanager.TaskDoc, error)
+	AssignTask(ctx context.Context, ownerId string, id string, assignees []string) (int, error)
+	UnassignTask(ctx context.Context, ownerId string, id string, assignees []string) (int, error)
+	Workflow() *taskmanager.Workflow
+	AddLabels(ctx context.Context, ownerId string, id string, labels []string) (int, error)
+	RemoveLabels(ctx context.Context, ownerId string, id string, labels []string) (int, error)
+	GetLabels(ctx context.Context, ownerId string) ([]taskmanager.LabelCount, error)
+	AddDependency(ctx context.Context, ownerId string, id string, blockerId string) (int, error)
+	RemoveDependency(ctx context.Context, ownerId string, id string, blockerId string) (int, error)
 }
 type IComments interface {
-	CreateComment(ctx context.Context, ownerId string, taskId string, content string) (*comment.CommentDoc, error)
-	GetTopicComments(ctx context.Context, taskId string, page int, limit int) ([]comment.CommentDoc, error)
+	CreateComment(ctx context.Context, ownerId string, taskId string, content string, parentId string) (*comment.CommentDoc, error)
+	GetTopicComments(ctx context.Context, taskId string, topLevel bool, page m.Pagination) ([]comment.CommentDoc, *m.PageInfo, error)
+	GetReplies(ctx context.Context, taskId string, id string, page m.Pagination) ([]comment.CommentDoc, *m.PageInfo, error)
+	UpdateComment(ctx context.Context, ownerId string, taskId string, id string, content string) (*comment.CommentDoc, error)
+	DeleteComment(ctx context.Context, ownerId string, taskId string, id string) error
+	GetCommentRevisions(ctx context.Context, ownerId string, taskId string, id string) ([]comment.Revision, error)
+	DeleteTaskComments(ctx context.Context, taskIds []string) (int64, error)
 }
 
 type IProfile interface {
 	GetProfile(ctx context.Context, ownerId string) (*profile.ProfileDoc, error)
 	GetProfileList(ctx context.Context, ownerId []string) ([]profile.ProfileDoc, error)
 }
+
+type IRecurrences interface {
+	CreateRecurrence(ctx context.Context, ownerId string, series recurrence.NewRecurrence) (*recurrence.RecurrenceDoc, error)
+	GetRecurrences(ctx context.Context, ownerId string) ([]recurrence.RecurrenceDoc, error)
+	EndRecurrence(ctx context.Context, ownerId string, id string) (int, error)
+}
+
+type IHistory interface {
+	GetTaskHistory(ctx context.Context, taskId string, page m.Pagination) ([]history.HistoryDoc, *m.PageInfo, error)
+}
+
+type IMentions interface {
+	GetUnread(ctx context.Context, ownerId string, page m.Pagination) ([]mention.MentionDoc, *m.PageInfo, error)
+	MarkRead(ctx context.Context, ownerId string, id string) (int, error)
+	DeleteTaskMentions(ctx context.Context, taskIds []string) (int64, error)
+}
+
+type IReactions interface {
+	AddReaction(ctx context.Context, ownerId string, taskId string, commentId string, emoji string) (*reaction.ReactionDoc, error)
+	RemoveReaction(ctx context.Context, ownerId string, taskId string, commentId string, emoji string) (int, error)
+	TaskSummary(ctx context.Context, taskId string, viewer string) ([]reaction.Summary, error)
+	CommentSummaries(ctx context.Context, taskId string, commentIds []string, viewer string) (map[string][]reaction.Summary, error)
+	DeleteTaskReactions(ctx context.Context, taskIds []string) (int64, error)
+}
+
+type IAuth interface {
+	Verify(token string) (string, error)
+}
+
 type Handler struct {
-	task    ITasks
-	comment IComments
-	profile IProfile
+	task       ITasks
+	comment    IComments
+	profile    IProfile
+	auth       IAuth
+	recurrence IRecurrences
+	history    IHistory
+	mention    IMentions
+	reaction   IReactions
 }
 
-func NewHandler(tasksService ITasks, commentService IComments, profileService IProfile) *Handler {
+// taskView is a task with the reactions on it and, with render=html, the
+// HTML rendering of its description.
+type taskView struct {
+	*taskmanager.TaskDoc
+	DescriptionHTML string             `json:"description_html,omitempty"`
+	Reactions       []reaction.Summary `json:"reactions,omitempty"`
+}
+
+// commentView is a comment with the reactions on it and, with render=html,
+// the HTML rendering of its content.
+type commentView struct {
+	comment.CommentDoc
+	ContentHTML string             `json:"content_html,omitempty"`
+	Reactions   []reaction.Summary `json:"reactions,omitempty"`
+}
+
+func newTaskView(task *taskmanager.TaskDoc, render bool) taskView {
+	view := taskView{TaskDoc: task}
+	if render {
+		view.DescriptionHTML = markdown.Render(task.Description)
+	}
+	return view
+}
+
+func taskViews(tasks []taskmanager.TaskDoc, render bool) []taskView {
+	views := make([]taskView, 0, len(tasks))
+	for i := range tasks {
+		views = append(views, newTaskView(&tasks[i], render))
+	}
+	return views
+}
+
+func NewHandler(tasksService ITasks, commentService IComments, profileService IProfile, authService IAuth, recurrenceService IRecurrences, historyService IHistory, mentionService IMentions, reactionService IReactions) *Handler {
 	return &Handler{
-		task:    tasksService,
-		comment: commentService,
-		profile: profileService,
+		task:       tasksService,
+		comment:    commentService,
+		profile:    profileService,
+		auth:       authService,
+		recurrence: recurrenceService,
+		history:    historyService,
+		mention:    mentionService,
+		reaction:   reactionService,
+	}
+}
+
+// Authorize verifies the bearer token and only lets the request through
+// when the token subject is the :ownerId of the route.
+func (h *Handler) Authorize(c *fiber.Ctx) error {
+	header := c.Get(fiber.HeaderAuthorization)
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		c.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+		return fiber.NewError(fiber.StatusUnauthorized, "Missing bearer token")
+	}
+
+	subject, err := h.verify(c, token)
+	if err != nil {
+		return err
+	}
+
+	if subject != c.Params("ownerId") {
+		return fiber.NewError(fiber.StatusForbidden, "Token subject does not match owner id")
+	}
+
+	c.Locals(LocalsSubject, subject)
+	return c.Next()
+}
+
+// viewer returns the subject of the bearer token a public route may be
+// called with, or "" when the request is anonymous.
+func (h *Handler) viewer(c *fiber.Ctx) (string, error) {
+	c.Vary(fiber.HeaderAuthorization)
+	header := c.Get(fiber.HeaderAuthorization)
+	if header == "" {
+		return "", nil
+	}
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		c.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+		return "", fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
 	}
+	return h.verify(c, token)
+}
+
+func (h *Handler) verify(c *fiber.Ctx, token string) (string, error) {
+	subject, err := h.auth.Verify(strings.TrimSpace(token))
+	if err != nil {
+		log.Printf("token rejected: %v", err)
+		c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+		return "", fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+	return subject, nil
 }
 
 func (h *Handler) CreateTask(c *fiber.Ctx) error {
 	payload := struct {
-		Topic       string `json:"topic"`
-		Description string `json:"description"`
+		Topic       string          `json:"topic"`
+		Description string          `json:"description"`
+		Priority    json.RawMessage `json:"priority"`
+		ParentID    string          `json:"parent_id"`
+		StartDate   *string         `json:"start_date"`
+		DueDate     *string         `json:"due_date"`
+		TZ          string          `json:"tz"`
 	}{}
 	if err := c.BodyParser(&payload); err != nil {
-		return err
+		return errInvalidBody.Wrap(err)
 	}
 	topic := strings.TrimSpace(payload.Topic)
 	description := strings.TrimSpace(payload.Description)
@@ -68,15 +240,44 @@ func (h *Handler) CreateTask(c *fiber.Ctx) error {
 	if description == "" {
 		return fiber.NewError(fiber.StatusBadRequest, "Description is required")
 	}
+	priority, err := bodyPriority(payload.Priority)
+	if err != nil {
+		return err
+	}
+	newTask := taskmanager.NewTask{
+		Topic:       topic,
+		Description: description,
+		ParentID:    strings.TrimSpace(payload.ParentID),
+	}
+	if priority != nil {
+		newTask.Priority = *priority
+	}
+	loc, err := location(payload.TZ)
+	if err != nil {
+		return err
+	}
+	if newTask.StartDate, err = bodyDate("start_date", payload.StartDate, loc); err != nil {
+		return err
+	}
+	if newTask.DueDate, err = bodyDate("due_date", payload.DueDate, loc); err != nil {
+		return err
+	}
+	// an empty date on creation means no date
+	if newTask.StartDate != nil && *newTask.StartDate == 0 {
+		newTask.StartDate = nil
+	}
+	if newTask.DueDate != nil && *newTask.DueDate == 0 {
+		newTask.DueDate = nil
+	}
+
 	ownerId := c.Params("ownerId")
 	if err := h.validateOwnerId(c, ownerId); err != nil {
 		return err
 	}
 
-	task, err := h.task.CreateTask(c.Context(), ownerId, topic, description)
+	task, err := h.task.CreateTask(c.Context(), ownerId, newTask)
 	if err != nil {
-		// TODO: convert interl sensitive error to code [1213413]
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.Status(http.StatusCreated).JSON(response{
 		Data: task,
@@ -84,50 +285,135 @@ func (h *Handler) CreateTask(c *fiber.Ctx) error {
 }
 
 func (h *Handler) GetAllTask(c *fiber.Ctx) error {
-	page := c.Query("page", "1")
-	limit := c.Query("limit", "10")
-	pageInt, err := strconv.Atoi(page)
+	page, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseTaskFilter(c)
+	if err != nil {
+		return err
+	}
+	render, err := parseRender(c)
 	if err != nil {
-		fmt.Println("Error during conversion")
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid page number")
+		return err
 	}
 
-	limitInt, err := strconv.Atoi(limit)
+	tasks, pageInfo, err := h.task.GetAllTask(c.Context(), filter, page)
 	if err != nil {
-		fmt.Println("Error during conversion 'limit'")
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid limit number")
+		return err
 	}
+	return c.JSON(pageResponse(c, taskViews(tasks, render), page, pageInfo))
+}
 
-	if limitInt > config.Conf.Pagination.MaxLimit {
-		return fiber.NewError(fiber.StatusBadRequest, "Limit cannot be more than 100")
+// GetArchivedTasks lists the archived tasks of the account, most recently
+// archived first unless another sort is given.
+func (h *Handler) GetArchivedTasks(c *fiber.Ctx) error {
+	page, err := parsePagination(c)
+	if err != nil {
+		return err
 	}
 
-	tasks, err := h.task.GetAllTask(c.Context(), pageInt, limitInt)
+	filter, err := parseTaskFilter(c)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
 	}
-	return c.JSON(response{
-		Data: tasks,
-	})
+	filter.OwnerID = c.Params("ownerId")
+	filter.Archived = true
+	if len(filter.Sort) == 0 {
+		filter.Sort = []taskmanager.SortField{{Field: "archive_date", Desc: true}}
+	}
+	render, err := parseRender(c)
+	if err != nil {
+		return err
+	}
+
+	tasks, pageInfo, err := h.task.GetAllTask(c.Context(), filter, page)
+	if err != nil {
+		return err
+	}
+	return c.JSON(pageResponse(c, taskViews(tasks, render), page, pageInfo))
 }
 
+// GetTask returns a task with its reactions, reacted_by_me is only set for
+// a request with a bearer token.
 func (h *Handler) GetTask(c *fiber.Ctx) error {
+	render, err := parseRender(c)
+	if err != nil {
+		return err
+	}
+	viewer, err := h.viewer(c)
+	if err != nil {
+		return err
+	}
+
 	taskId := c.Params("taskId")
 	task, err := h.task.GetTask(c.Context(), taskId)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
+	}
+	reactions, err := h.reaction.TaskSummary(c.Context(), task.ID, viewer)
+	if err != nil {
+		return err
+	}
+	view := newTaskView(task, render)
+	view.Reactions = reactions
+	body, err := c.App().Config().JSONEncoder(response{
+		Data: view,
+	})
+	if err != nil {
+		return err
+	}
+	// reactions do not move the update date, the etag covers them
+	return sendFresh(c, body, etag(task.Version, body), lastModified(task.CreateDate, task.UpdateDate))
+}
+
+// GetSubtasks lists the subtasks of a task, accepting the same filters as GetAllTask.
+func (h *Handler) GetSubtasks(c *fiber.Ctx) error {
+	page, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseTaskFilter(c)
+	if err != nil {
+		return err
+	}
+	render, err := parseRender(c)
+	if err != nil {
+		return err
+	}
+
+	parent, err := h.task.GetTask(c.Context(), c.Params("taskId"))
+	if err != nil {
+		return err
+	}
+	filter.ParentID = parent.ID
+
+	tasks, pageInfo, err := h.task.GetAllTask(c.Context(), filter, page)
+	if err != nil {
+		return err
 	}
+	return c.JSON(pageResponse(c, taskViews(tasks, render), page, pageInfo))
+}
+
+// GetWorkflow lists the task statuses and the transitions allowed between them.
+func (h *Handler) GetWorkflow(c *fiber.Ctx) error {
 	return c.JSON(response{
-		Data: task,
+		Data: h.task.Workflow().States(),
 	})
 }
 
 func (h *Handler) ArchiveTask(c *fiber.Ctx) error {
 	taskId := c.Params("taskId")
 	ownerId := c.Params("ownerId")
-	modifiedCount, err := h.task.ArchiveTask(c.Context(), ownerId, taskId)
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	modifiedCount, err := h.task.ArchiveTask(c.Context(), ownerId, taskId, version)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
 	}
 	if modifiedCount == 0 {
 		return fiber.NewError(fiber.StatusBadRequest, "Task or account not found")
@@ -137,137 +423,881 @@ func (h *Handler) ArchiveTask(c *fiber.Ctx) error {
 	})
 }
 
+func (h *Handler) UnarchiveTask(c *fiber.Ctx) error {
+	taskId := c.Params("taskId")
+	ownerId := c.Params("ownerId")
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	matchedCount, err := h.task.UnarchiveTask(c.Context(), ownerId, taskId, version)
+	if err != nil {
+		return err
+	}
+	if matchedCount == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Task or account not found")
+	}
+	return c.JSON(response{
+		Data: "Task unarchived successfully",
+	})
+}
+
+// DeleteTask removes a task with its comments, reactions and mentions, only
+// the owner can delete. The task is gone once deleted, comments, reactions
+// and mentions failing to follow are left to the retention purger.
+func (h *Handler) DeleteTask(c *fiber.Ctx) error {
+	taskId := c.Params("taskId")
+	ownerId := c.Params("ownerId")
+	task, err := h.task.DeleteTask(c.Context(), ownerId, taskId)
+	if err != nil {
+		return err
+	}
+	if task == nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Task or account not found")
+	}
+	taskIds := []string{task.ID}
+	if _, err := h.comment.DeleteTaskComments(c.Context(), taskIds); err != nil {
+		log.Printf("delete comments of task %v: %v", task.ID, err)
+	}
+	if _, err := h.reaction.DeleteTaskReactions(c.Context(), taskIds); err != nil {
+		log.Printf("delete reactions of task %v: %v", task.ID, err)
+	}
+	if _, err := h.mention.DeleteTaskMentions(c.Context(), taskIds); err != nil {
+		log.Printf("delete mentions of task %v: %v", task.ID, err)
+	}
+	return c.JSON(response{
+		Data: "Task deleted successfully",
+	})
+}
+
 func (h *Handler) UpdateTask(c *fiber.Ctx) error {
 	taskId := c.Params("taskId")
 	ownerId := c.Params("ownerId")
 	payload := struct {
-		Status *int `json:"status"`
+		Status      *int            `json:"status"`
+		Force       bool            `json:"force"`
+		Topic       *string         `json:"topic"`
+		Description *string         `json:"description"`
+		Priority    json.RawMessage `json:"priority"`
+		StartDate   *string         `json:"start_date"`
+		DueDate     *string         `json:"due_date"`
+		TZ          string          `json:"tz"`
 	}{}
 	if err := c.BodyParser(&payload); err != nil {
-		return err
+		return errInvalidBody.Wrap(err)
 	}
 
-	// currently only status can be updated
-	if payload.Status != nil {
-		if *payload.Status < taskmanager.TaskStatusOpen || *payload.Status > taskmanager.TaskStatusDone {
-			return fiber.NewError(fiber.StatusBadRequest, "Invalid status")
+	// omitted fields are left untouched, supplied ones follow the CreateTask rules
+	update := taskmanager.TaskUpdate{}
+	if payload.Topic != nil {
+		topic := strings.TrimSpace(*payload.Topic)
+		if topic == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "Topic is required")
 		}
-		err := h.task.UpdateTaskStatus(c.Context(), ownerId, taskId, *payload.Status)
-		if err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		update.Topic = &topic
+	}
+	if payload.Description != nil {
+		description := strings.TrimSpace(*payload.Description)
+		if description == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "Description is required")
 		}
-		return c.JSON(response{
-			Data: "Task status updated successfully",
-		})
+		update.Description = &description
 	}
-
-	return fiber.NewError(fiber.StatusNotImplemented, "Not implemented")
-}
-
-func (h *Handler) GetTopicComments(c *fiber.Ctx) error {
-	page := c.Query("page", "1")
-	limit := c.Query("limit", "10")
-	pageInt, err := strconv.Atoi(page)
+	priority, err := bodyPriority(payload.Priority)
+	if err != nil {
+		return err
+	}
+	update.Priority = priority
+	// an empty start_date or due_date clears it
+	loc, err := location(payload.TZ)
 	if err != nil {
-		fmt.Println("Error during conversion")
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid page number")
+		return err
+	}
+	if update.StartDate, err = bodyDate("start_date", payload.StartDate, loc); err != nil {
+		return err
+	}
+	if update.DueDate, err = bodyDate("due_date", payload.DueDate, loc); err != nil {
+		return err
+	}
+	if payload.Status != nil && !h.task.Workflow().Valid(*payload.Status) {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid status")
 	}
 
-	limitInt, err := strconv.Atoi(limit)
+	hasFields := update != taskmanager.TaskUpdate{}
+	if !hasFields && payload.Status == nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Nothing to update")
+	}
+	version, err := ifMatch(c)
 	if err != nil {
-		fmt.Println("Error during conversion 'limit'")
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid limit number")
+		return err
 	}
 
-	if limitInt > config.Conf.Pagination.MaxLimit {
-		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Limit cannot be more than %v", config.Conf.Pagination.MaxLimit))
+	// a status alone may be changed by assignees too, along with fields it
+	// is saved in the same write so the request applies fully or not at all
+	if !hasFields {
+		err := h.task.UpdateTaskStatus(c.Context(), ownerId, taskId, *payload.Status, payload.Force, version)
+		if err != nil {
+			return err
+		}
+		return c.JSON(response{
+			Data: "Task status updated successfully",
+		})
 	}
+	update.Status = payload.Status
+	update.Force = payload.Force
 
-	taskId := c.Params("taskId")
-	comments, err := h.comment.GetTopicComments(c.Context(), taskId, pageInt, limitInt)
+	matchedCount, err := h.task.UpdateTask(c.Context(), ownerId, taskId, update, version)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
 	}
+	if matchedCount == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Task or account not found")
+	}
+
 	return c.JSON(response{
-		Data: comments,
+		Data: "Task updated successfully",
 	})
 }
 
-func (h *Handler) CreateComment(c *fiber.Ctx) error {
+// AssignTask adds assignees to a task, every assignee must have a profile.
+func (h *Handler) AssignTask(c *fiber.Ctx) error {
+	taskId := c.Params("taskId")
+	ownerId := c.Params("ownerId")
 	payload := struct {
-		Content string `json:"content"`
+		Assignees []string `json:"assignees"`
 	}{}
-
-	ownerId := c.Params("ownerId")
-	taskId := c.Params("taskId")
 	if err := c.BodyParser(&payload); err != nil {
-		return err
+		return errInvalidBody.Wrap(err)
 	}
 
-	content := strings.TrimSpace(payload.Content)
-	if content == "" {
-		return fiber.NewError(fiber.StatusBadRequest, "Content is required")
+	assignees := make([]string, 0, len(payload.Assignees))
+	seen := map[string]bool{}
+	for _, assignee := range payload.Assignees {
+		assignee = strings.TrimSpace(assignee)
+		if assignee == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid assignee")
+		}
+		if !seen[assignee] {
+			seen[assignee] = true
+			assignees = append(assignees, assignee)
+		}
 	}
-	if err := h.validateOwnerId(c, ownerId); err != nil {
+	if len(assignees) == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Assignees are required")
+	}
+	if len(assignees) > config.Conf.Pagination.MaxGetProfileLimit {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Assignees cannot be more than %v", config.Conf.Pagination.MaxGetProfileLimit))
+	}
+
+	profiles, err := h.profile.GetProfileList(c.Context(), assignees)
+	if err != nil {
 		return err
 	}
+	if len(profiles) != len(assignees) {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid assignee")
+	}
 
-	comment, err := h.comment.CreateComment(c.Context(), ownerId, taskId, content)
+	matchedCount, err := h.task.AssignTask(c.Context(), ownerId, taskId, assignees)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
 	}
-	return c.Status(http.StatusCreated).JSON(response{
-		Data: comment,
+	if matchedCount == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Task or account not found")
+	}
+	return c.JSON(response{
+		Data: "Task assigned successfully",
 	})
 }
 
-func (h *Handler) GetProfile(c *fiber.Ctx) error {
+func (h *Handler) UnassignTask(c *fiber.Ctx) error {
+	taskId := c.Params("taskId")
 	ownerId := c.Params("ownerId")
-	profile, err := h.profile.GetProfile(c.Context(), ownerId)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	assigneeId := strings.TrimSpace(c.Params("assigneeId"))
+	if assigneeId == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid assignee")
 	}
 
-	if profile == nil {
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid owner id")
+	matchedCount, err := h.task.UnassignTask(c.Context(), ownerId, taskId, []string{assigneeId})
+	if err != nil {
+		return err
+	}
+	if matchedCount == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Task or account not found")
 	}
-
 	return c.JSON(response{
-		Data: profile,
+		Data: "Task unassigned successfully",
 	})
 }
 
-func (h *Handler) GetProfileList(c *fiber.Ctx) error {
-	ownerId := c.Query("owner_id", "")
-	ownerIds := strings.Split(ownerId, ",")
-	if len(ownerIds) == 0 || ownerId == "" {
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid owner id")
+func (h *Handler) AddLabels(c *fiber.Ctx) error {
+	taskId := c.Params("taskId")
+	ownerId := c.Params("ownerId")
+	payload := struct {
+		Labels []string `json:"labels"`
+	}{}
+	if err := c.BodyParser(&payload); err != nil {
+		return errInvalidBody.Wrap(err)
 	}
-	if len(ownerIds) > config.Conf.Pagination.MaxGetProfileLimit {
-		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Limit cannot be more than %v", config.Conf.Pagination.MaxGetProfileLimit))
+	if len(payload.Labels) == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Labels are required")
 	}
-	profiles, err := h.profile.GetProfileList(c.Context(), ownerIds)
+	labels, err := taskmanager.NormalizeLabels(payload.Labels)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
 	}
 
+	matchedCount, err := h.task.AddLabels(c.Context(), ownerId, taskId, labels)
+	if err != nil {
+		return err
+	}
+	if matchedCount == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Task or account not found")
+	}
 	return c.JSON(response{
-		Data: profiles,
+		Data: "Labels added successfully",
 	})
 }
 
-func (h *Handler) validateOwnerId(c *fiber.Ctx, ownerId string) error {
-	if ownerId == "" {
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid owner id")
+func (h *Handler) RemoveLabel(c *fiber.Ctx) error {
+	taskId := c.Params("taskId")
+	ownerId := c.Params("ownerId")
+	label, err := url.PathUnescape(c.Params("label"))
+	if err != nil {
+		return taskmanager.ErrInvalidLabel.Wrap(err)
 	}
-	profile, err := h.profile.GetProfile(c.Context(), ownerId)
+	labels, err := taskmanager.NormalizeLabels([]string{label})
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
 	}
 
-	if profile == nil {
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid owner id")
+	matchedCount, err := h.task.RemoveLabels(c.Context(), ownerId, taskId, labels)
+	if err != nil {
+		return err
+	}
+	if matchedCount == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Task or account not found")
+	}
+	return c.JSON(response{
+		Data: "Label removed successfully",
+	})
+}
+
+// GetLabels lists labels with the number of tasks using them, optionally for one owner.
+func (h *Handler) GetLabels(c *fiber.Ctx) error {
+	labels, err := h.task.GetLabels(c.Context(), strings.TrimSpace(c.Query("owner_id")))
+	if err != nil {
+		return err
+	}
+	return c.JSON(response{
+		Data: labels,
+	})
+}
+
+func (h *Handler) AddDependency(c *fiber.Ctx) error {
+	taskId := c.Params("taskId")
+	ownerId := c.Params("ownerId")
+	payload := struct {
+		BlockedBy string `json:"blocked_by"`
+	}{}
+	if err := c.BodyParser(&payload); err != nil {
+		return errInvalidBody.Wrap(err)
+	}
+	blockerId := strings.TrimSpace(payload.BlockedBy)
+	if blockerId == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Blocking task is required")
+	}
+
+	matchedCount, err := h.task.AddDependency(c.Context(), ownerId, taskId, blockerId)
+	if err != nil {
+		return err
+	}
+	if matchedCount == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Task or account not found")
+	}
+	return c.JSON(response{
+		Data: "Dependency added successfully",
+	})
+}
+
+func (h *Handler) RemoveDependency(c *fiber.Ctx) error {
+	taskId := c.Params("taskId")
+	ownerId := c.Params("ownerId")
+	matchedCount, err := h.task.RemoveDependency(c.Context(), ownerId, taskId, c.Params("blockerId"))
+	if err != nil {
+		return err
+	}
+	if matchedCount == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Task or account not found")
+	}
+	return c.JSON(response{
+		Data: "Dependency removed successfully",
+	})
+}
+
+func (h *Handler) CreateRecurrence(c *fiber.Ctx) error {
+	payload := struct {
+		Topic       string          `json:"topic"`
+		Description string          `json:"description"`
+		Priority    json.RawMessage `json:"priority"`
+		RRule       string          `json:"rrule"`
+		StartDate   string          `json:"start_date"`
+		TZ          string          `json:"tz"`
+	}{}
+	if err := c.BodyParser(&payload); err != nil {
+		return errInvalidBody.Wrap(err)
+	}
+	topic := strings.TrimSpace(payload.Topic)
+	description := strings.TrimSpace(payload.Description)
+	if topic == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Topic is required")
+	}
+	if description == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Description is required")
+	}
+	priority, err := bodyPriority(payload.Priority)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(payload.RRule) == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Rule is required")
+	}
+	if strings.TrimSpace(payload.StartDate) == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Start date is required")
+	}
+	loc, err := location(payload.TZ)
+	if err != nil {
+		return err
+	}
+	start, err := parseDate("start_date", payload.StartDate, loc)
+	if err != nil {
+		return err
+	}
+
+	ownerId := c.Params("ownerId")
+	if err := h.validateOwnerId(c, ownerId); err != nil {
+		return err
+	}
+
+	template := recurrence.Template{
+		Topic:       topic,
+		Description: description,
+	}
+	if priority != nil {
+		template.Priority = *priority
+	}
+	doc, err := h.recurrence.CreateRecurrence(c.Context(), ownerId, recurrence.NewRecurrence{
+		Template:  template,
+		Rule:      payload.RRule,
+		TZ:        loc.String(),
+		StartDate: start,
+	})
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusCreated).JSON(response{
+		Data: doc,
+	})
+}
+
+func (h *Handler) GetRecurrences(c *fiber.Ctx) error {
+	recurrences, err := h.recurrence.GetRecurrences(c.Context(), c.Params("ownerId"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(response{
+		Data: recurrences,
+	})
+}
+
+func (h *Handler) EndRecurrence(c *fiber.Ctx) error {
+	matchedCount, err := h.recurrence.EndRecurrence(c.Context(), c.Params("ownerId"), c.Params("recurrenceId"))
+	if err != nil {
+		return err
+	}
+	if matchedCount == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Recurrence or account not found")
+	}
+	return c.JSON(response{
+		Data: "Recurrence ended successfully",
+	})
+}
+
+func (h *Handler) GetTopicComments(c *fiber.Ctx) error {
+	page, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+
+	topLevel := false
+	if value := c.Query("top_level"); value != "" {
+		if topLevel, err = strconv.ParseBool(value); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid top_level")
+		}
+	}
+
+	render, err := parseRender(c)
+	if err != nil {
+		return err
+	}
+	viewer, err := h.viewer(c)
+	if err != nil {
+		return err
+	}
+
+	taskId := c.Params("taskId")
+	comments, pageInfo, err := h.comment.GetTopicComments(c.Context(), taskId, topLevel, page)
+	if err != nil {
+		return err
+	}
+	views, err := h.commentViews(c, taskId, comments, viewer, render)
+	if err != nil {
+		return err
+	}
+	// a page has no date of its own, new comments change its total
+	body, err := c.App().Config().JSONEncoder(pageResponse(c, views, page, pageInfo))
+	if err != nil {
+		return err
+	}
+	return sendFresh(c, body, contentTag(body), 0)
+}
+
+// GetCommentReplies pages through the direct replies of a comment.
+func (h *Handler) GetCommentReplies(c *fiber.Ctx) error {
+	page, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+
+	render, err := parseRender(c)
+	if err != nil {
+		return err
+	}
+	viewer, err := h.viewer(c)
+	if err != nil {
+		return err
+	}
+
+	taskId := c.Params("taskId")
+	replies, pageInfo, err := h.comment.GetReplies(c.Context(), taskId, c.Params("commentId"), page)
+	if err != nil {
+		return err
+	}
+	views, err := h.commentViews(c, taskId, replies, viewer, render)
+	if err != nil {
+		return err
+	}
+	body, err := c.App().Config().JSONEncoder(pageResponse(c, views, page, pageInfo))
+	if err != nil {
+		return err
+	}
+	return sendFresh(c, body, contentTag(body), 0)
+}
+
+// commentViews attaches the reactions of the viewer to a page of comments,
+// rendering their content when render is set.
+func (h *Handler) commentViews(c *fiber.Ctx, taskId string, comments []comment.CommentDoc, viewer string, render bool) ([]commentView, error) {
+	ids := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+	}
+	reactions, err := h.reaction.CommentSummaries(c.Context(), taskId, ids, viewer)
+	if err != nil {
+		return nil, err
+	}
+	views := make([]commentView, 0, len(comments))
+	for _, comment := range comments {
+		view := commentView{CommentDoc: comment, Reactions: reactions[comment.ID]}
+		if render {
+			view.ContentHTML = markdown.Render(comment.Content)
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+func (h *Handler) GetTaskHistory(c *fiber.Ctx) error {
+	page, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+
+	records, pageInfo, err := h.history.GetTaskHistory(c.Context(), c.Params("taskId"), page)
+	if err != nil {
+		return err
+	}
+	return c.JSON(pageResponse(c, records, page, pageInfo))
+}
+
+func (h *Handler) CreateComment(c *fiber.Ctx) error {
+	payload := struct {
+		Content  string `json:"content"`
+		ParentID string `json:"parent_comment_id"`
+	}{}
+
+	ownerId := c.Params("ownerId")
+	taskId := c.Params("taskId")
+	if err := c.BodyParser(&payload); err != nil {
+		return errInvalidBody.Wrap(err)
+	}
+
+	content := strings.TrimSpace(payload.Content)
+	if content == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Content is required")
+	}
+	if err := h.validateOwnerId(c, ownerId); err != nil {
+		return err
+	}
+
+	comment, err := h.comment.CreateComment(c.Context(), ownerId, taskId, content, strings.TrimSpace(payload.ParentID))
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusCreated).JSON(response{
+		Data: comment,
+	})
+}
+
+// UpdateComment lets the author fix the content of a comment.
+func (h *Handler) UpdateComment(c *fiber.Ctx) error {
+	payload := struct {
+		Content string `json:"content"`
+	}{}
+	if err := c.BodyParser(&payload); err != nil {
+		return errInvalidBody.Wrap(err)
+	}
+
+	content := strings.TrimSpace(payload.Content)
+	if content == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Content is required")
+	}
+
+	comment, err := h.comment.UpdateComment(c.Context(), c.Params("ownerId"), c.Params("taskId"), c.Params("commentId"), content)
+	if err != nil {
+		return err
+	}
+	return c.JSON(response{
+		Data: comment,
+	})
+}
+
+// DeleteComment soft deletes a comment of the author.
+func (h *Handler) DeleteComment(c *fiber.Ctx) error {
+	err := h.comment.DeleteComment(c.Context(), c.Params("ownerId"), c.Params("taskId"), c.Params("commentId"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(response{
+		Data: "Comment deleted successfully",
+	})
+}
+
+// GetCommentRevisions lists the previous contents of a comment to its author.
+func (h *Handler) GetCommentRevisions(c *fiber.Ctx) error {
+	revisions, err := h.comment.GetCommentRevisions(c.Context(), c.Params("ownerId"), c.Params("taskId"), c.Params("commentId"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(response{
+		Data: revisions,
+	})
+}
+
+// GetMentions lists the unread mentions of the owner, oldest first.
+func (h *Handler) GetMentions(c *fiber.Ctx) error {
+	page, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+
+	mentions, pageInfo, err := h.mention.GetUnread(c.Context(), c.Params("ownerId"), page)
+	if err != nil {
+		return err
+	}
+	return c.JSON(pageResponse(c, mentions, page, pageInfo))
+}
+
+func (h *Handler) MarkMentionRead(c *fiber.Ctx) error {
+	matchedCount, err := h.mention.MarkRead(c.Context(), c.Params("ownerId"), c.Params("mentionId"))
+	if err != nil {
+		return err
+	}
+
+	if matchedCount == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Mention or account not found")
+	}
+
+	return c.JSON(response{
+		Data: "Mention marked as read",
+	})
+}
+
+// AddReaction reacts with an emoji to a task, or to one of its comments
+// when the route has a :commentId.
+func (h *Handler) AddReaction(c *fiber.Ctx) error {
+	payload := struct {
+		Emoji string `json:"emoji"`
+	}{}
+	if err := c.BodyParser(&payload); err != nil {
+		return errInvalidBody.Wrap(err)
+	}
+
+	ownerId := c.Params("ownerId")
+	if err := h.validateOwnerId(c, ownerId); err != nil {
+		return err
+	}
+
+	reaction, err := h.reaction.AddReaction(c.Context(), ownerId, c.Params("taskId"), c.Params("commentId"), strings.TrimSpace(payload.Emoji))
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusCreated).JSON(response{
+		Data: reaction,
+	})
+}
+
+func (h *Handler) RemoveReaction(c *fiber.Ctx) error {
+	emoji, err := url.PathUnescape(c.Params("emoji"))
+	if err != nil {
+		return reaction.ErrInvalidEmoji.Wrap(err)
+	}
+
+	deletedCount, err := h.reaction.RemoveReaction(c.Context(), c.Params("ownerId"), c.Params("taskId"), c.Params("commentId"), emoji)
+	if err != nil {
+		return err
+	}
+	if deletedCount == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Reaction or account not found")
+	}
+	return c.JSON(response{
+		Data: "Reaction removed successfully",
+	})
+}
+
+func (h *Handler) GetProfile(c *fiber.Ctx) error {
+	ownerId := c.Params("ownerId")
+	profile, err := h.profile.GetProfile(c.Context(), ownerId)
+	if err != nil {
+		return err
+	}
+
+	body, err := c.App().Config().JSONEncoder(response{
+		Data: profile,
+	})
+	if err != nil {
+		return err
+	}
+	return sendFresh(c, body, contentTag(body), lastModified(profile.CreateDate, &profile.UpdateDate))
+}
+
+func (h *Handler) GetProfileList(c *fiber.Ctx) error {
+	ownerId := c.Query("owner_id", "")
+	ownerIds := strings.Split(ownerId, ",")
+	if len(ownerIds) == 0 || ownerId == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid owner id")
+	}
+	if len(ownerIds) > config.Conf.Pagination.MaxGetProfileLimit {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Limit cannot be more than %v", config.Conf.Pagination.MaxGetProfileLimit))
+	}
+	profiles, err := h.profile.GetProfileList(c.Context(), ownerIds)
+	if err != nil {
+		return err
+	}
+
+	total := int64(len(profiles))
+	return c.JSON(response{
+		Data: profiles,
+		Meta: &meta{
+			Page:  1,
+			Limit: len(ownerIds),
+			Total: &total,
+		},
+		Links: &links{
+			Self: c.OriginalURL(),
+		},
+	})
+}
+
+// pageResponse wraps a list with its pagination meta and self/next/prev links.
+func pageResponse(c *fiber.Ctx, data interface{}, page m.Pagination, info *m.PageInfo) response {
+	resp := response{
+		Data:       data,
+		NextCursor: info.NextCursor,
+		Meta: &meta{
+			Page:    page.Page,
+			Limit:   page.Limit,
+			Total:   info.Total,
+			HasNext: info.HasNext,
+		},
+		Links: &links{
+			Self: c.OriginalURL(),
+		},
+	}
+
+	if page.IsKeyset() {
+		if info.HasNext {
+			resp.Links.Next = pageLink(c, "cursor", info.NextCursor)
+		}
+		return resp
+	}
+	if info.HasNext {
+		resp.Links.Next = pageLink(c, "page", strconv.Itoa(page.Page+1))
+	}
+	if page.Page > 1 {
+		resp.Links.Prev = pageLink(c, "page", strconv.Itoa(page.Page-1))
+	}
+	return resp
+}
+
+// pageLink returns the current path and query with key replaced by value.
+func pageLink(c *fiber.Ctx, key string, value string) string {
+	query, _ := url.ParseQuery(string(c.Request().URI().QueryString()))
+	query.Set(key, value)
+	return c.Path() + "?" + query.Encode()
+}
+
+// parsePagination reads page and limit, or cursor for keyset pagination.
+// Passing cursor, even empty for the first page, selects keyset mode.
+func parsePagination(c *fiber.Ctx) (m.Pagination, error) {
+	page := m.Pagination{}
+	limitInt, err := strconv.Atoi(c.Query("limit", "10"))
+	if err != nil || limitInt < 1 {
+		return page, fiber.NewError(fiber.StatusBadRequest, "Invalid limit number")
+	}
+	if limitInt > config.Conf.Pagination.MaxLimit {
+		return page, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Limit cannot be more than %v", config.Conf.Pagination.MaxLimit))
+	}
+	page.Limit = limitInt
+
+	page.SkipCount = c.Query("count") == "false"
+
+	if c.Context().QueryArgs().Has("cursor") {
+		page.Cursor = c.Query("cursor")
+		return page, nil
+	}
+
+	pageInt, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || pageInt < 1 {
+		return page, fiber.NewError(fiber.StatusBadRequest, "Invalid page number")
+	}
+	page.Page = pageInt
+	return page, nil
+}
+
+func parseTaskFilter(c *fiber.Ctx) (taskmanager.TaskFilter, error) {
+	filter := taskmanager.TaskFilter{
+		OwnerID:  strings.TrimSpace(c.Query("owner_id")),
+		Assignee: strings.TrimSpace(c.Query("assignee")),
+	}
+	for _, status := range queryValues(c, "status") {
+		statusInt, err := strconv.Atoi(status)
+		if err != nil {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "Invalid status")
+		}
+		filter.Status = append(filter.Status, statusInt)
+	}
+	if values := queryValues(c, "label"); len(values) > 0 {
+		labels, err := taskmanager.NormalizeLabels(values)
+		if err != nil {
+			return filter, err
+		}
+		filter.Labels = labels
+	}
+	switch c.Query("label_match", "any") {
+	case "any":
+	case "all":
+		filter.AllLabels = true
+	default:
+		return filter, fiber.NewError(fiber.StatusBadRequest, "Invalid label_match")
+	}
+	for _, value := range queryValues(c, "priority") {
+		priority, err := taskmanager.ParsePriority(value)
+		if err != nil {
+			return filter, err
+		}
+		filter.Priority = append(filter.Priority, priority)
+	}
+
+	var err error
+	dates := map[string]**int64{
+		"created_from": &filter.CreatedFrom,
+		"created_to":   &filter.CreatedTo,
+		"updated_from": &filter.UpdatedFrom,
+		"updated_to":   &filter.UpdatedTo,
+	}
+	for key, target := range dates {
+		if *target, err = queryInt64(c, key); err != nil {
+			return filter, err
+		}
+	}
+
+	loc, err := location(c.Query("tz"))
+	if err != nil {
+		return filter, err
+	}
+	if filter.DueAfter, err = queryDate(c, "due_after", loc); err != nil {
+		return filter, err
+	}
+	if filter.DueBefore, err = queryDate(c, "due_before", loc); err != nil {
+		return filter, err
+	}
+	if overdue := c.Query("overdue"); overdue != "" {
+		if filter.Overdue, err = strconv.ParseBool(overdue); err != nil {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "Invalid overdue")
+		}
+	}
+
+	if filter.Sort, err = taskmanager.ParseSort(c.Query("sort")); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// queryValues accepts both repeated (?status=1&status=2) and comma separated (?status=1,2) values.
+func queryValues(c *fiber.Ctx, key string) []string {
+	var values []string
+	for _, raw := range c.Context().QueryArgs().PeekMulti(key) {
+		for _, v := range strings.Split(string(raw), ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+// parseRender reads the render query, html adds a sanitized HTML rendering
+// of the markdown fields next to their source.
+func parseRender(c *fiber.Ctx) (bool, error) {
+	switch c.Query("render") {
+	case "":
+		return false, nil
+	case "html":
+		return true, nil
+	}
+	return false, fiber.NewError(fiber.StatusBadRequest, "Invalid render")
+}
+
+func queryInt64(c *fiber.Ctx, key string) (*int64, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Invalid %v", key))
+	}
+	return &v, nil
+}
+
+func (h *Handler) validateOwnerId(c *fiber.Ctx, ownerId string) error {
+	if ownerId == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid owner id")
+	}
+	if _, err := h.profile.GetProfile(c.Context(), ownerId); err != nil {
+		if errors.Is(err, profile.ErrProfileNotFound) {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid owner id")
+		}
+		return err
 	}
-
 	return nil
 }
@@ -6,14 +6,23 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"task-manager-api/config"
+	"task-manager-api/internal/apperror"
+	"task-manager-api/internal/auth"
 	"task-manager-api/internal/comment"
 	mock "task-manager-api/internal/handler/mock"
+	"task-manager-api/internal/history"
+	"task-manager-api/internal/mention"
+	m "task-manager-api/internal/mongo"
 	"task-manager-api/internal/profile"
+	"task-manager-api/internal/reaction"
+	"task-manager-api/internal/recurrence"
 	"task-manager-api/internal/taskmanager"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/suite"
 )
@@ -25,6 +34,11 @@ type HandlerTestSuite struct {
 	taskService    *mock.MockITasks
 	commentService *mock.MockIComments
 	profileService *mock.MockIProfile
+	authService    *mock.MockIAuth
+	recurrences    *mock.MockIRecurrences
+	history        *mock.MockIHistory
+	mentions       *mock.MockIMentions
+	reactions      *mock.MockIReactions
 }
 
 func (t *HandlerTestSuite) SetupTest() {
@@ -32,7 +46,13 @@ func (t *HandlerTestSuite) SetupTest() {
 	t.taskService = mock.NewMockITasks(t.ctrl)
 	t.commentService = mock.NewMockIComments(t.ctrl)
 	t.profileService = mock.NewMockIProfile(t.ctrl)
-	t.handler = NewHandler(t.taskService, t.commentService, t.profileService)
+	t.authService = mock.NewMockIAuth(t.ctrl)
+	t.recurrences = mock.NewMockIRecurrences(t.ctrl)
+	t.history = mock.NewMockIHistory(t.ctrl)
+	t.mentions = mock.NewMockIMentions(t.ctrl)
+	t.reactions = mock.NewMockIReactions(t.ctrl)
+	t.handler = NewHandler(t.taskService, t.commentService, t.profileService, t.authService, t.recurrences, t.history, t.mentions, t.reactions)
+	t.taskService.EXPECT().Workflow().Return(taskmanager.DefaultWorkflow()).AnyTimes()
 
 	config.Conf = &config.Config{}
 	config.Conf.Pagination.MaxGetProfileLimit = 3
@@ -45,6 +65,11 @@ func (t *HandlerTestSuite) TearDownTest() {
 	t.taskService = nil
 	t.commentService = nil
 	t.profileService = nil
+	t.authService = nil
+	t.recurrences = nil
+	t.history = nil
+	t.mentions = nil
+	t.reactions = nil
 }
 
 func TestCHandlerTestSuite(t *testing.T) {
@@ -82,7 +107,7 @@ func (t HandlerTestSuite) TestCreateTask() {
 	})
 	t.Run("create task but service has error should return error", func() {
 		t.profileService.EXPECT().GetProfile(gomock.Any(), "1234").Return(&profile.ProfileDoc{}, nil)
-		t.taskService.EXPECT().CreateTask(gomock.Any(), "1234", "test_topic", "mock_desv").Return(&taskmanager.TaskDoc{}, errors.New("create task error"))
+		t.taskService.EXPECT().CreateTask(gomock.Any(), "1234", taskmanager.NewTask{Topic: "test_topic", Description: "mock_desv"}).Return(&taskmanager.TaskDoc{}, errors.New("create task error"))
 		// Define Fiber app.
 		app := fiber.New()
 		// Create route with POST method for test
@@ -96,6 +121,18 @@ func (t HandlerTestSuite) TestCreateTask() {
 		t.Equal(500, resp.StatusCode)
 	})
 
+	t.Run("create task for an owner without profile should return bad request", func() {
+		t.profileService.EXPECT().GetProfile(gomock.Any(), "1234").Return(nil, profile.ErrProfileNotFound)
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Post("/account/:ownerId/tasks", func(c *fiber.Ctx) error {
+			return t.handler.CreateTask(c)
+		})
+		req := httptest.NewRequest("POST", "/account/1234/tasks", strings.NewReader(`{"topic":"test_topic","description":"mock_desv"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := app.Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
 	t.Run("create task but profile service has error should return error", func() {
 		t.profileService.EXPECT().GetProfile(gomock.Any(), "1234").Return(&profile.ProfileDoc{}, errors.New("get profile error"))
 		// Define Fiber app.
@@ -113,7 +150,7 @@ func (t HandlerTestSuite) TestCreateTask() {
 
 	t.Run("create task success return task", func() {
 		t.profileService.EXPECT().GetProfile(gomock.Any(), "12345").Return(&profile.ProfileDoc{}, nil)
-		t.taskService.EXPECT().CreateTask(gomock.Any(), "12345", "test_topic", "mock_desv").Return(&taskmanager.TaskDoc{
+		t.taskService.EXPECT().CreateTask(gomock.Any(), "12345", taskmanager.NewTask{Topic: "test_topic", Description: "mock_desv"}).Return(&taskmanager.TaskDoc{
 			ID:          "1234",
 			OwnerID:     "12345",
 			Topic:       "test_topic",
@@ -133,7 +170,20 @@ func (t HandlerTestSuite) TestCreateTask() {
 		resp, _ := app.Test(req, 20)
 		t.Equal(201, resp.StatusCode)
 		b, _ := io.ReadAll(resp.Body)
-		t.Equal(`{"data":{"id":"1234","topic":"test_topic","description":"mock_desv","status":1,"create_date":2131341,"owner_id":"12345","archive_date":null,"update_date":null}}`, string(b))
+		t.Equal(`{"data":{"id":"1234","topic":"test_topic","description":"mock_desv","status":1,"create_date":2131341,"owner_id":"12345","archive_date":null,"update_date":null,"priority":0,"version":0}}`, string(b))
+	})
+
+	t.Run("create task should accept a priority by name", func() {
+		t.profileService.EXPECT().GetProfile(gomock.Any(), "12345").Return(&profile.ProfileDoc{}, nil)
+		t.taskService.EXPECT().CreateTask(gomock.Any(), "12345", taskmanager.NewTask{Topic: "test_topic", Description: "mock_desv", Priority: taskmanager.PriorityUrgent}).Return(&taskmanager.TaskDoc{ID: "1234"}, nil)
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Post("/account/:ownerId/tasks", func(c *fiber.Ctx) error {
+			return t.handler.CreateTask(c)
+		})
+		req := httptest.NewRequest("POST", "/account/12345/tasks", strings.NewReader(`{"topic":"test_topic","description":"mock_desv","priority":"urgent"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := app.Test(req, 20)
+		t.Equal(201, resp.StatusCode)
 	})
 }
 
@@ -153,7 +203,34 @@ func (t HandlerTestSuite) TestGetTask() {
 		t.Equal(500, resp.StatusCode)
 	})
 
+	t.Run("get task but task not found should return 404", func() {
+		t.taskService.EXPECT().GetTask(gomock.Any(), "1234").Return(nil, taskmanager.ErrTaskNotFound.Wrap(errors.New("mongo: no documents in result")))
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Get("/tasks/:taskId", func(c *fiber.Ctx) error {
+			return t.handler.GetTask(c)
+		})
+		req := httptest.NewRequest("GET", "/tasks/1234", nil)
+		resp, _ := app.Test(req, 20)
+		t.Equal(404, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"error_code":"task_not_found","error_msg":"Task not found","status":404}`, string(b))
+	})
+
+	t.Run("get task but task id is malformed should return 400", func() {
+		t.taskService.EXPECT().GetTask(gomock.Any(), "xyz").Return(nil, taskmanager.ErrInvalidTaskID.Wrap(errors.New("the provided hex string is not a valid ObjectID")))
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Get("/tasks/:taskId", func(c *fiber.Ctx) error {
+			return t.handler.GetTask(c)
+		})
+		req := httptest.NewRequest("GET", "/tasks/xyz", nil)
+		resp, _ := app.Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"error_code":"invalid_task_id","error_msg":"Invalid task id","status":400}`, string(b))
+	})
+
 	t.Run("get task success return task", func() {
+		t.reactions.EXPECT().TaskSummary(gomock.Any(), "1234", "").Return(nil, nil)
 		t.taskService.EXPECT().GetTask(gomock.Any(), "1234").Return(&taskmanager.TaskDoc{
 			ID:          "1234",
 			OwnerID:     "12345",
@@ -174,7 +251,68 @@ func (t HandlerTestSuite) TestGetTask() {
 		resp, _ := app.Test(req, 20)
 		t.Equal(200, resp.StatusCode)
 		b, _ := io.ReadAll(resp.Body)
-		t.Equal(`{"data":{"id":"1234","topic":"test_topic","description":"mock_desv","status":1,"create_date":2131341,"owner_id":"12345","archive_date":null,"update_date":null}}`, string(b))
+		t.Equal(`{"data":{"id":"1234","topic":"test_topic","description":"mock_desv","status":1,"create_date":2131341,"owner_id":"12345","archive_date":null,"update_date":null,"priority":0,"version":0}}`, string(b))
+	})
+
+	updated := int64(1683712800)
+	newApp := func() *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Get("/tasks/:taskId", func(c *fiber.Ctx) error {
+			return t.handler.GetTask(c)
+		})
+		return app
+	}
+	expectTask := func() {
+		t.taskService.EXPECT().GetTask(gomock.Any(), "1234").Return(&taskmanager.TaskDoc{ID: "1234", Version: 7, CreateDate: 1683700000, UpdateDate: &updated}, nil)
+		t.reactions.EXPECT().TaskSummary(gomock.Any(), "1234", "").Return(nil, nil)
+	}
+
+	t.Run("get task should return the version in etag and the update date", func() {
+		expectTask()
+		resp, _ := newApp().Test(httptest.NewRequest("GET", "/tasks/1234", nil), 20)
+		t.Equal(200, resp.StatusCode)
+		t.True(strings.HasPrefix(resp.Header.Get("ETag"), `"7-`))
+		t.Equal("Wed, 10 May 2023 10:00:00 GMT", resp.Header.Get("Last-Modified"))
+	})
+
+	t.Run("get task with matching if-none-match should return 304", func() {
+		expectTask()
+		resp, _ := newApp().Test(httptest.NewRequest("GET", "/tasks/1234", nil), 20)
+		tag := resp.Header.Get("ETag")
+
+		expectTask()
+		req := httptest.NewRequest("GET", "/tasks/1234", nil)
+		req.Header.Set("If-None-Match", `"other", W/`+tag)
+		resp, _ = newApp().Test(req, 20)
+		t.Equal(304, resp.StatusCode)
+		t.Equal(tag, resp.Header.Get("ETag"))
+		b, _ := io.ReadAll(resp.Body)
+		t.Empty(b)
+	})
+
+	t.Run("get task with stale if-none-match should ignore if-modified-since", func() {
+		expectTask()
+		req := httptest.NewRequest("GET", "/tasks/1234", nil)
+		req.Header.Set("If-None-Match", `"6-0000000000000000"`)
+		req.Header.Set("If-Modified-Since", "Wed, 10 May 2023 10:00:00 GMT")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+	})
+
+	t.Run("get task not modified since should return 304", func() {
+		expectTask()
+		req := httptest.NewRequest("GET", "/tasks/1234", nil)
+		req.Header.Set("If-Modified-Since", "Wed, 10 May 2023 10:00:00 GMT")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(304, resp.StatusCode)
+	})
+
+	t.Run("get task modified since should return task", func() {
+		expectTask()
+		req := httptest.NewRequest("GET", "/tasks/1234", nil)
+		req.Header.Set("If-Modified-Since", "Wed, 10 May 2023 09:59:59 GMT")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
 	})
 }
 
@@ -194,7 +332,7 @@ func (t HandlerTestSuite) TestUpdateTask() {
 	})
 
 	t.Run("update task but service has error should return error", func() {
-		t.taskService.EXPECT().UpdateTaskStatus(gomock.Any(), "1234", "1234", 1).Return(errors.New("update task error"))
+		t.taskService.EXPECT().UpdateTaskStatus(gomock.Any(), "1234", "1234", 1, false, nil).Return(errors.New("update task error"))
 		// Define Fiber app.
 		app := fiber.New()
 		// Create route with PATCH method for test
@@ -209,7 +347,7 @@ func (t HandlerTestSuite) TestUpdateTask() {
 	})
 
 	t.Run("update task success return task", func() {
-		t.taskService.EXPECT().UpdateTaskStatus(gomock.Any(), "1234", "1234", 1).Return(nil)
+		t.taskService.EXPECT().UpdateTaskStatus(gomock.Any(), "1234", "1234", 1, false, nil).Return(nil)
 		// Define Fiber app.
 		app := fiber.New()
 		// Create route with PATCH method for test
@@ -225,11 +363,281 @@ func (t HandlerTestSuite) TestUpdateTask() {
 		t.Equal(`{"data":"Task status updated successfully"}`, string(b))
 	})
 
+	t.Run("update task with illegal transition should return 409 without updating fields", func() {
+		topic := "new topic"
+		status := 3
+		t.taskService.EXPECT().UpdateTask(gomock.Any(), "1234", "1234", taskmanager.TaskUpdate{Topic: &topic, Status: &status}, nil).Return(0, taskmanager.ErrIllegalTransition)
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Patch("/account/:ownerId/tasks/:taskId", func(c *fiber.Ctx) error {
+			return t.handler.UpdateTask(c)
+		})
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/1234", strings.NewReader(`{"status":3,"topic":"new topic"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := app.Test(req, 20)
+		t.Equal(409, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"error_code":"illegal_transition","error_msg":"Status transition not allowed","status":409}`, string(b))
+	})
+}
+
+func (t *HandlerTestSuite) TestGetSubtasks() {
+	newApp := func() *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Get("/tasks/:taskId/subtasks", func(c *fiber.Ctx) error {
+			return t.handler.GetSubtasks(c)
+		})
+		return app
+	}
+
+	t.Run("get subtasks of missing task should return 404", func() {
+		t.taskService.EXPECT().GetTask(gomock.Any(), "6041c3a6cfcba2fb9c4a4fd2").Return(nil, taskmanager.ErrTaskNotFound)
+		req := httptest.NewRequest("GET", "/tasks/6041c3a6cfcba2fb9c4a4fd2/subtasks", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(404, resp.StatusCode)
+	})
+
+	t.Run("get subtasks should filter by parent", func() {
+		t.taskService.EXPECT().GetTask(gomock.Any(), "6041c3a6cfcba2fb9c4a4fd2").Return(&taskmanager.TaskDoc{ID: "6041c3a6cfcba2fb9c4a4fd2"}, nil)
+		t.taskService.EXPECT().GetAllTask(gomock.Any(), taskmanager.TaskFilter{
+			ParentID: "6041c3a6cfcba2fb9c4a4fd2",
+			Status:   []int{1},
+		}, m.Pagination{Page: 1, Limit: 10}).Return([]taskmanager.TaskDoc{}, &m.PageInfo{}, nil)
+		req := httptest.NewRequest("GET", "/tasks/6041c3a6cfcba2fb9c4a4fd2/subtasks?status=1", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":[],"meta":{"page":1,"limit":10,"has_next":false},"links":{"self":"/tasks/6041c3a6cfcba2fb9c4a4fd2/subtasks?status=1"}}`, string(b))
+	})
+}
+
+func (t *HandlerTestSuite) TestGetWorkflow() {
+	t.Run("get workflow should list states", func() {
+		app := fiber.New()
+		app.Get("/workflow", func(c *fiber.Ctx) error {
+			return t.handler.GetWorkflow(c)
+		})
+		req := httptest.NewRequest("GET", "/workflow", nil)
+		resp, _ := app.Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":[{"id":1,"name":"open","terminal":false,"transitions":[2,3]},{"id":2,"name":"in_progress","terminal":false,"transitions":[1,3]},{"id":3,"name":"done","terminal":true,"transitions":[1,2]}]}`, string(b))
+	})
+}
+
+func (t *HandlerTestSuite) TestUpdateTaskFields() {
+	newApp := func() *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Patch("/account/:ownerId/tasks/:taskId", func(c *fiber.Ctx) error {
+			return t.handler.UpdateTask(c)
+		})
+		return app
+	}
+	topic := "new topic"
+	description := "new description"
+
+	t.Run("update task without fields should return 400", func() {
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/1234", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("update task with blank topic should return 400", func() {
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/1234", strings.NewReader(`{"topic":"   "}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("update task with blank description should return 400", func() {
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/1234", strings.NewReader(`{"topic":"new topic","description":""}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("update task but task not found should return 400", func() {
+		t.taskService.EXPECT().UpdateTask(gomock.Any(), "1234", "1234", taskmanager.TaskUpdate{Topic: &topic}, nil).Return(0, nil)
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/1234", strings.NewReader(`{"topic":" new topic "}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("update task but service has error should return error", func() {
+		t.taskService.EXPECT().UpdateTask(gomock.Any(), "1234", "1234", taskmanager.TaskUpdate{Topic: &topic}, nil).Return(0, errors.New("update task error"))
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/1234", strings.NewReader(`{"topic":"new topic"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(500, resp.StatusCode)
+	})
+
+	t.Run("update task fields and status success", func() {
+		status := 2
+		t.taskService.EXPECT().UpdateTask(gomock.Any(), "1234", "1234", taskmanager.TaskUpdate{Topic: &topic, Description: &description, Status: &status, Force: true}, nil).Return(1, nil)
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/1234", strings.NewReader(`{"topic":"new topic","description":"new description ","status":2,"force":true}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":"Task updated successfully"}`, string(b))
+	})
+
+	t.Run("update task dates should parse them in the request timezone", func() {
+		// 2023-05-10 18:00 in Bangkok is 11:00 UTC
+		due := time.Date(2023, 5, 10, 11, 0, 0, 0, time.UTC).Unix()
+		var cleared int64
+		t.taskService.EXPECT().UpdateTask(gomock.Any(), "1234", "1234", taskmanager.TaskUpdate{StartDate: &cleared, DueDate: &due}, nil).Return(1, nil)
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/1234", strings.NewReader(`{"due_date":"2023-05-10T18:00","start_date":"","tz":"Asia/Bangkok"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+	})
+
+	t.Run("update task priority should pass it to service", func() {
+		priority := taskmanager.PriorityLow
+		t.taskService.EXPECT().UpdateTask(gomock.Any(), "1234", "1234", taskmanager.TaskUpdate{Priority: &priority}, nil).Return(1, nil)
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/1234", strings.NewReader(`{"priority":1}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+	})
+
+	t.Run("update task with if-match should pass the version along", func() {
+		version, status := int64(4), 2
+		t.taskService.EXPECT().UpdateTask(gomock.Any(), "1234", "1234", taskmanager.TaskUpdate{Topic: &topic, Status: &status}, &version).Return(1, nil)
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/1234", strings.NewReader(`{"topic":"new topic","status":2}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `"4"`)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+	})
+
+	t.Run("update task with a stale version should return 412", func() {
+		version := int64(4)
+		t.taskService.EXPECT().UpdateTask(gomock.Any(), "1234", "1234", taskmanager.TaskUpdate{Topic: &topic}, &version).Return(0, taskmanager.ErrVersionMismatch)
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/1234", strings.NewReader(`{"topic":"new topic"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `W/"4"`)
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Patch("/account/:ownerId/tasks/:taskId", func(c *fiber.Ctx) error {
+			return t.handler.UpdateTask(c)
+		})
+		resp, _ := app.Test(req, 20)
+		t.Equal(412, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"error_code":"version_mismatch","error_msg":"Task was modified, reload it and try again","status":412}`, string(b))
+	})
+
+	t.Run("update task with malformed if-match should return 400", func() {
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/1234", strings.NewReader(`{"topic":"new topic"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `"4", "5"`)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("update task priority by name should pass its level to service", func() {
+		priority := taskmanager.PriorityHigh
+		t.taskService.EXPECT().UpdateTask(gomock.Any(), "1234", "1234", taskmanager.TaskUpdate{Priority: &priority}, nil).Return(1, nil)
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/1234", strings.NewReader(`{"priority":"High"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+	})
+
+	t.Run("update task with unknown priority name should return 400", func() {
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/1234", strings.NewReader(`{"priority":"critical"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Contains(string(b), `"error_code":"invalid_priority"`)
+	})
+
+	t.Run("update task with unknown priority should return 400", func() {
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/1234", strings.NewReader(`{"priority":9}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("update task with invalid date should return 400", func() {
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/1234", strings.NewReader(`{"due_date":"next friday"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("update task with unknown timezone should return 400", func() {
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/1234", strings.NewReader(`{"due_date":"2023-05-10","tz":"Mars/Olympus"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+}
+
+func (t *HandlerTestSuite) TestCreateTaskDates() {
+	newApp := func() *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Post("/account/:ownerId/tasks", func(c *fiber.Ctx) error {
+			return t.handler.CreateTask(c)
+		})
+		return app
+	}
+
+	t.Run("create task should default dates to UTC", func() {
+		start := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC).Unix()
+		due := time.Date(2023, 5, 10, 9, 30, 0, 0, time.UTC).Unix()
+		t.profileService.EXPECT().GetProfile(gomock.Any(), "1234").Return(&profile.ProfileDoc{}, nil)
+		t.taskService.EXPECT().CreateTask(gomock.Any(), "1234", taskmanager.NewTask{
+			Topic:       "test_topic",
+			Description: "mock_desv",
+			StartDate:   &start,
+			DueDate:     &due,
+		}).Return(&taskmanager.TaskDoc{}, nil)
+		req := httptest.NewRequest("POST", "/account/1234/tasks", strings.NewReader(`{"topic":"test_topic","description":"mock_desv","start_date":"2023-05-01","due_date":"2023-05-10T16:30:00+07:00"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(201, resp.StatusCode)
+	})
+
+	t.Run("create subtask should pass parent id", func() {
+		t.profileService.EXPECT().GetProfile(gomock.Any(), "1234").Return(&profile.ProfileDoc{}, nil)
+		t.taskService.EXPECT().CreateTask(gomock.Any(), "1234", taskmanager.NewTask{
+			Topic:       "test_topic",
+			Description: "mock_desv",
+			ParentID:    "6041c3a6cfcba2fb9c4a4fd2",
+		}).Return(nil, taskmanager.ErrInvalidParent)
+		req := httptest.NewRequest("POST", "/account/1234/tasks", strings.NewReader(`{"topic":"test_topic","description":"mock_desv","parent_id":"6041c3a6cfcba2fb9c4a4fd2"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Contains(string(b), `"error_code":"invalid_parent"`)
+	})
+
+	t.Run("create task starting after due date should return 400", func() {
+		start, due := int64(1683800000), int64(1683700000)
+		t.profileService.EXPECT().GetProfile(gomock.Any(), "1234").Return(&profile.ProfileDoc{}, nil)
+		t.taskService.EXPECT().CreateTask(gomock.Any(), "1234", taskmanager.NewTask{
+			Topic:       "test_topic",
+			Description: "mock_desv",
+			StartDate:   &start,
+			DueDate:     &due,
+		}).Return(nil, taskmanager.ErrInvalidSchedule)
+		req := httptest.NewRequest("POST", "/account/1234/tasks", strings.NewReader(`{"topic":"test_topic","description":"mock_desv","start_date":"1683800000","due_date":"1683700000"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Contains(string(b), `"error_code":"invalid_schedule"`)
+	})
 }
 
 func (t HandlerTestSuite) TestGetAllTask() {
 	t.Run("get all task but service has error should return error", func() {
-		t.taskService.EXPECT().GetAllTask(gomock.Any(), 1, 10).Return(nil, errors.New("get all task error"))
+		t.taskService.EXPECT().GetAllTask(gomock.Any(), taskmanager.TaskFilter{}, m.Pagination{Page: 1, Limit: 10}).Return(nil, nil, errors.New("get all task error"))
 		// Define Fiber app.
 		app := fiber.New()
 		// Create route with GET method for test
@@ -286,7 +694,7 @@ func (t HandlerTestSuite) TestGetAllTask() {
 	})
 
 	t.Run("get all task success return task", func() {
-		t.taskService.EXPECT().GetAllTask(gomock.Any(), 1, 10).Return([]taskmanager.TaskDoc{
+		t.taskService.EXPECT().GetAllTask(gomock.Any(), taskmanager.TaskFilter{}, m.Pagination{Page: 1, Limit: 10}).Return([]taskmanager.TaskDoc{
 			{
 				ID:          "1234",
 				OwnerID:     "12345",
@@ -295,7 +703,7 @@ func (t HandlerTestSuite) TestGetAllTask() {
 				Status:      1,
 				CreateDate:  2131341,
 			},
-		}, nil)
+		}, &m.PageInfo{}, nil)
 		// Define Fiber app.
 		app := fiber.New()
 		// Create route with GET method for test
@@ -308,13 +716,270 @@ func (t HandlerTestSuite) TestGetAllTask() {
 		resp, _ := app.Test(req, 20)
 		t.Equal(200, resp.StatusCode)
 		b, _ := io.ReadAll(resp.Body)
-		t.Equal(`{"data":[{"id":"1234","topic":"test_topic","description":"mock_desv","status":1,"create_date":2131341,"owner_id":"12345","archive_date":null,"update_date":null}]}`, string(b))
+		t.Equal(`{"data":[{"id":"1234","topic":"test_topic","description":"mock_desv","status":1,"create_date":2131341,"owner_id":"12345","archive_date":null,"update_date":null,"priority":0,"version":0}],"meta":{"page":1,"limit":10,"has_next":false},"links":{"self":"/tasks"}}`, string(b))
+	})
+}
+
+func (t *HandlerTestSuite) TestGetAllTaskFilter() {
+	newApp := func() *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Get("/tasks", func(c *fiber.Ctx) error {
+			return t.handler.GetAllTask(c)
+		})
+		return app
+	}
+
+	t.Run("get all task should pass filters and sort to service", func() {
+		createdFrom := int64(1683721846)
+		updatedTo := int64(1683723423)
+		t.taskService.EXPECT().GetAllTask(gomock.Any(), taskmanager.TaskFilter{
+			OwnerID:     "1234",
+			Status:      []int{1, 2, 3},
+			CreatedFrom: &createdFrom,
+			UpdatedTo:   &updatedTo,
+			Sort: []taskmanager.SortField{
+				{Field: "status"},
+				{Field: "create_date", Desc: true},
+			},
+		}, m.Pagination{Page: 1, Limit: 10}).Return([]taskmanager.TaskDoc{}, &m.PageInfo{}, nil)
+		req := httptest.NewRequest("GET", "/tasks?owner_id=1234&status=1,2&status=3&created_from=1683721846&updated_to=1683723423&sort=status,-create_date", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+	})
+
+	t.Run("get all task should filter by assignee", func() {
+		t.taskService.EXPECT().GetAllTask(gomock.Any(), taskmanager.TaskFilter{
+			Assignee: "5678",
+		}, m.Pagination{Page: 1, Limit: 10}).Return([]taskmanager.TaskDoc{}, &m.PageInfo{}, nil)
+		req := httptest.NewRequest("GET", "/tasks?assignee=5678", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+	})
+
+	t.Run("get all task should filter overdue and due range in timezone", func() {
+		after := time.Date(2023, 4, 30, 17, 0, 0, 0, time.UTC).Unix()
+		before := int64(1683723423)
+		t.taskService.EXPECT().GetAllTask(gomock.Any(), taskmanager.TaskFilter{
+			DueAfter:  &after,
+			DueBefore: &before,
+			Overdue:   true,
+		}, m.Pagination{Page: 1, Limit: 10}).Return([]taskmanager.TaskDoc{}, &m.PageInfo{}, nil)
+		req := httptest.NewRequest("GET", "/tasks?overdue=true&due_after=2023-05-01&due_before=1683723423&tz=Asia/Bangkok", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+	})
+
+	t.Run("get all task should filter by priority and sort for triage", func() {
+		t.taskService.EXPECT().GetAllTask(gomock.Any(), taskmanager.TaskFilter{
+			Priority: []int{taskmanager.PriorityHigh, taskmanager.PriorityUrgent},
+			Sort: []taskmanager.SortField{
+				{Field: "priority", Desc: true},
+				{Field: "due_date"},
+			},
+		}, m.Pagination{Page: 1, Limit: 10}).Return([]taskmanager.TaskDoc{}, &m.PageInfo{}, nil)
+		req := httptest.NewRequest("GET", "/tasks?priority=high,4&sort=triage", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+	})
+
+	t.Run("get all task should filter by all labels", func() {
+		t.taskService.EXPECT().GetAllTask(gomock.Any(), taskmanager.TaskFilter{
+			Labels:    []string{"bug", "ui"},
+			AllLabels: true,
+		}, m.Pagination{Page: 1, Limit: 10}).Return([]taskmanager.TaskDoc{}, &m.PageInfo{}, nil)
+		req := httptest.NewRequest("GET", "/tasks?label=Bug,ui&label_match=all", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+	})
+
+	t.Run("get all task with invalid label match should return 400", func() {
+		req := httptest.NewRequest("GET", "/tasks?label=bug&label_match=some", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("get all task with unknown priority should return 400", func() {
+		req := httptest.NewRequest("GET", "/tasks?priority=critical", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Contains(string(b), `"error_code":"invalid_priority"`)
+	})
+
+	t.Run("get all task with invalid overdue should return 400", func() {
+		req := httptest.NewRequest("GET", "/tasks?overdue=maybe", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("get all task with sort field outside whitelist should return 400", func() {
+		req := httptest.NewRequest("GET", "/tasks?sort=-description", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Contains(string(b), `"error_code":"invalid_sort"`)
+	})
+
+	t.Run("get all task with invalid status should return 400", func() {
+		req := httptest.NewRequest("GET", "/tasks?status=open", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("get all task with invalid date should return 400", func() {
+		req := httptest.NewRequest("GET", "/tasks?created_to=yesterday", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+}
+
+func (t *HandlerTestSuite) TestGetAllTaskCursor() {
+	newApp := func() *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Get("/tasks", func(c *fiber.Ctx) error {
+			return t.handler.GetAllTask(c)
+		})
+		return app
+	}
+
+	t.Run("get all task with empty cursor should request first keyset page", func() {
+		t.taskService.EXPECT().GetAllTask(gomock.Any(), taskmanager.TaskFilter{}, m.Pagination{Limit: 10}).Return([]taskmanager.TaskDoc{}, &m.PageInfo{HasNext: true, NextCursor: "next"}, nil)
+		req := httptest.NewRequest("GET", "/tasks?cursor=", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":[],"next_cursor":"next","meta":{"limit":10,"has_next":true},"links":{"self":"/tasks?cursor=","next":"/tasks?cursor=next"}}`, string(b))
+	})
+
+	t.Run("get all task with cursor should ignore page", func() {
+		t.taskService.EXPECT().GetAllTask(gomock.Any(), taskmanager.TaskFilter{}, m.Pagination{Limit: 5, Cursor: "abc"}).Return([]taskmanager.TaskDoc{}, &m.PageInfo{}, nil)
+		req := httptest.NewRequest("GET", "/tasks?cursor=abc&page=3&limit=5", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":[],"meta":{"limit":5,"has_next":false},"links":{"self":"/tasks?cursor=abc\u0026page=3\u0026limit=5"}}`, string(b))
+	})
+
+	t.Run("get all task with invalid cursor should return 400", func() {
+		t.taskService.EXPECT().GetAllTask(gomock.Any(), taskmanager.TaskFilter{}, m.Pagination{Limit: 10, Cursor: "abc"}).Return(nil, nil, m.ErrInvalidCursor)
+		req := httptest.NewRequest("GET", "/tasks?cursor=abc", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("get all task with page zero should return 400", func() {
+		req := httptest.NewRequest("GET", "/tasks?page=0", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+}
+
+func (t *HandlerTestSuite) TestGetAllTaskEnvelope() {
+	newApp := func() *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Get("/tasks", func(c *fiber.Ctx) error {
+			return t.handler.GetAllTask(c)
+		})
+		return app
+	}
+
+	t.Run("middle page should link to next and previous pages", func() {
+		total := int64(25)
+		t.taskService.EXPECT().GetAllTask(gomock.Any(), taskmanager.TaskFilter{OwnerID: "1234"}, m.Pagination{Page: 2, Limit: 10}).Return([]taskmanager.TaskDoc{}, &m.PageInfo{Total: &total, HasNext: true}, nil)
+		req := httptest.NewRequest("GET", "/tasks?owner_id=1234&page=2", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":[],"meta":{"page":2,"limit":10,"total":25,"has_next":true},"links":{"self":"/tasks?owner_id=1234\u0026page=2","next":"/tasks?owner_id=1234\u0026page=3","prev":"/tasks?owner_id=1234\u0026page=1"}}`, string(b))
+	})
+
+	t.Run("count=false should skip counting", func() {
+		t.taskService.EXPECT().GetAllTask(gomock.Any(), taskmanager.TaskFilter{}, m.Pagination{Page: 1, Limit: 10, SkipCount: true}).Return([]taskmanager.TaskDoc{}, &m.PageInfo{}, nil)
+		req := httptest.NewRequest("GET", "/tasks?count=false", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":[],"meta":{"page":1,"limit":10,"has_next":false},"links":{"self":"/tasks?count=false"}}`, string(b))
+	})
+}
+
+func (t *HandlerTestSuite) TestArchivedTasks() {
+	newApp := func() *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Get("/account/:ownerId/tasks/archived", func(c *fiber.Ctx) error {
+			return t.handler.GetArchivedTasks(c)
+		})
+		app.Patch("/account/:ownerId/tasks/:taskId/unarchive", func(c *fiber.Ctx) error {
+			return t.handler.UnarchiveTask(c)
+		})
+		app.Delete("/account/:ownerId/tasks/:taskId", func(c *fiber.Ctx) error {
+			return t.handler.DeleteTask(c)
+		})
+		return app
+	}
+
+	t.Run("get archived tasks lists the account's archived tasks, latest first", func() {
+		t.taskService.EXPECT().GetAllTask(gomock.Any(), taskmanager.TaskFilter{
+			OwnerID:  "1234",
+			Archived: true,
+			Sort:     []taskmanager.SortField{{Field: "archive_date", Desc: true}},
+		}, m.Pagination{Page: 1, Limit: 10}).Return([]taskmanager.TaskDoc{}, &m.PageInfo{}, nil)
+		req := httptest.NewRequest("GET", "/account/1234/tasks/archived?owner_id=5678", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+	})
+
+	t.Run("unarchive task not owned should return 400", func() {
+		t.taskService.EXPECT().UnarchiveTask(gomock.Any(), "1234", "134134134", nil).Return(0, nil)
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/134134134/unarchive", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("unarchive task success", func() {
+		t.taskService.EXPECT().UnarchiveTask(gomock.Any(), "1234", "134134134", nil).Return(1, nil)
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/134134134/unarchive", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":"Task unarchived successfully"}`, string(b))
+	})
+
+	t.Run("delete task not owned should return 400 and keep comments", func() {
+		t.taskService.EXPECT().DeleteTask(gomock.Any(), "1234", "134134134").Return(nil, nil)
+		req := httptest.NewRequest("DELETE", "/account/1234/tasks/134134134", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("delete task also deletes its comments, reactions and mentions", func() {
+		taskIds := []string{"6041c3a6cfcba2fb9c4a4fd2"}
+		t.taskService.EXPECT().DeleteTask(gomock.Any(), "1234", "6041c3a6cfcba2fb9c4a4fd2").Return(&taskmanager.TaskDoc{ID: "6041c3a6cfcba2fb9c4a4fd2"}, nil)
+		t.commentService.EXPECT().DeleteTaskComments(gomock.Any(), taskIds).Return(int64(3), nil)
+		t.reactions.EXPECT().DeleteTaskReactions(gomock.Any(), taskIds).Return(int64(1), nil)
+		t.mentions.EXPECT().DeleteTaskMentions(gomock.Any(), taskIds).Return(int64(1), nil)
+		req := httptest.NewRequest("DELETE", "/account/1234/tasks/6041c3a6cfcba2fb9c4a4fd2", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":"Task deleted successfully"}`, string(b))
+	})
+
+	t.Run("delete task but deleting its comments fails should still delete the rest", func() {
+		taskIds := []string{"6041c3a6cfcba2fb9c4a4fd2"}
+		t.taskService.EXPECT().DeleteTask(gomock.Any(), "1234", "6041c3a6cfcba2fb9c4a4fd2").Return(&taskmanager.TaskDoc{ID: "6041c3a6cfcba2fb9c4a4fd2"}, nil)
+		t.commentService.EXPECT().DeleteTaskComments(gomock.Any(), taskIds).Return(int64(0), errors.New("delete many error"))
+		t.reactions.EXPECT().DeleteTaskReactions(gomock.Any(), taskIds).Return(int64(1), nil)
+		t.mentions.EXPECT().DeleteTaskMentions(gomock.Any(), taskIds).Return(int64(1), nil)
+		req := httptest.NewRequest("DELETE", "/account/1234/tasks/6041c3a6cfcba2fb9c4a4fd2", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
 	})
 }
 
 func (t HandlerTestSuite) TestArchiveTask() {
 	t.Run("archive task but service has error should return error", func() {
-		t.taskService.EXPECT().ArchiveTask(gomock.Any(), "1234", "134134134").Return(0, errors.New("archive task error"))
+		t.taskService.EXPECT().ArchiveTask(gomock.Any(), "1234", "134134134", nil).Return(0, errors.New("archive task error"))
 		// Define Fiber app.
 		app := fiber.New()
 		// Create route with PATCH method for test
@@ -328,42 +993,352 @@ func (t HandlerTestSuite) TestArchiveTask() {
 		t.Equal(500, resp.StatusCode)
 	})
 
-	t.Run("archive task success but modification count is 0 return 400", func() {
-		t.taskService.EXPECT().ArchiveTask(gomock.Any(), "1234", "134134134").Return(0, nil)
-		// Define Fiber app.
-		app := fiber.New()
-		// Create route with PATCH method for test
-		app.Patch("/account/:ownerId/tasks/:taskId/archive", func(c *fiber.Ctx) error {
-			// Return simple string as response
-			return t.handler.ArchiveTask(c)
-		})
-		req := httptest.NewRequest("PATCH", "/account/1234/tasks/134134134/archive", nil)
-		req.Header.Set("Content-Type", "application/json")
-		resp, _ := app.Test(req, 20)
+	t.Run("archive task success but modification count is 0 return 400", func() {
+		t.taskService.EXPECT().ArchiveTask(gomock.Any(), "1234", "134134134", nil).Return(0, nil)
+		// Define Fiber app.
+		app := fiber.New()
+		// Create route with PATCH method for test
+		app.Patch("/account/:ownerId/tasks/:taskId/archive", func(c *fiber.Ctx) error {
+			// Return simple string as response
+			return t.handler.ArchiveTask(c)
+		})
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/134134134/archive", nil)
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := app.Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("archive task success return task", func() {
+		t.taskService.EXPECT().ArchiveTask(gomock.Any(), "1234", "134134134", nil).Return(1, nil)
+		// Define Fiber app.
+		app := fiber.New()
+		// Create route with PATCH method for test
+		app.Patch("/account/:ownerId/tasks/:taskId/archive", func(c *fiber.Ctx) error {
+			// Return simple string as response
+			return t.handler.ArchiveTask(c)
+		})
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/134134134/archive", nil)
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := app.Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":"Task archived successfully"}`, string(b))
+	})
+
+	t.Run("archive task with a stale version should return 412", func() {
+		version := int64(2)
+		t.taskService.EXPECT().ArchiveTask(gomock.Any(), "1234", "134134134", &version).Return(0, taskmanager.ErrVersionMismatch)
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Patch("/account/:ownerId/tasks/:taskId/archive", func(c *fiber.Ctx) error {
+			return t.handler.ArchiveTask(c)
+		})
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/134134134/archive", nil)
+		req.Header.Set("If-Match", `"2-e49ddb78b8e3ef26"`)
+		resp, _ := app.Test(req, 20)
+		t.Equal(412, resp.StatusCode)
+	})
+}
+
+func (t *HandlerTestSuite) TestAssignTask() {
+	newApp := func() *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Post("/account/:ownerId/tasks/:taskId/assignees", func(c *fiber.Ctx) error {
+			return t.handler.AssignTask(c)
+		})
+		app.Delete("/account/:ownerId/tasks/:taskId/assignees/:assigneeId", func(c *fiber.Ctx) error {
+			return t.handler.UnassignTask(c)
+		})
+		return app
+	}
+
+	t.Run("assign task without assignees should return 400", func() {
+		req := httptest.NewRequest("POST", "/account/1234/tasks/134134134/assignees", strings.NewReader(`{"assignees":[]}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("assign task with unknown profile should return 400", func() {
+		t.profileService.EXPECT().GetProfileList(gomock.Any(), []string{"5678", "9999"}).Return([]profile.ProfileDoc{
+			{OwnerId: "5678"},
+		}, nil)
+		req := httptest.NewRequest("POST", "/account/1234/tasks/134134134/assignees", strings.NewReader(`{"assignees":["5678"," 9999","5678"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"error_code":"bad_request","error_msg":"Invalid assignee","status":400}`, string(b))
+	})
+
+	t.Run("assign task with too many assignees should return 400", func() {
+		req := httptest.NewRequest("POST", "/account/1234/tasks/134134134/assignees", strings.NewReader(`{"assignees":["1","2","3","4"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("assign task not owned should return 400", func() {
+		t.profileService.EXPECT().GetProfileList(gomock.Any(), []string{"5678"}).Return([]profile.ProfileDoc{{OwnerId: "5678"}}, nil)
+		t.taskService.EXPECT().AssignTask(gomock.Any(), "1234", "134134134", []string{"5678"}).Return(0, nil)
+		req := httptest.NewRequest("POST", "/account/1234/tasks/134134134/assignees", strings.NewReader(`{"assignees":["5678"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("assign task success", func() {
+		t.profileService.EXPECT().GetProfileList(gomock.Any(), []string{"5678"}).Return([]profile.ProfileDoc{{OwnerId: "5678"}}, nil)
+		t.taskService.EXPECT().AssignTask(gomock.Any(), "1234", "134134134", []string{"5678"}).Return(1, nil)
+		req := httptest.NewRequest("POST", "/account/1234/tasks/134134134/assignees", strings.NewReader(`{"assignees":["5678"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":"Task assigned successfully"}`, string(b))
+	})
+
+	t.Run("unassign task success", func() {
+		t.taskService.EXPECT().UnassignTask(gomock.Any(), "1234", "134134134", []string{"5678"}).Return(1, nil)
+		req := httptest.NewRequest("DELETE", "/account/1234/tasks/134134134/assignees/5678", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":"Task unassigned successfully"}`, string(b))
+	})
+
+	t.Run("unassign task but service has error should return error", func() {
+		t.taskService.EXPECT().UnassignTask(gomock.Any(), "1234", "134134134", []string{"5678"}).Return(0, taskmanager.ErrInvalidTaskID)
+		req := httptest.NewRequest("DELETE", "/account/1234/tasks/134134134/assignees/5678", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+}
+
+func (t *HandlerTestSuite) TestLabels() {
+	newApp := func() *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Post("/account/:ownerId/tasks/:taskId/labels", func(c *fiber.Ctx) error {
+			return t.handler.AddLabels(c)
+		})
+		app.Delete("/account/:ownerId/tasks/:taskId/labels/:label", func(c *fiber.Ctx) error {
+			return t.handler.RemoveLabel(c)
+		})
+		app.Get("/labels", func(c *fiber.Ctx) error {
+			return t.handler.GetLabels(c)
+		})
+		return app
+	}
+
+	t.Run("add labels without labels should return 400", func() {
+		req := httptest.NewRequest("POST", "/account/1234/tasks/134134134/labels", strings.NewReader(`{"labels":[]}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("add labels with blank label should return 400", func() {
+		req := httptest.NewRequest("POST", "/account/1234/tasks/134134134/labels", strings.NewReader(`{"labels":["bug"," "]}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Contains(string(b), `"error_code":"invalid_label"`)
+	})
+
+	t.Run("add labels success should normalize labels", func() {
+		t.taskService.EXPECT().AddLabels(gomock.Any(), "1234", "134134134", []string{"bug", "ui"}).Return(1, nil)
+		req := httptest.NewRequest("POST", "/account/1234/tasks/134134134/labels", strings.NewReader(`{"labels":["Bug","ui","bug"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":"Labels added successfully"}`, string(b))
+	})
+
+	t.Run("remove label on task not owned should return 400", func() {
+		t.taskService.EXPECT().RemoveLabels(gomock.Any(), "1234", "134134134", []string{"needs review"}).Return(0, nil)
+		req := httptest.NewRequest("DELETE", "/account/1234/tasks/134134134/labels/needs%20review", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("remove label success", func() {
+		t.taskService.EXPECT().RemoveLabels(gomock.Any(), "1234", "134134134", []string{"bug"}).Return(1, nil)
+		req := httptest.NewRequest("DELETE", "/account/1234/tasks/134134134/labels/bug", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":"Label removed successfully"}`, string(b))
+	})
+
+	t.Run("get labels should return usage counts", func() {
+		t.taskService.EXPECT().GetLabels(gomock.Any(), "1234").Return([]taskmanager.LabelCount{{Label: "bug", Count: 2}}, nil)
+		req := httptest.NewRequest("GET", "/labels?owner_id=1234", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":[{"label":"bug","count":2}]}`, string(b))
+	})
+}
+
+func (t *HandlerTestSuite) TestDependencies() {
+	newApp := func() *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Post("/account/:ownerId/tasks/:taskId/dependencies", func(c *fiber.Ctx) error {
+			return t.handler.AddDependency(c)
+		})
+		app.Delete("/account/:ownerId/tasks/:taskId/dependencies/:blockerId", func(c *fiber.Ctx) error {
+			return t.handler.RemoveDependency(c)
+		})
+		app.Patch("/account/:ownerId/tasks/:taskId", func(c *fiber.Ctx) error {
+			return t.handler.UpdateTask(c)
+		})
+		return app
+	}
+
+	t.Run("add dependency without blocker should return 400", func() {
+		req := httptest.NewRequest("POST", "/account/1234/tasks/134134134/dependencies", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("add dependency creating a cycle should return 409", func() {
+		t.taskService.EXPECT().AddDependency(gomock.Any(), "1234", "134134134", "5678").Return(0, taskmanager.ErrDependencyCycle)
+		req := httptest.NewRequest("POST", "/account/1234/tasks/134134134/dependencies", strings.NewReader(`{"blocked_by":"5678"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(409, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"error_code":"dependency_cycle","error_msg":"Dependency would create a cycle","status":409}`, string(b))
+	})
+
+	t.Run("add dependency success", func() {
+		t.taskService.EXPECT().AddDependency(gomock.Any(), "1234", "134134134", "5678").Return(1, nil)
+		req := httptest.NewRequest("POST", "/account/1234/tasks/134134134/dependencies", strings.NewReader(`{"blocked_by":"5678"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":"Dependency added successfully"}`, string(b))
+	})
+
+	t.Run("remove dependency on task not owned should return 400", func() {
+		t.taskService.EXPECT().RemoveDependency(gomock.Any(), "1234", "134134134", "5678").Return(0, nil)
+		req := httptest.NewRequest("DELETE", "/account/1234/tasks/134134134/dependencies/5678", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("remove dependency success", func() {
+		t.taskService.EXPECT().RemoveDependency(gomock.Any(), "1234", "134134134", "5678").Return(1, nil)
+		req := httptest.NewRequest("DELETE", "/account/1234/tasks/134134134/dependencies/5678", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+	})
+
+	t.Run("start blocked task should return 409 unless forced", func() {
+		t.taskService.EXPECT().UpdateTaskStatus(gomock.Any(), "1234", "134134134", 2, false, nil).Return(taskmanager.ErrTaskBlocked)
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/134134134", strings.NewReader(`{"status":2}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(409, resp.StatusCode)
+
+		t.taskService.EXPECT().UpdateTaskStatus(gomock.Any(), "1234", "134134134", 2, true, nil).Return(nil)
+		req = httptest.NewRequest("PATCH", "/account/1234/tasks/134134134", strings.NewReader(`{"status":2,"force":true}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ = newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+	})
+}
+
+func (t *HandlerTestSuite) TestRecurrences() {
+	newApp := func() *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Post("/account/:ownerId/recurrences", func(c *fiber.Ctx) error {
+			return t.handler.CreateRecurrence(c)
+		})
+		app.Get("/account/:ownerId/recurrences", func(c *fiber.Ctx) error {
+			return t.handler.GetRecurrences(c)
+		})
+		app.Delete("/account/:ownerId/recurrences/:recurrenceId", func(c *fiber.Ctx) error {
+			return t.handler.EndRecurrence(c)
+		})
+		return app
+	}
+
+	t.Run("create recurrence without rule should return 400", func() {
+		req := httptest.NewRequest("POST", "/account/1234/recurrences", strings.NewReader(`{"topic":"t","description":"d","start_date":"2023-05-10"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"error_code":"bad_request","error_msg":"Rule is required","status":400}`, string(b))
+	})
+
+	t.Run("create recurrence without start date should return 400", func() {
+		req := httptest.NewRequest("POST", "/account/1234/recurrences", strings.NewReader(`{"topic":"t","description":"d","rrule":"FREQ=DAILY"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("create recurrence with invalid rule should return 400", func() {
+		t.profileService.EXPECT().GetProfile(gomock.Any(), "1234").Return(&profile.ProfileDoc{}, nil)
+		t.recurrences.EXPECT().CreateRecurrence(gomock.Any(), "1234", gomock.Any()).Return(nil, recurrence.ErrInvalidRule)
+		req := httptest.NewRequest("POST", "/account/1234/recurrences", strings.NewReader(`{"topic":"t","description":"d","rrule":"FREQ=YEARLY","start_date":"2023-05-10"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"error_code":"invalid_rule","error_msg":"Invalid recurrence rule","status":400}`, string(b))
+	})
+
+	t.Run("create recurrence reads start date in tz", func() {
+		t.profileService.EXPECT().GetProfile(gomock.Any(), "1234").Return(&profile.ProfileDoc{}, nil)
+		t.recurrences.EXPECT().CreateRecurrence(gomock.Any(), "1234", recurrence.NewRecurrence{
+			Template:  recurrence.Template{Topic: "t", Description: "d", Priority: 2},
+			Rule:      "FREQ=WEEKLY;BYDAY=MO",
+			TZ:        "Asia/Bangkok",
+			StartDate: 1683680400,
+		}).Return(&recurrence.RecurrenceDoc{ID: "645b9183fcfbc11433e23ab3", OwnerID: "1234", Rule: "FREQ=WEEKLY;BYDAY=MO", TZ: "Asia/Bangkok", StartDate: 1683680400}, nil)
+		req := httptest.NewRequest("POST", "/account/1234/recurrences", strings.NewReader(`{"topic":"t","description":"d","priority":2,"rrule":"FREQ=WEEKLY;BYDAY=MO","start_date":"2023-05-10T08:00","tz":"Asia/Bangkok"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(201, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":{"id":"645b9183fcfbc11433e23ab3","owner_id":"1234","template":{"topic":"","description":"","priority":0},"rule":"FREQ=WEEKLY;BYDAY=MO","tz":"Asia/Bangkok","start_date":1683680400,"generated":0,"last_occurrence":null,"ended":false,"create_date":0}}`, string(b))
+	})
+
+	t.Run("get recurrences", func() {
+		t.recurrences.EXPECT().GetRecurrences(gomock.Any(), "1234").Return([]recurrence.RecurrenceDoc{}, nil)
+		req := httptest.NewRequest("GET", "/account/1234/recurrences", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":[]}`, string(b))
+	})
+
+	t.Run("end recurrence not owned should return 400", func() {
+		t.recurrences.EXPECT().EndRecurrence(gomock.Any(), "1234", "645b9183fcfbc11433e23ab3").Return(0, nil)
+		req := httptest.NewRequest("DELETE", "/account/1234/recurrences/645b9183fcfbc11433e23ab3", nil)
+		resp, _ := newApp().Test(req, 20)
 		t.Equal(400, resp.StatusCode)
 	})
 
-	t.Run("archive task success return task", func() {
-		t.taskService.EXPECT().ArchiveTask(gomock.Any(), "1234", "134134134").Return(1, nil)
-		// Define Fiber app.
-		app := fiber.New()
-		// Create route with PATCH method for test
-		app.Patch("/account/:ownerId/tasks/:taskId/archive", func(c *fiber.Ctx) error {
-			// Return simple string as response
-			return t.handler.ArchiveTask(c)
-		})
-		req := httptest.NewRequest("PATCH", "/account/1234/tasks/134134134/archive", nil)
-		req.Header.Set("Content-Type", "application/json")
-		resp, _ := app.Test(req, 20)
+	t.Run("end recurrence success", func() {
+		t.recurrences.EXPECT().EndRecurrence(gomock.Any(), "1234", "645b9183fcfbc11433e23ab3").Return(1, nil)
+		req := httptest.NewRequest("DELETE", "/account/1234/recurrences/645b9183fcfbc11433e23ab3", nil)
+		resp, _ := newApp().Test(req, 20)
 		t.Equal(200, resp.StatusCode)
 		b, _ := io.ReadAll(resp.Body)
-		t.Equal(`{"data":"Task archived successfully"}`, string(b))
+		t.Equal(`{"data":"Recurrence ended successfully"}`, string(b))
 	})
 }
 
 func (t *HandlerTestSuite) TestGetTopicComments() {
 	t.Run("get topic comments but service has error should return error", func() {
-		t.commentService.EXPECT().GetTopicComments(gomock.Any(), "134134134", 1, 10).Return(nil, errors.New("get topic comments error"))
+		t.commentService.EXPECT().GetTopicComments(gomock.Any(), "134134134", false, m.Pagination{Page: 1, Limit: 10}).Return(nil, nil, errors.New("get topic comments error"))
 		// Define Fiber app.
 		app := fiber.New()
 		// Create route with GET method for test
@@ -420,14 +1395,15 @@ func (t *HandlerTestSuite) TestGetTopicComments() {
 	})
 
 	t.Run("get topic comments success return task", func() {
-		t.commentService.EXPECT().GetTopicComments(gomock.Any(), "134134134", 1, 10).Return([]comment.CommentDoc{
+		t.commentService.EXPECT().GetTopicComments(gomock.Any(), "134134134", false, m.Pagination{Page: 1, Limit: 10}).Return([]comment.CommentDoc{
 			{
 				ID:      "1234",
 				TaskId:  "134134134",
 				Content: "test_comment",
 				OwnerId: "12345",
 			},
-		}, nil)
+		}, &m.PageInfo{}, nil)
+		t.reactions.EXPECT().CommentSummaries(gomock.Any(), "134134134", []string{"1234"}, "").Return(map[string][]reaction.Summary{}, nil)
 		// Define Fiber app.
 		app := fiber.New()
 		// Create route with GET method for test
@@ -441,13 +1417,106 @@ func (t *HandlerTestSuite) TestGetTopicComments() {
 		resp, _ := app.Test(req, 20)
 		t.Equal(200, resp.StatusCode)
 		b, _ := io.ReadAll(resp.Body)
-		t.Equal(`{"data":[{"id":"1234","owner_id":"12345","task_id":"134134134","content":"test_comment","create_date":0,"update_date":null}]}`, string(b))
+		t.Equal(`{"data":[{"id":"1234","owner_id":"12345","task_id":"134134134","content":"test_comment","create_date":0,"update_date":null}],"meta":{"page":1,"limit":10,"has_next":false},"links":{"self":"/account/1234/tasks/134134134/comments?page=1\u0026limit=10"}}`, string(b))
+		t.NotEmpty(resp.Header.Get("ETag"))
+		t.Empty(resp.Header.Get("Last-Modified"))
+	})
+
+	t.Run("get topic comments with matching if-none-match should return 304", func() {
+		comments := []comment.CommentDoc{{ID: "1234", TaskId: "134134134", Content: "test_comment"}}
+		t.commentService.EXPECT().GetTopicComments(gomock.Any(), "134134134", false, gomock.Any()).Return(comments, &m.PageInfo{}, nil).Times(2)
+		t.reactions.EXPECT().CommentSummaries(gomock.Any(), "134134134", []string{"1234"}, "").Return(map[string][]reaction.Summary{}, nil).Times(2)
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Get("/tasks/:taskId/comments", func(c *fiber.Ctx) error {
+			return t.handler.GetTopicComments(c)
+		})
+		resp, _ := app.Test(httptest.NewRequest("GET", "/tasks/134134134/comments", nil), 20)
+		t.Equal(200, resp.StatusCode)
+
+		req := httptest.NewRequest("GET", "/tasks/134134134/comments", nil)
+		req.Header.Set("If-None-Match", resp.Header.Get("ETag"))
+		resp, _ = app.Test(req, 20)
+		t.Equal(304, resp.StatusCode)
+	})
+}
+
+func (t *HandlerTestSuite) TestCacheControl() {
+	newApp := func() *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Use(CacheControl(config.Cache{Routes: []config.CacheRoute{
+			{Path: "/tasks/:taskId", Control: "public, no-cache"},
+			{Path: "/account/:ownerId/tasks/archived", Control: "private, max-age=30"},
+		}}))
+		app.Get("/tasks/:taskId", func(c *fiber.Ctx) error {
+			if c.Params("taskId") == "missing" {
+				return fiber.NewError(fiber.StatusNotFound, "Task not found")
+			}
+			return c.SendString("task")
+		})
+		app.Get("/tasks", func(c *fiber.Ctx) error {
+			return c.SendString("tasks")
+		})
+		group := app.Group("/account/:ownerId")
+		group.Get("/tasks/archived", func(c *fiber.Ctx) error {
+			return c.SendString("archived")
+		})
+		return app
+	}
+
+	t.Run("configured route should send its policy", func() {
+		resp, _ := newApp().Test(httptest.NewRequest("GET", "/tasks/1234", nil), 20)
+		t.Equal("public, no-cache", resp.Header.Get("Cache-Control"))
+	})
+
+	t.Run("route of a group should be matched on its full path", func() {
+		resp, _ := newApp().Test(httptest.NewRequest("GET", "/account/1234/tasks/archived", nil), 20)
+		t.Equal("private, max-age=30", resp.Header.Get("Cache-Control"))
+	})
+
+	t.Run("route without policy should send no cache control", func() {
+		resp, _ := newApp().Test(httptest.NewRequest("GET", "/tasks", nil), 20)
+		t.Empty(resp.Header.Get("Cache-Control"))
+	})
+
+	t.Run("error response should send no cache control", func() {
+		resp, _ := newApp().Test(httptest.NewRequest("GET", "/tasks/missing", nil), 20)
+		t.Equal(404, resp.StatusCode)
+		t.Empty(resp.Header.Get("Cache-Control"))
+	})
+}
+
+func (t *HandlerTestSuite) TestGetTaskHistory() {
+	newApp := func() *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Get("/tasks/:taskId/history", func(c *fiber.Ctx) error {
+			return t.handler.GetTaskHistory(c)
+		})
+		return app
+	}
+
+	t.Run("get task history with malformed id should return 400", func() {
+		t.history.EXPECT().GetTaskHistory(gomock.Any(), "1234", m.Pagination{Page: 1, Limit: 10}).Return(nil, nil, history.ErrInvalidTaskID)
+		req := httptest.NewRequest("GET", "/tasks/1234/history", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("get task history should return a page of records", func() {
+		total := int64(1)
+		t.history.EXPECT().GetTaskHistory(gomock.Any(), "645b9183fcfbc11433e23ab3", m.Pagination{Page: 1, Limit: 10}).Return([]history.HistoryDoc{
+			{ID: "645b9183fcfbc11433e23ab4", TaskID: "645b9183fcfbc11433e23ab3", Actor: "1234", Date: 1569130951, Field: "status", Old: 1, New: 2},
+		}, &m.PageInfo{Total: &total}, nil)
+		req := httptest.NewRequest("GET", "/tasks/645b9183fcfbc11433e23ab3/history", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":[{"id":"645b9183fcfbc11433e23ab4","task_id":"645b9183fcfbc11433e23ab3","actor":"1234","date":1569130951,"field":"status","old":1,"new":2}],"meta":{"page":1,"limit":10,"total":1,"has_next":false},"links":{"self":"/tasks/645b9183fcfbc11433e23ab3/history"}}`, string(b))
 	})
 }
 
 func (t *HandlerTestSuite) TestCreateComment() {
 	t.Run("create comment but service has error should return error", func() {
-		t.commentService.EXPECT().CreateComment(gomock.Any(), "1234", "134134134", "test_comment").Return(nil, errors.New("create comment error"))
+		t.commentService.EXPECT().CreateComment(gomock.Any(), "1234", "134134134", "test_comment", "").Return(nil, errors.New("create comment error"))
 		t.profileService.EXPECT().GetProfile(gomock.Any(), "1234").Return(&profile.ProfileDoc{}, nil)
 
 		// Define Fiber app.
@@ -477,8 +1546,21 @@ func (t *HandlerTestSuite) TestCreateComment() {
 		t.Equal(400, resp.StatusCode)
 	})
 
+	t.Run("create comment but task is archived should return 409", func() {
+		t.commentService.EXPECT().CreateComment(gomock.Any(), "1234", "134134134", "test_comment", "").Return(nil, comment.ErrTaskArchived)
+		t.profileService.EXPECT().GetProfile(gomock.Any(), "1234").Return(&profile.ProfileDoc{}, nil)
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Post("/account/:ownerId/tasks/:taskId/comments", func(c *fiber.Ctx) error {
+			return t.handler.CreateComment(c)
+		})
+		req := httptest.NewRequest("POST", "/account/1234/tasks/134134134/comments", strings.NewReader(`{"content":"test_comment"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := app.Test(req, 20)
+		t.Equal(409, resp.StatusCode)
+	})
+
 	t.Run("create comment success should return comment", func() {
-		t.commentService.EXPECT().CreateComment(gomock.Any(), "1234", "134134134", "test_comment").Return(&comment.CommentDoc{
+		t.commentService.EXPECT().CreateComment(gomock.Any(), "1234", "134134134", "test_comment", "").Return(&comment.CommentDoc{
 			ID:      "1234",
 			TaskId:  "134134134",
 			Content: "test_comment",
@@ -502,6 +1584,364 @@ func (t *HandlerTestSuite) TestCreateComment() {
 	})
 }
 
+func (t *HandlerTestSuite) TestCommentReplies() {
+	newApp := func() *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Get("/tasks/:taskId/comments", func(c *fiber.Ctx) error {
+			return t.handler.GetTopicComments(c)
+		})
+		app.Get("/tasks/:taskId/comments/:commentId/replies", func(c *fiber.Ctx) error {
+			return t.handler.GetCommentReplies(c)
+		})
+		app.Post("/account/:ownerId/tasks/:taskId/comments", func(c *fiber.Ctx) error {
+			return t.handler.CreateComment(c)
+		})
+		return app
+	}
+
+	t.Run("get top-level comments should pass the mode to service", func() {
+		replies := int64(2)
+		t.commentService.EXPECT().GetTopicComments(gomock.Any(), "134134134", true, m.Pagination{Page: 1, Limit: 10}).Return([]comment.CommentDoc{
+			{ID: "5678", OwnerId: "1234", TaskId: "134134134", Content: "question", ReplyCount: &replies},
+		}, &m.PageInfo{}, nil)
+		t.reactions.EXPECT().CommentSummaries(gomock.Any(), "134134134", []string{"5678"}, "").Return(map[string][]reaction.Summary{}, nil)
+		resp, _ := newApp().Test(httptest.NewRequest("GET", "/tasks/134134134/comments?top_level=true", nil), 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":[{"id":"5678","owner_id":"1234","task_id":"134134134","content":"question","create_date":0,"update_date":null,"reply_count":2}],"meta":{"page":1,"limit":10,"has_next":false},"links":{"self":"/tasks/134134134/comments?top_level=true"}}`, string(b))
+	})
+
+	t.Run("get comments with invalid top_level should return 400", func() {
+		resp, _ := newApp().Test(httptest.NewRequest("GET", "/tasks/134134134/comments?top_level=maybe", nil), 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("get replies of a missing comment should return 404", func() {
+		t.commentService.EXPECT().GetReplies(gomock.Any(), "134134134", "5678", m.Pagination{Page: 1, Limit: 10}).Return(nil, nil, comment.ErrCommentNotFound)
+		resp, _ := newApp().Test(httptest.NewRequest("GET", "/tasks/134134134/comments/5678/replies", nil), 20)
+		t.Equal(404, resp.StatusCode)
+	})
+
+	t.Run("get replies should return a page of replies", func() {
+		t.commentService.EXPECT().GetReplies(gomock.Any(), "134134134", "5678", m.Pagination{Page: 2, Limit: 5}).Return([]comment.CommentDoc{
+			{ID: "9012", OwnerId: "1234", TaskId: "134134134", Content: "answer", ParentID: "5678", Depth: 1},
+		}, &m.PageInfo{}, nil)
+		t.reactions.EXPECT().CommentSummaries(gomock.Any(), "134134134", []string{"9012"}, "").Return(map[string][]reaction.Summary{}, nil)
+		resp, _ := newApp().Test(httptest.NewRequest("GET", "/tasks/134134134/comments/5678/replies?page=2&limit=5", nil), 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Contains(string(b), `"parent_comment_id":"5678","depth":1`)
+	})
+
+	t.Run("create reply too deep should return 400", func() {
+		t.profileService.EXPECT().GetProfile(gomock.Any(), "1234").Return(&profile.ProfileDoc{}, nil)
+		t.commentService.EXPECT().CreateComment(gomock.Any(), "1234", "134134134", "answer", "5678").Return(nil, comment.ErrReplyTooDeep)
+		req := httptest.NewRequest("POST", "/account/1234/tasks/134134134/comments", strings.NewReader(`{"content":"answer","parent_comment_id":"5678"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"error_code":"reply_too_deep","error_msg":"Replies cannot be nested deeper","status":400}`, string(b))
+	})
+}
+
+func (t *HandlerTestSuite) TestEditComments() {
+	newApp := func() *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Patch("/account/:ownerId/tasks/:taskId/comments/:commentId", func(c *fiber.Ctx) error {
+			return t.handler.UpdateComment(c)
+		})
+		app.Delete("/account/:ownerId/tasks/:taskId/comments/:commentId", func(c *fiber.Ctx) error {
+			return t.handler.DeleteComment(c)
+		})
+		app.Get("/account/:ownerId/tasks/:taskId/comments/:commentId/revisions", func(c *fiber.Ctx) error {
+			return t.handler.GetCommentRevisions(c)
+		})
+		return app
+	}
+
+	t.Run("update comment without content should return 400", func() {
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/134134134/comments/5678", strings.NewReader(`{"content":"  "}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("update comment of someone else should return 403", func() {
+		t.commentService.EXPECT().UpdateComment(gomock.Any(), "1234", "134134134", "5678", "fixed").Return(nil, comment.ErrNotAuthor)
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/134134134/comments/5678", strings.NewReader(`{"content":" fixed "}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(403, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"error_code":"not_comment_author","error_msg":"Only the author can change this comment","status":403}`, string(b))
+	})
+
+	t.Run("update comment success should return comment", func() {
+		updated := int64(1569152551)
+		t.commentService.EXPECT().UpdateComment(gomock.Any(), "1234", "134134134", "5678", "fixed").Return(&comment.CommentDoc{
+			ID:         "5678",
+			OwnerId:    "1234",
+			TaskId:     "134134134",
+			Content:    "fixed",
+			CreateDate: 1569152000,
+			UpdateDate: &updated,
+		}, nil)
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/134134134/comments/5678", strings.NewReader(`{"content":"fixed"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":{"id":"5678","owner_id":"1234","task_id":"134134134","content":"fixed","create_date":1569152000,"update_date":1569152551}}`, string(b))
+	})
+
+	t.Run("delete comment not found should return 404", func() {
+		t.commentService.EXPECT().DeleteComment(gomock.Any(), "1234", "134134134", "5678").Return(comment.ErrCommentNotFound)
+		resp, _ := newApp().Test(httptest.NewRequest("DELETE", "/account/1234/tasks/134134134/comments/5678", nil), 20)
+		t.Equal(404, resp.StatusCode)
+	})
+
+	t.Run("delete comment success", func() {
+		t.commentService.EXPECT().DeleteComment(gomock.Any(), "1234", "134134134", "5678").Return(nil)
+		resp, _ := newApp().Test(httptest.NewRequest("DELETE", "/account/1234/tasks/134134134/comments/5678", nil), 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":"Comment deleted successfully"}`, string(b))
+	})
+
+	t.Run("get comment revisions success", func() {
+		t.commentService.EXPECT().GetCommentRevisions(gomock.Any(), "1234", "134134134", "5678").Return([]comment.Revision{{Content: "fxied", Date: 1569152000}}, nil)
+		resp, _ := newApp().Test(httptest.NewRequest("GET", "/account/1234/tasks/134134134/comments/5678/revisions", nil), 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":[{"content":"fxied","date":1569152000}]}`, string(b))
+	})
+}
+
+func (t *HandlerTestSuite) TestMentions() {
+	newApp := func() *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Get("/account/:ownerId/mentions", func(c *fiber.Ctx) error {
+			return t.handler.GetMentions(c)
+		})
+		app.Patch("/account/:ownerId/mentions/:mentionId/read", func(c *fiber.Ctx) error {
+			return t.handler.MarkMentionRead(c)
+		})
+		return app
+	}
+
+	t.Run("get mentions should list unread mentions of the owner", func() {
+		t.mentions.EXPECT().GetUnread(gomock.Any(), "5678", m.Pagination{Page: 1, Limit: 10}).Return([]mention.MentionDoc{
+			{ID: "1111", OwnerID: "5678", AuthorID: "1234", TaskID: "134134134", CommentID: "9012", CreateDate: 1569152551},
+		}, &m.PageInfo{}, nil)
+		resp, _ := newApp().Test(httptest.NewRequest("GET", "/account/5678/mentions", nil), 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":[{"id":"1111","owner_id":"5678","author_id":"1234","task_id":"134134134","comment_id":"9012","create_date":1569152551,"read_date":null}],"meta":{"page":1,"limit":10,"has_next":false},"links":{"self":"/account/5678/mentions"}}`, string(b))
+	})
+
+	t.Run("mark mention read but nothing matched should return 400", func() {
+		t.mentions.EXPECT().MarkRead(gomock.Any(), "5678", "1111").Return(0, nil)
+		resp, _ := newApp().Test(httptest.NewRequest("PATCH", "/account/5678/mentions/1111/read", nil), 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("mark mention read with malformed id should return 400", func() {
+		t.mentions.EXPECT().MarkRead(gomock.Any(), "5678", "xyz").Return(0, mention.ErrInvalidMentionID)
+		resp, _ := newApp().Test(httptest.NewRequest("PATCH", "/account/5678/mentions/xyz/read", nil), 20)
+		t.Equal(400, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"error_code":"invalid_mention_id","error_msg":"Invalid mention id","status":400}`, string(b))
+	})
+
+	t.Run("mark mention read success", func() {
+		t.mentions.EXPECT().MarkRead(gomock.Any(), "5678", "1111").Return(1, nil)
+		resp, _ := newApp().Test(httptest.NewRequest("PATCH", "/account/5678/mentions/1111/read", nil), 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":"Mention marked as read"}`, string(b))
+	})
+}
+
+func (t *HandlerTestSuite) TestReactions() {
+	newApp := func() *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Get("/tasks/:taskId", func(c *fiber.Ctx) error {
+			return t.handler.GetTask(c)
+		})
+		app.Get("/tasks/:taskId/comments", func(c *fiber.Ctx) error {
+			return t.handler.GetTopicComments(c)
+		})
+		app.Post("/account/:ownerId/tasks/:taskId/reactions", func(c *fiber.Ctx) error {
+			return t.handler.AddReaction(c)
+		})
+		app.Delete("/account/:ownerId/tasks/:taskId/reactions/:emoji", func(c *fiber.Ctx) error {
+			return t.handler.RemoveReaction(c)
+		})
+		app.Post("/account/:ownerId/tasks/:taskId/comments/:commentId/reactions", func(c *fiber.Ctx) error {
+			return t.handler.AddReaction(c)
+		})
+		app.Delete("/account/:ownerId/tasks/:taskId/comments/:commentId/reactions/:emoji", func(c *fiber.Ctx) error {
+			return t.handler.RemoveReaction(c)
+		})
+		return app
+	}
+
+	t.Run("get task with bearer token should show reactions of the viewer", func() {
+		t.authService.EXPECT().Verify("token").Return("5678", nil)
+		t.taskService.EXPECT().GetTask(gomock.Any(), "1234").Return(&taskmanager.TaskDoc{ID: "1234", OwnerID: "12345"}, nil)
+		t.reactions.EXPECT().TaskSummary(gomock.Any(), "1234", "5678").Return([]reaction.Summary{
+			{Emoji: "heart", Count: 2, ReactedByMe: true},
+			{Emoji: "eyes", Count: 1},
+		}, nil)
+		req := httptest.NewRequest("GET", "/tasks/1234", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+		t.Equal("Authorization", resp.Header.Get("Vary"))
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":{"id":"1234","topic":"","description":"","status":0,"create_date":0,"owner_id":"12345","archive_date":null,"update_date":null,"priority":0,"version":0,"reactions":[{"emoji":"heart","count":2,"reacted_by_me":true},{"emoji":"eyes","count":1,"reacted_by_me":false}]}}`, string(b))
+	})
+
+	t.Run("get task with invalid bearer token should return 401", func() {
+		t.authService.EXPECT().Verify("token").Return("", errors.New("token is expired"))
+		req := httptest.NewRequest("GET", "/tasks/1234", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(401, resp.StatusCode)
+	})
+
+	t.Run("get topic comments should show reactions of each comment", func() {
+		t.commentService.EXPECT().GetTopicComments(gomock.Any(), "134134134", false, m.Pagination{Page: 1, Limit: 10}).Return([]comment.CommentDoc{
+			{ID: "5678", OwnerId: "1234", TaskId: "134134134", Content: "first"},
+			{ID: "9012", OwnerId: "1234", TaskId: "134134134", Content: "second"},
+		}, &m.PageInfo{}, nil)
+		t.reactions.EXPECT().CommentSummaries(gomock.Any(), "134134134", []string{"5678", "9012"}, "").Return(map[string][]reaction.Summary{
+			"9012": {{Emoji: "tada", Count: 3}},
+		}, nil)
+		resp, _ := newApp().Test(httptest.NewRequest("GET", "/tasks/134134134/comments", nil), 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":[{"id":"5678","owner_id":"1234","task_id":"134134134","content":"first","create_date":0,"update_date":null},{"id":"9012","owner_id":"1234","task_id":"134134134","content":"second","create_date":0,"update_date":null,"reactions":[{"emoji":"tada","count":3,"reacted_by_me":false}]}],"meta":{"page":1,"limit":10,"has_next":false},"links":{"self":"/tasks/134134134/comments"}}`, string(b))
+	})
+
+	t.Run("add reaction to task should return the reaction", func() {
+		t.profileService.EXPECT().GetProfile(gomock.Any(), "5678").Return(&profile.ProfileDoc{}, nil)
+		t.reactions.EXPECT().AddReaction(gomock.Any(), "5678", "1234", "", "heart").Return(&reaction.ReactionDoc{
+			ID: "1111", TaskID: "1234", OwnerID: "5678", Emoji: "heart", CreateDate: 1569152551,
+		}, nil)
+		req := httptest.NewRequest("POST", "/account/5678/tasks/1234/reactions", strings.NewReader(`{"emoji":" heart "}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(201, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":{"id":"1111","task_id":"1234","comment_id":"","owner_id":"5678","emoji":"heart","create_date":1569152551}}`, string(b))
+	})
+
+	t.Run("add reaction to comment should pass the comment id", func() {
+		t.profileService.EXPECT().GetProfile(gomock.Any(), "5678").Return(&profile.ProfileDoc{}, nil)
+		t.reactions.EXPECT().AddReaction(gomock.Any(), "5678", "1234", "9012", "tada").Return(&reaction.ReactionDoc{}, nil)
+		req := httptest.NewRequest("POST", "/account/5678/tasks/1234/comments/9012/reactions", strings.NewReader(`{"emoji":"tada"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(201, resp.StatusCode)
+	})
+
+	t.Run("add reaction with unknown emoji should return 400", func() {
+		t.profileService.EXPECT().GetProfile(gomock.Any(), "5678").Return(&profile.ProfileDoc{}, nil)
+		t.reactions.EXPECT().AddReaction(gomock.Any(), "5678", "1234", "", "rocket").Return(nil, reaction.ErrInvalidEmoji)
+		req := httptest.NewRequest("POST", "/account/5678/tasks/1234/reactions", strings.NewReader(`{"emoji":"rocket"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(400, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"error_code":"invalid_emoji","error_msg":"Invalid emoji","status":400}`, string(b))
+	})
+
+	t.Run("add reaction twice should return 409", func() {
+		t.profileService.EXPECT().GetProfile(gomock.Any(), "5678").Return(&profile.ProfileDoc{}, nil)
+		t.reactions.EXPECT().AddReaction(gomock.Any(), "5678", "1234", "", "heart").Return(nil, reaction.ErrAlreadyReacted)
+		req := httptest.NewRequest("POST", "/account/5678/tasks/1234/reactions", strings.NewReader(`{"emoji":"heart"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(409, resp.StatusCode)
+	})
+
+	t.Run("remove reaction but nothing deleted should return 400", func() {
+		t.reactions.EXPECT().RemoveReaction(gomock.Any(), "5678", "1234", "9012", "heart").Return(0, nil)
+		resp, _ := newApp().Test(httptest.NewRequest("DELETE", "/account/5678/tasks/1234/comments/9012/reactions/heart", nil), 20)
+		t.Equal(400, resp.StatusCode)
+	})
+
+	t.Run("remove reaction success", func() {
+		t.reactions.EXPECT().RemoveReaction(gomock.Any(), "5678", "1234", "", "thumbs_up").Return(1, nil)
+		resp, _ := newApp().Test(httptest.NewRequest("DELETE", "/account/5678/tasks/1234/reactions/thumbs_up", nil), 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":"Reaction removed successfully"}`, string(b))
+	})
+}
+
+func (t *HandlerTestSuite) TestRenderMarkdown() {
+	newApp := func() *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Get("/tasks", func(c *fiber.Ctx) error {
+			return t.handler.GetAllTask(c)
+		})
+		app.Get("/tasks/:taskId", func(c *fiber.Ctx) error {
+			return t.handler.GetTask(c)
+		})
+		app.Get("/tasks/:taskId/comments", func(c *fiber.Ctx) error {
+			return t.handler.GetTopicComments(c)
+		})
+		return app
+	}
+
+	t.Run("get task with render html should return source and sanitized html", func() {
+		t.taskService.EXPECT().GetTask(gomock.Any(), "1234").Return(&taskmanager.TaskDoc{
+			ID:          "1234",
+			OwnerID:     "12345",
+			Topic:       "test_topic",
+			Description: "**done** <script>x</script> [go](javascript:alert(1))",
+		}, nil)
+		t.reactions.EXPECT().TaskSummary(gomock.Any(), "1234", "").Return(nil, nil)
+		resp, _ := newApp().Test(httptest.NewRequest("GET", "/tasks/1234?render=html", nil), 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"data":{"id":"1234","topic":"test_topic","description":"**done** \u003cscript\u003ex\u003c/script\u003e [go](javascript:alert(1))","status":0,"create_date":0,"owner_id":"12345","archive_date":null,"update_date":null,"priority":0,"version":0,"description_html":"\u003cp\u003e\u003cstrong\u003edone\u003c/strong\u003e \u0026lt;script\u0026gt;x\u0026lt;/script\u0026gt; go\u003c/p\u003e\n"}}`, string(b))
+	})
+
+	t.Run("get all task with render html should render each description", func() {
+		t.taskService.EXPECT().GetAllTask(gomock.Any(), taskmanager.TaskFilter{}, m.Pagination{Page: 1, Limit: 10}).Return([]taskmanager.TaskDoc{
+			{ID: "1234", Description: "_a_"},
+			{ID: "5678"},
+		}, &m.PageInfo{}, nil)
+		resp, _ := newApp().Test(httptest.NewRequest("GET", "/tasks?render=html", nil), 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Contains(string(b), `"description":"_a_","status":0,"create_date":0,"owner_id":"","archive_date":null,"update_date":null,"priority":0,"version":0,"description_html":"\u003cp\u003e\u003cem\u003ea\u003c/em\u003e\u003c/p\u003e\n"}`)
+		t.Contains(string(b), `"id":"5678","topic":"","description":"","status":0,"create_date":0,"owner_id":"","archive_date":null,"update_date":null,"priority":0,"version":0}`)
+	})
+
+	t.Run("get topic comments with render html should render each content", func() {
+		t.commentService.EXPECT().GetTopicComments(gomock.Any(), "134134134", false, m.Pagination{Page: 1, Limit: 10}).Return([]comment.CommentDoc{
+			{ID: "5678", OwnerId: "1234", TaskId: "134134134", Content: "see `x`"},
+		}, &m.PageInfo{}, nil)
+		t.reactions.EXPECT().CommentSummaries(gomock.Any(), "134134134", []string{"5678"}, "").Return(map[string][]reaction.Summary{}, nil)
+		resp, _ := newApp().Test(httptest.NewRequest("GET", "/tasks/134134134/comments?render=html", nil), 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Contains(string(b), `"content":"see `+"`x`"+`","create_date":0,"update_date":null,"content_html":"\u003cp\u003esee \u003ccode\u003ex\u003c/code\u003e\u003c/p\u003e\n"}`)
+	})
+
+	t.Run("get task with unknown render should return 400", func() {
+		resp, _ := newApp().Test(httptest.NewRequest("GET", "/tasks/1234?render=pdf", nil), 20)
+		t.Equal(400, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal(`{"error_code":"bad_request","error_msg":"Invalid render","status":400}`, string(b))
+	})
+}
+
 func (t *HandlerTestSuite) TestGetProfile() {
 	t.Run("get profile but service has error should return error", func() {
 		t.profileService.EXPECT().GetProfile(gomock.Any(), "1234").Return(nil, errors.New("get profile error"))
@@ -519,11 +1959,11 @@ func (t *HandlerTestSuite) TestGetProfile() {
 		t.Equal(500, resp.StatusCode)
 	})
 
-	t.Run("get profile but got nil", func() {
-		t.profileService.EXPECT().GetProfile(gomock.Any(), "1234").Return(nil, nil)
+	t.Run("get profile not found should return not found", func() {
+		t.profileService.EXPECT().GetProfile(gomock.Any(), "1234").Return(nil, profile.ErrProfileNotFound)
 
 		// Define Fiber app.
-		app := fiber.New()
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
 		// Create route with GET method for test
 		app.Get("/account/:ownerId/profile", func(c *fiber.Ctx) error {
 			// Return simple string as response
@@ -532,7 +1972,7 @@ func (t *HandlerTestSuite) TestGetProfile() {
 		req := httptest.NewRequest("GET", "/account/1234/profile", nil)
 		req.Header.Set("Content-Type", "application/json")
 		resp, _ := app.Test(req, 20)
-		t.Equal(400, resp.StatusCode)
+		t.Equal(404, resp.StatusCode)
 	})
 
 	t.Run("get profile success should return profile", func() {
@@ -558,6 +1998,23 @@ func (t *HandlerTestSuite) TestGetProfile() {
 		t.Equal(200, resp.StatusCode)
 		b, _ := io.ReadAll(resp.Body)
 		t.Equal(`{"data":{"owner_id":"user_id","display_name":"display_name","email":"email","display_pic":"url"}}`, string(b))
+		t.Equal("Sun, 22 Sep 2019 11:42:31 GMT", resp.Header.Get("Last-Modified"))
+	})
+
+	t.Run("get profile with matching if-none-match should return 304", func() {
+		doc := &profile.ProfileDoc{OwnerId: "user_id", DisplayName: "display_name", UpdateDate: 1569152551}
+		t.profileService.EXPECT().GetProfile(gomock.Any(), "1234").Return(doc, nil).Times(2)
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Get("/profiles/:ownerId", func(c *fiber.Ctx) error {
+			return t.handler.GetProfile(c)
+		})
+		resp, _ := app.Test(httptest.NewRequest("GET", "/profiles/1234", nil), 20)
+		t.Equal(200, resp.StatusCode)
+
+		req := httptest.NewRequest("GET", "/profiles/1234", nil)
+		req.Header.Set("If-None-Match", resp.Header.Get("ETag"))
+		resp, _ = app.Test(req, 20)
+		t.Equal(304, resp.StatusCode)
 	})
 }
 
@@ -647,6 +2104,143 @@ func (t *HandlerTestSuite) TestGetProfileList() {
 		resp, _ := app.Test(req, 20)
 		t.Equal(200, resp.StatusCode)
 		b, _ := io.ReadAll(resp.Body)
-		t.Equal(`{"data":[{"owner_id":"user_id","display_name":"display_name","email":"email","display_pic":"url"}]}`, string(b))
+		t.Equal(`{"data":[{"owner_id":"user_id","display_name":"display_name","email":"email","display_pic":"url"}],"meta":{"page":1,"limit":2,"total":1,"has_next":false},"links":{"self":"/account/profiles?owner_id=1234,5454"}}`, string(b))
 	})
 }
+
+func (t *HandlerTestSuite) TestAuthorize() {
+	verifier, err := auth.NewVerifier(config.Auth{
+		Issuer:   "issuer",
+		Audience: "audience",
+		Keys:     []config.AuthKey{{ID: "local", Algorithm: "HS256", Secret: "secret"}},
+	})
+	t.Require().NoError(err)
+	h := NewHandler(t.taskService, t.commentService, t.profileService, verifier, t.recurrences, t.history, t.mentions, t.reactions)
+
+	sign := func(sub string, exp time.Time, secret string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+			Subject:   sub,
+			Issuer:    "issuer",
+			Audience:  jwt.ClaimStrings{"audience"},
+			ExpiresAt: jwt.NewNumericDate(exp),
+		})
+		token.Header["kid"] = "local"
+		signed, _ := token.SignedString([]byte(secret))
+		return signed
+	}
+	newApp := func() *fiber.App {
+		app := fiber.New()
+		group := app.Group("/account/:ownerId")
+		group.Use(h.Authorize)
+		group.Patch("/tasks/:taskId/archive", func(c *fiber.Ctx) error {
+			return c.SendString(c.Locals(LocalsSubject).(string))
+		})
+		return app
+	}
+
+	t.Run("request without token should return 401", func() {
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/134134134/archive", nil)
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(401, resp.StatusCode)
+		t.Equal("Bearer", resp.Header.Get("WWW-Authenticate"))
+	})
+
+	t.Run("request with non bearer scheme should return 401", func() {
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/134134134/archive", nil)
+		req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(401, resp.StatusCode)
+	})
+
+	t.Run("forged token should return 401", func() {
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/134134134/archive", nil)
+		req.Header.Set("Authorization", "Bearer "+sign("1234", time.Now().Add(time.Minute), "forged"))
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(401, resp.StatusCode)
+	})
+
+	t.Run("expired token should return 401", func() {
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/134134134/archive", nil)
+		req.Header.Set("Authorization", "Bearer "+sign("1234", time.Now().Add(-time.Minute), "secret"))
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(401, resp.StatusCode)
+	})
+
+	t.Run("token subject not matching owner id should return 403", func() {
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/134134134/archive", nil)
+		req.Header.Set("Authorization", "Bearer "+sign("5678", time.Now().Add(time.Minute), "secret"))
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(403, resp.StatusCode)
+	})
+
+	t.Run("valid token should pass subject to next handler", func() {
+		req := httptest.NewRequest("PATCH", "/account/1234/tasks/134134134/archive", nil)
+		req.Header.Set("Authorization", "Bearer "+sign("1234", time.Now().Add(time.Minute), "secret"))
+		resp, _ := newApp().Test(req, 20)
+		t.Equal(200, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		t.Equal("1234", string(b))
+	})
+}
+
+func (t *HandlerTestSuite) TestErrorHandler() {
+	newApp := func(err error) *fiber.App {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Get("/", func(c *fiber.Ctx) error {
+			return err
+		})
+		return app
+	}
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		body   string
+	}{
+		{
+			name:   "validation error should return 400 with its code",
+			err:    apperror.Validation("invalid_status", "Invalid status"),
+			status: 400,
+			body:   `{"error_code":"invalid_status","error_msg":"Invalid status","status":400}`,
+		},
+		{
+			name:   "forbidden error should return 403",
+			err:    apperror.ErrForbidden,
+			status: 403,
+			body:   `{"error_code":"forbidden","error_msg":"Operation not allowed","status":403}`,
+		},
+		{
+			name:   "conflict error should return 409",
+			err:    apperror.ErrConflict.Wrap(errors.New("E11000 duplicate key error collection: taskManager.tasks")),
+			status: 409,
+			body:   `{"error_code":"conflict","error_msg":"Resource state conflict","status":409}`,
+		},
+		{
+			name:   "unavailable error should return 503 without the raw cause",
+			err:    apperror.ErrUnavailable.Wrap(errors.New("server selection error: 10.0.0.1:27017")),
+			status: 503,
+			body:   `{"error_code":"service_unavailable","error_msg":"Service temporarily unavailable","status":503}`,
+		},
+		{
+			name:   "unknown error should return 500 without the raw cause",
+			err:    errors.New("(Unauthorized) command find requires authentication"),
+			status: 500,
+			body:   `{"error_code":"internal_error","error_msg":"Internal server error","status":500}`,
+		},
+		{
+			name:   "fiber error should keep its status and message",
+			err:    fiber.NewError(fiber.StatusBadRequest, "Invalid page number"),
+			status: 400,
+			body:   `{"error_code":"bad_request","error_msg":"Invalid page number","status":400}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func() {
+			resp, _ := newApp(tc.err).Test(httptest.NewRequest("GET", "/", nil), 20)
+			t.Equal(tc.status, resp.StatusCode)
+			b, _ := io.ReadAll(resp.Body)
+			t.Equal(tc.body, string(b))
+		})
+	}
+}
@@ -8,7 +8,12 @@ import (
 	context "context"
 	reflect "reflect"
 	comment "task-manager-api/internal/comment"
+	history "task-manager-api/internal/history"
+	mention "task-manager-api/internal/mention"
+	mongo "task-manager-api/internal/mongo"
 	profile "task-manager-api/internal/profile"
+	reaction "task-manager-api/internal/reaction"
+	recurrence "task-manager-api/internal/recurrence"
 	taskmanager "task-manager-api/internal/taskmanager"
 
 	gomock "github.com/golang/mock/gomock"
@@ -37,49 +42,125 @@ func (m *MockITasks) EXPECT() *MockITasksMockRecorder {
 	return m.recorder
 }
 
+// AddDependency mocks base method.
+func (m *MockITasks) AddDependency(ctx context.Context, ownerId, id, blockerId string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddDependency", ctx, ownerId, id, blockerId)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddDependency indicates an expected call of AddDependency.
+func (mr *MockITasksMockRecorder) AddDependency(ctx, ownerId, id, blockerId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddDependency", reflect.TypeOf((*MockITasks)(nil).AddDependency), ctx, ownerId, id, blockerId)
+}
+
+// AddLabels mocks base method.
+func (m *MockITasks) AddLabels(ctx context.Context, ownerId, id string, labels []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLabels", ctx, ownerId, id, labels)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddLabels indicates an expected call of AddLabels.
+func (mr *MockITasksMockRecorder) AddLabels(ctx, ownerId, id, labels interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLabels", reflect.TypeOf((*MockITasks)(nil).AddLabels), ctx, ownerId, id, labels)
+}
+
 // ArchiveTask mocks base method.
-func (m *MockITasks) ArchiveTask(ctx context.Context, ownerId, id string) (int, error) {
+func (m *MockITasks) ArchiveTask(ctx context.Context, ownerId, id string, version *int64) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ArchiveTask", ctx, ownerId, id)
+	ret := m.ctrl.Call(m, "ArchiveTask", ctx, ownerId, id, version)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ArchiveTask indicates an expected call of ArchiveTask.
-func (mr *MockITasksMockRecorder) ArchiveTask(ctx, ownerId, id interface{}) *gomock.Call {
+func (mr *MockITasksMockRecorder) ArchiveTask(ctx, ownerId, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveTask", reflect.TypeOf((*MockITasks)(nil).ArchiveTask), ctx, ownerId, id, version)
+}
+
+// AssignTask mocks base method.
+func (m *MockITasks) AssignTask(ctx context.Context, ownerId, id string, assignees []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignTask", ctx, ownerId, id, assignees)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AssignTask indicates an expected call of AssignTask.
+func (mr *MockITasksMockRecorder) AssignTask(ctx, ownerId, id, assignees interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveTask", reflect.TypeOf((*MockITasks)(nil).ArchiveTask), ctx, ownerId, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignTask", reflect.TypeOf((*MockITasks)(nil).AssignTask), ctx, ownerId, id, assignees)
 }
 
 // CreateTask mocks base method.
-func (m *MockITasks) CreateTask(ctx context.Context, ownerId, topic, desc string) (*taskmanager.TaskDoc, error) {
+func (m *MockITasks) CreateTask(ctx context.Context, ownerId string, task taskmanager.NewTask) (*taskmanager.TaskDoc, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateTask", ctx, ownerId, topic, desc)
+	ret := m.ctrl.Call(m, "CreateTask", ctx, ownerId, task)
 	ret0, _ := ret[0].(*taskmanager.TaskDoc)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateTask indicates an expected call of CreateTask.
-func (mr *MockITasksMockRecorder) CreateTask(ctx, ownerId, topic, desc interface{}) *gomock.Call {
+func (mr *MockITasksMockRecorder) CreateTask(ctx, ownerId, task interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTask", reflect.TypeOf((*MockITasks)(nil).CreateTask), ctx, ownerId, topic, desc)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTask", reflect.TypeOf((*MockITasks)(nil).CreateTask), ctx, ownerId, task)
+}
+
+// DeleteTask mocks base method.
+func (m *MockITasks) DeleteTask(ctx context.Context, ownerId, id string) (*taskmanager.TaskDoc, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTask", ctx, ownerId, id)
+	ret0, _ := ret[0].(*taskmanager.TaskDoc)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteTask indicates an expected call of DeleteTask.
+func (mr *MockITasksMockRecorder) DeleteTask(ctx, ownerId, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTask", reflect.TypeOf((*MockITasks)(nil).DeleteTask), ctx, ownerId, id)
 }
 
 // GetAllTask mocks base method.
-func (m *MockITasks) GetAllTask(ctx context.Context, page, limit int) ([]taskmanager.TaskDoc, error) {
+func (m *MockITasks) GetAllTask(ctx context.Context, filter taskmanager.TaskFilter, page mongo.Pagination) ([]taskmanager.TaskDoc, *mongo.PageInfo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllTask", ctx, page, limit)
+	ret := m.ctrl.Call(m, "GetAllTask", ctx, filter, page)
 	ret0, _ := ret[0].([]taskmanager.TaskDoc)
+	ret1, _ := ret[1].(*mongo.PageInfo)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAllTask indicates an expected call of GetAllTask.
+func (mr *MockITasksMockRecorder) GetAllTask(ctx, filter, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllTask", reflect.TypeOf((*MockITasks)(nil).GetAllTask), ctx, filter, page)
+}
+
+// GetLabels mocks base method.
+func (m *MockITasks) GetLabels(ctx context.Context, ownerId string) ([]taskmanager.LabelCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLabels", ctx, ownerId)
+	ret0, _ := ret[0].([]taskmanager.LabelCount)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAllTask indicates an expected call of GetAllTask.
-func (mr *MockITasksMockRecorder) GetAllTask(ctx, page, limit interface{}) *gomock.Call {
+// GetLabels indicates an expected call of GetLabels.
+func (mr *MockITasksMockRecorder) GetLabels(ctx, ownerId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllTask", reflect.TypeOf((*MockITasks)(nil).GetAllTask), ctx, page, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLabels", reflect.TypeOf((*MockITasks)(nil).GetLabels), ctx, ownerId)
 }
 
 // GetTask mocks base method.
@@ -97,18 +178,107 @@ func (mr *MockITasksMockRecorder) GetTask(ctx, id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTask", reflect.TypeOf((*MockITasks)(nil).GetTask), ctx, id)
 }
 
+// RemoveDependency mocks base method.
+func (m *MockITasks) RemoveDependency(ctx context.Context, ownerId, id, blockerId string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveDependency", ctx, ownerId, id, blockerId)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveDependency indicates an expected call of RemoveDependency.
+func (mr *MockITasksMockRecorder) RemoveDependency(ctx, ownerId, id, blockerId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveDependency", reflect.TypeOf((*MockITasks)(nil).RemoveDependency), ctx, ownerId, id, blockerId)
+}
+
+// RemoveLabels mocks base method.
+func (m *MockITasks) RemoveLabels(ctx context.Context, ownerId, id string, labels []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveLabels", ctx, ownerId, id, labels)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveLabels indicates an expected call of RemoveLabels.
+func (mr *MockITasksMockRecorder) RemoveLabels(ctx, ownerId, id, labels interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveLabels", reflect.TypeOf((*MockITasks)(nil).RemoveLabels), ctx, ownerId, id, labels)
+}
+
+// UnarchiveTask mocks base method.
+func (m *MockITasks) UnarchiveTask(ctx context.Context, ownerId, id string, version *int64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnarchiveTask", ctx, ownerId, id, version)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnarchiveTask indicates an expected call of UnarchiveTask.
+func (mr *MockITasksMockRecorder) UnarchiveTask(ctx, ownerId, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnarchiveTask", reflect.TypeOf((*MockITasks)(nil).UnarchiveTask), ctx, ownerId, id, version)
+}
+
+// UnassignTask mocks base method.
+func (m *MockITasks) UnassignTask(ctx context.Context, ownerId, id string, assignees []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnassignTask", ctx, ownerId, id, assignees)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnassignTask indicates an expected call of UnassignTask.
+func (mr *MockITasksMockRecorder) UnassignTask(ctx, ownerId, id, assignees interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnassignTask", reflect.TypeOf((*MockITasks)(nil).UnassignTask), ctx, ownerId, id, assignees)
+}
+
+// UpdateTask mocks base method.
+func (m *MockITasks) UpdateTask(ctx context.Context, ownerId, id string, update taskmanager.TaskUpdate, version *int64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTask", ctx, ownerId, id, update, version)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTask indicates an expected call of UpdateTask.
+func (mr *MockITasksMockRecorder) UpdateTask(ctx, ownerId, id, update, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTask", reflect.TypeOf((*MockITasks)(nil).UpdateTask), ctx, ownerId, id, update, version)
+}
+
 // UpdateTaskStatus mocks base method.
-func (m *MockITasks) UpdateTaskStatus(ctx context.Context, ownerId, id string, status int) error {
+func (m *MockITasks) UpdateTaskStatus(ctx context.Context, ownerId, id string, status int, force bool, version *int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateTaskStatus", ctx, ownerId, id, status)
+	ret := m.ctrl.Call(m, "UpdateTaskStatus", ctx, ownerId, id, status, force, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateTaskStatus indicates an expected call of UpdateTaskStatus.
-func (mr *MockITasksMockRecorder) UpdateTaskStatus(ctx, ownerId, id, status interface{}) *gomock.Call {
+func (mr *MockITasksMockRecorder) UpdateTaskStatus(ctx, ownerId, id, status, force, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTaskStatus", reflect.TypeOf((*MockITasks)(nil).UpdateTaskStatus), ctx, ownerId, id, status, force, version)
+}
+
+// Workflow mocks base method.
+func (m *MockITasks) Workflow() *taskmanager.Workflow {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Workflow")
+	ret0, _ := ret[0].(*taskmanager.Workflow)
+	return ret0
+}
+
+// Workflow indicates an expected call of Workflow.
+func (mr *MockITasksMockRecorder) Workflow() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTaskStatus", reflect.TypeOf((*MockITasks)(nil).UpdateTaskStatus), ctx, ownerId, id, status)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Workflow", reflect.TypeOf((*MockITasks)(nil).Workflow))
 }
 
 // MockIComments is a mock of IComments interface.
@@ -135,33 +305,109 @@ func (m *MockIComments) EXPECT() *MockICommentsMockRecorder {
 }
 
 // CreateComment mocks base method.
-func (m *MockIComments) CreateComment(ctx context.Context, ownerId, taskId, content string) (*comment.CommentDoc, error) {
+func (m *MockIComments) CreateComment(ctx context.Context, ownerId, taskId, content, parentId string) (*comment.CommentDoc, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateComment", ctx, ownerId, taskId, content)
+	ret := m.ctrl.Call(m, "CreateComment", ctx, ownerId, taskId, content, parentId)
 	ret0, _ := ret[0].(*comment.CommentDoc)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateComment indicates an expected call of CreateComment.
-func (mr *MockICommentsMockRecorder) CreateComment(ctx, ownerId, taskId, content interface{}) *gomock.Call {
+func (mr *MockICommentsMockRecorder) CreateComment(ctx, ownerId, taskId, content, parentId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateComment", reflect.TypeOf((*MockIComments)(nil).CreateComment), ctx, ownerId, taskId, content, parentId)
+}
+
+// DeleteComment mocks base method.
+func (m *MockIComments) DeleteComment(ctx context.Context, ownerId, taskId, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteComment", ctx, ownerId, taskId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteComment indicates an expected call of DeleteComment.
+func (mr *MockICommentsMockRecorder) DeleteComment(ctx, ownerId, taskId, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateComment", reflect.TypeOf((*MockIComments)(nil).CreateComment), ctx, ownerId, taskId, content)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteComment", reflect.TypeOf((*MockIComments)(nil).DeleteComment), ctx, ownerId, taskId, id)
+}
+
+// DeleteTaskComments mocks base method.
+func (m *MockIComments) DeleteTaskComments(ctx context.Context, taskIds []string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTaskComments", ctx, taskIds)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteTaskComments indicates an expected call of DeleteTaskComments.
+func (mr *MockICommentsMockRecorder) DeleteTaskComments(ctx, taskIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTaskComments", reflect.TypeOf((*MockIComments)(nil).DeleteTaskComments), ctx, taskIds)
+}
+
+// GetCommentRevisions mocks base method.
+func (m *MockIComments) GetCommentRevisions(ctx context.Context, ownerId, taskId, id string) ([]comment.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommentRevisions", ctx, ownerId, taskId, id)
+	ret0, _ := ret[0].([]comment.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCommentRevisions indicates an expected call of GetCommentRevisions.
+func (mr *MockICommentsMockRecorder) GetCommentRevisions(ctx, ownerId, taskId, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentRevisions", reflect.TypeOf((*MockIComments)(nil).GetCommentRevisions), ctx, ownerId, taskId, id)
+}
+
+// GetReplies mocks base method.
+func (m *MockIComments) GetReplies(ctx context.Context, taskId, id string, page mongo.Pagination) ([]comment.CommentDoc, *mongo.PageInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReplies", ctx, taskId, id, page)
+	ret0, _ := ret[0].([]comment.CommentDoc)
+	ret1, _ := ret[1].(*mongo.PageInfo)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetReplies indicates an expected call of GetReplies.
+func (mr *MockICommentsMockRecorder) GetReplies(ctx, taskId, id, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReplies", reflect.TypeOf((*MockIComments)(nil).GetReplies), ctx, taskId, id, page)
 }
 
 // GetTopicComments mocks base method.
-func (m *MockIComments) GetTopicComments(ctx context.Context, taskId string, page, limit int) ([]comment.CommentDoc, error) {
+func (m *MockIComments) GetTopicComments(ctx context.Context, taskId string, topLevel bool, page mongo.Pagination) ([]comment.CommentDoc, *mongo.PageInfo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTopicComments", ctx, taskId, page, limit)
+	ret := m.ctrl.Call(m, "GetTopicComments", ctx, taskId, topLevel, page)
 	ret0, _ := ret[0].([]comment.CommentDoc)
+	ret1, _ := ret[1].(*mongo.PageInfo)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTopicComments indicates an expected call of GetTopicComments.
+func (mr *MockICommentsMockRecorder) GetTopicComments(ctx, taskId, topLevel, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTopicComments", reflect.TypeOf((*MockIComments)(nil).GetTopicComments), ctx, taskId, topLevel, page)
+}
+
+// UpdateComment mocks base method.
+func (m *MockIComments) UpdateComment(ctx context.Context, ownerId, taskId, id, content string) (*comment.CommentDoc, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateComment", ctx, ownerId, taskId, id, content)
+	ret0, _ := ret[0].(*comment.CommentDoc)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetTopicComments indicates an expected call of GetTopicComments.
-func (mr *MockICommentsMockRecorder) GetTopicComments(ctx, taskId, page, limit interface{}) *gomock.Call {
+// UpdateComment indicates an expected call of UpdateComment.
+func (mr *MockICommentsMockRecorder) UpdateComment(ctx, ownerId, taskId, id, content interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTopicComments", reflect.TypeOf((*MockIComments)(nil).GetTopicComments), ctx, taskId, page, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateComment", reflect.TypeOf((*MockIComments)(nil).UpdateComment), ctx, ownerId, taskId, id, content)
 }
 
 // MockIProfile is a mock of IProfile interface.
@@ -216,3 +462,315 @@ func (mr *MockIProfileMockRecorder) GetProfileList(ctx, ownerId interface{}) *go
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfileList", reflect.TypeOf((*MockIProfile)(nil).GetProfileList), ctx, ownerId)
 }
+
+// MockIRecurrences is a mock of IRecurrences interface.
+type MockIRecurrences struct {
+	ctrl     *gomock.Controller
+	recorder *MockIRecurrencesMockRecorder
+}
+
+// MockIRecurrencesMockRecorder is the mock recorder for MockIRecurrences.
+type MockIRecurrencesMockRecorder struct {
+	mock *MockIRecurrences
+}
+
+// NewMockIRecurrences creates a new mock instance.
+func NewMockIRecurrences(ctrl *gomock.Controller) *MockIRecurrences {
+	mock := &MockIRecurrences{ctrl: ctrl}
+	mock.recorder = &MockIRecurrencesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIRecurrences) EXPECT() *MockIRecurrencesMockRecorder {
+	return m.recorder
+}
+
+// CreateRecurrence mocks base method.
+func (m *MockIRecurrences) CreateRecurrence(ctx context.Context, ownerId string, series recurrence.NewRecurrence) (*recurrence.RecurrenceDoc, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRecurrence", ctx, ownerId, series)
+	ret0, _ := ret[0].(*recurrence.RecurrenceDoc)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRecurrence indicates an expected call of CreateRecurrence.
+func (mr *MockIRecurrencesMockRecorder) CreateRecurrence(ctx, ownerId, series interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRecurrence", reflect.TypeOf((*MockIRecurrences)(nil).CreateRecurrence), ctx, ownerId, series)
+}
+
+// EndRecurrence mocks base method.
+func (m *MockIRecurrences) EndRecurrence(ctx context.Context, ownerId, id string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EndRecurrence", ctx, ownerId, id)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EndRecurrence indicates an expected call of EndRecurrence.
+func (mr *MockIRecurrencesMockRecorder) EndRecurrence(ctx, ownerId, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EndRecurrence", reflect.TypeOf((*MockIRecurrences)(nil).EndRecurrence), ctx, ownerId, id)
+}
+
+// GetRecurrences mocks base method.
+func (m *MockIRecurrences) GetRecurrences(ctx context.Context, ownerId string) ([]recurrence.RecurrenceDoc, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecurrences", ctx, ownerId)
+	ret0, _ := ret[0].([]recurrence.RecurrenceDoc)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecurrences indicates an expected call of GetRecurrences.
+func (mr *MockIRecurrencesMockRecorder) GetRecurrences(ctx, ownerId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecurrences", reflect.TypeOf((*MockIRecurrences)(nil).GetRecurrences), ctx, ownerId)
+}
+
+// MockIHistory is a mock of IHistory interface.
+type MockIHistory struct {
+	ctrl     *gomock.Controller
+	recorder *MockIHistoryMockRecorder
+}
+
+// MockIHistoryMockRecorder is the mock recorder for MockIHistory.
+type MockIHistoryMockRecorder struct {
+	mock *MockIHistory
+}
+
+// NewMockIHistory creates a new mock instance.
+func NewMockIHistory(ctrl *gomock.Controller) *MockIHistory {
+	mock := &MockIHistory{ctrl: ctrl}
+	mock.recorder = &MockIHistoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIHistory) EXPECT() *MockIHistoryMockRecorder {
+	return m.recorder
+}
+
+// GetTaskHistory mocks base method.
+func (m *MockIHistory) GetTaskHistory(ctx context.Context, taskId string, page mongo.Pagination) ([]history.HistoryDoc, *mongo.PageInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaskHistory", ctx, taskId, page)
+	ret0, _ := ret[0].([]history.HistoryDoc)
+	ret1, _ := ret[1].(*mongo.PageInfo)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTaskHistory indicates an expected call of GetTaskHistory.
+func (mr *MockIHistoryMockRecorder) GetTaskHistory(ctx, taskId, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaskHistory", reflect.TypeOf((*MockIHistory)(nil).GetTaskHistory), ctx, taskId, page)
+}
+
+// MockIMentions is a mock of IMentions interface.
+type MockIMentions struct {
+	ctrl     *gomock.Controller
+	recorder *MockIMentionsMockRecorder
+}
+
+// MockIMentionsMockRecorder is the mock recorder for MockIMentions.
+type MockIMentionsMockRecorder struct {
+	mock *MockIMentions
+}
+
+// NewMockIMentions creates a new mock instance.
+func NewMockIMentions(ctrl *gomock.Controller) *MockIMentions {
+	mock := &MockIMentions{ctrl: ctrl}
+	mock.recorder = &MockIMentionsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIMentions) EXPECT() *MockIMentionsMockRecorder {
+	return m.recorder
+}
+
+// DeleteTaskMentions mocks base method.
+func (m *MockIMentions) DeleteTaskMentions(ctx context.Context, taskIds []string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTaskMentions", ctx, taskIds)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteTaskMentions indicates an expected call of DeleteTaskMentions.
+func (mr *MockIMentionsMockRecorder) DeleteTaskMentions(ctx, taskIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTaskMentions", reflect.TypeOf((*MockIMentions)(nil).DeleteTaskMentions), ctx, taskIds)
+}
+
+// GetUnread mocks base method.
+func (m *MockIMentions) GetUnread(ctx context.Context, ownerId string, page mongo.Pagination) ([]mention.MentionDoc, *mongo.PageInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUnread", ctx, ownerId, page)
+	ret0, _ := ret[0].([]mention.MentionDoc)
+	ret1, _ := ret[1].(*mongo.PageInfo)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetUnread indicates an expected call of GetUnread.
+func (mr *MockIMentionsMockRecorder) GetUnread(ctx, ownerId, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnread", reflect.TypeOf((*MockIMentions)(nil).GetUnread), ctx, ownerId, page)
+}
+
+// MarkRead mocks base method.
+func (m *MockIMentions) MarkRead(ctx context.Context, ownerId, id string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRead", ctx, ownerId, id)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkRead indicates an expected call of MarkRead.
+func (mr *MockIMentionsMockRecorder) MarkRead(ctx, ownerId, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRead", reflect.TypeOf((*MockIMentions)(nil).MarkRead), ctx, ownerId, id)
+}
+
+// MockIReactions is a mock of IReactions interface.
+type MockIReactions struct {
+	ctrl     *gomock.Controller
+	recorder *MockIReactionsMockRecorder
+}
+
+// MockIReactionsMockRecorder is the mock recorder for MockIReactions.
+type MockIReactionsMockRecorder struct {
+	mock *MockIReactions
+}
+
+// NewMockIReactions creates a new mock instance.
+func NewMockIReactions(ctrl *gomock.Controller) *MockIReactions {
+	mock := &MockIReactions{ctrl: ctrl}
+	mock.recorder = &MockIReactionsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIReactions) EXPECT() *MockIReactionsMockRecorder {
+	return m.recorder
+}
+
+// AddReaction mocks base method.
+func (m *MockIReactions) AddReaction(ctx context.Context, ownerId, taskId, commentId, emoji string) (*reaction.ReactionDoc, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddReaction", ctx, ownerId, taskId, commentId, emoji)
+	ret0, _ := ret[0].(*reaction.ReactionDoc)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddReaction indicates an expected call of AddReaction.
+func (mr *MockIReactionsMockRecorder) AddReaction(ctx, ownerId, taskId, commentId, emoji interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddReaction", reflect.TypeOf((*MockIReactions)(nil).AddReaction), ctx, ownerId, taskId, commentId, emoji)
+}
+
+// CommentSummaries mocks base method.
+func (m *MockIReactions) CommentSummaries(ctx context.Context, taskId string, commentIds []string, viewer string) (map[string][]reaction.Summary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommentSummaries", ctx, taskId, commentIds, viewer)
+	ret0, _ := ret[0].(map[string][]reaction.Summary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CommentSummaries indicates an expected call of CommentSummaries.
+func (mr *MockIReactionsMockRecorder) CommentSummaries(ctx, taskId, commentIds, viewer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommentSummaries", reflect.TypeOf((*MockIReactions)(nil).CommentSummaries), ctx, taskId, commentIds, viewer)
+}
+
+// DeleteTaskReactions mocks base method.
+func (m *MockIReactions) DeleteTaskReactions(ctx context.Context, taskIds []string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTaskReactions", ctx, taskIds)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteTaskReactions indicates an expected call of DeleteTaskReactions.
+func (mr *MockIReactionsMockRecorder) DeleteTaskReactions(ctx, taskIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTaskReactions", reflect.TypeOf((*MockIReactions)(nil).DeleteTaskReactions), ctx, taskIds)
+}
+
+// RemoveReaction mocks base method.
+func (m *MockIReactions) RemoveReaction(ctx context.Context, ownerId, taskId, commentId, emoji string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveReaction", ctx, ownerId, taskId, commentId, emoji)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveReaction indicates an expected call of RemoveReaction.
+func (mr *MockIReactionsMockRecorder) RemoveReaction(ctx, ownerId, taskId, commentId, emoji interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveReaction", reflect.TypeOf((*MockIReactions)(nil).RemoveReaction), ctx, ownerId, taskId, commentId, emoji)
+}
+
+// TaskSummary mocks base method.
+func (m *MockIReactions) TaskSummary(ctx context.Context, taskId, viewer string) ([]reaction.Summary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TaskSummary", ctx, taskId, viewer)
+	ret0, _ := ret[0].([]reaction.Summary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TaskSummary indicates an expected call of TaskSummary.
+func (mr *MockIReactionsMockRecorder) TaskSummary(ctx, taskId, viewer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskSummary", reflect.TypeOf((*MockIReactions)(nil).TaskSummary), ctx, taskId, viewer)
+}
+
+// MockIAuth is a mock of IAuth interface.
+type MockIAuth struct {
+	ctrl     *gomock.Controller
+	recorder *MockIAuthMockRecorder
+}
+
+// MockIAuthMockRecorder is the mock recorder for MockIAuth.
+type MockIAuthMockRecorder struct {
+	mock *MockIAuth
+}
+
+// NewMockIAuth creates a new mock instance.
+func NewMockIAuth(ctrl *gomock.Controller) *MockIAuth {
+	mock := &MockIAuth{ctrl: ctrl}
+	mock.recorder = &MockIAuthMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIAuth) EXPECT() *MockIAuthMockRecorder {
+	return m.recorder
+}
+
+// Verify mocks base method.
+func (m *MockIAuth) Verify(token string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", token)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockIAuthMockRecorder) Verify(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockIAuth)(nil).Verify), token)
+}
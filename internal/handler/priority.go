@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"encoding/json"
+	"task-manager-api/internal/taskmanager"
+)
+
+// bodyPriority parses an optional priority of a request body, given by name
+// or number as in the priority filter.
+func bodyPriority(raw json.RawMessage) (*int, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		// not a string, numbers are parsed as written
+		value = string(raw)
+	}
+	priority, err := taskmanager.ParsePriority(value)
+	if err != nil {
+		return nil, err
+	}
+	return &priority, nil
+}
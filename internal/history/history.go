@@ -0,0 +1,158 @@
+package history
+
+import (
+	"context"
+	"reflect"
+	"task-manager-api/internal/apperror"
+	m "task-manager-api/internal/mongo"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//go:generate mockgen -source=./history.go -destination=./mock/history.go
+type IMongo interface {
+	InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error)
+	Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (m.Cursor, error)
+	CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error)
+	CreateIndexes(ctx context.Context, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error)
+}
+
+var ErrInvalidTaskID = apperror.Validation("invalid_task_id", "Invalid task id")
+
+// Fields recorded for changes that are not a single task field.
+const (
+	FieldCreated = "created"
+	FieldDeleted = "deleted"
+	FieldComment = "comment"
+	// FieldCommentEdited records the id of an edited comment, its content
+	// stays in the revisions only its author can read.
+	FieldCommentEdited = "comment_edited"
+)
+
+// SystemActor is the actor of changes made by background jobs.
+const SystemActor = "system"
+
+// Change is a field of a task going from Old to New.
+type Change struct {
+	TaskID string
+	Actor  string
+	Field  string
+	Old    interface{}
+	New    interface{}
+}
+
+// Changed reports whether the change actually modifies the field, events
+// such as a creation or a deletion always count as a change.
+func (c Change) Changed() bool {
+	switch c.Field {
+	case FieldCreated, FieldDeleted, FieldComment, FieldCommentEdited:
+		return true
+	}
+	return !reflect.DeepEqual(c.Old, c.New)
+}
+
+type HistoryDoc struct {
+	ID     string      `json:"id" bson:"_id,omitempty"`
+	TaskID string      `json:"task_id" bson:"task_id"`
+	Actor  string      `json:"actor" bson:"actor"`
+	Date   int64       `json:"date" bson:"date"`
+	Field  string      `json:"field" bson:"field"`
+	Old    interface{} `json:"old" bson:"old"`
+	New    interface{} `json:"new" bson:"new"`
+}
+
+type History struct {
+	mongo IMongo
+	time  func() time.Time
+}
+
+func NewHistoryService(mongo IMongo) *History {
+	return &History{mongo: mongo}
+}
+
+// EnsureIndexes creates the index backing history listing by task in _id order.
+func (h *History) EnsureIndexes(ctx context.Context) error {
+	models := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "_id", Value: 1}}},
+	}
+	if _, err := h.mongo.CreateIndexes(ctx, models); err != nil {
+		return m.WrapError(err)
+	}
+	return nil
+}
+
+// Record appends the changes that modify a field, all stamped with the same date.
+func (h *History) Record(ctx context.Context, changes ...Change) error {
+	now := h.now().Unix()
+	docs := make([]interface{}, 0, len(changes))
+	for _, c := range changes {
+		if !c.Changed() {
+			continue
+		}
+		docs = append(docs, HistoryDoc{
+			TaskID: c.TaskID,
+			Actor:  c.Actor,
+			Date:   now,
+			Field:  c.Field,
+			Old:    c.Old,
+			New:    c.New,
+		})
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+	if _, err := h.mongo.InsertMany(ctx, docs); err != nil {
+		return m.WrapError(err)
+	}
+	return nil
+}
+
+// GetTaskHistory lists the changes of a task, oldest first. The history of
+// deleted tasks is kept.
+func (h *History) GetTaskHistory(ctx context.Context, taskId string, page m.Pagination) ([]HistoryDoc, *m.PageInfo, error) {
+	if _, err := primitive.ObjectIDFromHex(taskId); err != nil {
+		return nil, nil, ErrInvalidTaskID.Wrap(err)
+	}
+	filter := bson.M{
+		"task_id": taskId,
+	}
+	sort := bson.D{{Key: "_id", Value: 1}}
+	query, opts, err := page.Apply(filter, sort)
+	if err != nil {
+		return nil, nil, err
+	}
+	curr, err := h.mongo.Find(ctx, query, opts)
+	if err != nil {
+		return nil, nil, m.WrapError(err)
+	}
+
+	var records = make([]HistoryDoc, 0)
+	if err := curr.All(ctx, &records); err != nil {
+		return nil, nil, m.WrapError(err)
+	}
+	records, pageInfo, err := m.Paginate(page, sort, records)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !page.SkipCount {
+		total, err := h.mongo.CountDocuments(ctx, filter)
+		if err != nil {
+			return nil, nil, m.WrapError(err)
+		}
+		pageInfo.Total = &total
+	}
+	return records, pageInfo, nil
+}
+
+func (h *History) now() time.Time {
+	if h.time == nil {
+		return time.Now()
+	}
+
+	return h.time()
+}
@@ -0,0 +1,122 @@
+package history
+
+import (
+	"context"
+	"errors"
+	mock_history "task-manager-api/internal/history/mock"
+	m "task-manager-api/internal/mongo"
+	mock "task-manager-api/internal/mongo/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type HistoryTestSuite struct {
+	suite.Suite
+	ctrl      *gomock.Controller
+	mockMongo *mock_history.MockIMongo
+	service   *History
+	cursor    *mock.MockCursor
+}
+
+func (t *HistoryTestSuite) SetupTest() {
+	t.ctrl = gomock.NewController(t.T())
+	t.mockMongo = mock_history.NewMockIMongo(t.ctrl)
+	t.service = NewHistoryService(t.mockMongo)
+	t.cursor = mock.NewMockCursor(t.ctrl)
+	t.service.time = func() time.Time {
+		loc, _ := time.LoadLocation("Asia/Bangkok")
+		return time.Date(2019, 9, 22, 12, 42, 31, 0, loc)
+	}
+}
+
+func (t *HistoryTestSuite) TearDownTest() {
+	t.ctrl.Finish()
+	t.mockMongo = nil
+	t.service = nil
+	t.cursor = nil
+}
+
+func TestHistoryTestSuite(t *testing.T) {
+	suite.Run(t, new(HistoryTestSuite))
+}
+
+func (t *HistoryTestSuite) TestRecord() {
+	t.Run("record skips fields left unchanged", func() {
+		due := int64(1614962551)
+		same := int64(1614962551)
+		t.mockMongo.EXPECT().InsertMany(context.Background(), []interface{}{
+			HistoryDoc{TaskID: "task_id", Actor: "owner_id", Date: 1569130951, Field: "status", Old: 1, New: 2},
+		}).Return(&mongo.InsertManyResult{}, nil)
+		t.NoError(t.service.Record(context.Background(),
+			Change{TaskID: "task_id", Actor: "owner_id", Field: "status", Old: 1, New: 2},
+			Change{TaskID: "task_id", Actor: "owner_id", Field: "topic", Old: "topic", New: "topic"},
+			Change{TaskID: "task_id", Actor: "owner_id", Field: "due_date", Old: &due, New: &same},
+		))
+	})
+
+	t.Run("record always keeps events", func() {
+		t.mockMongo.EXPECT().InsertMany(context.Background(), []interface{}{
+			HistoryDoc{TaskID: "task_id", Actor: SystemActor, Date: 1569130951, Field: FieldDeleted},
+		}).Return(&mongo.InsertManyResult{}, nil)
+		t.NoError(t.service.Record(context.Background(), Change{TaskID: "task_id", Actor: SystemActor, Field: FieldDeleted}))
+	})
+
+	t.Run("record without changes writes nothing", func() {
+		t.NoError(t.service.Record(context.Background(), Change{Field: "topic", Old: "topic", New: "topic"}))
+	})
+
+	t.Run("record but insert has error should return error", func() {
+		t.mockMongo.EXPECT().InsertMany(context.Background(), gomock.Any()).Return(nil, errors.New("insert many error"))
+		t.EqualError(t.service.Record(context.Background(), Change{Field: "status", Old: 1, New: 2}), "insert many error")
+	})
+}
+
+func (t *HistoryTestSuite) TestGetTaskHistory() {
+	l := int64(11)
+	skip := int64(0)
+	fOpt := (&options.FindOptions{Limit: &l, Skip: &skip}).SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	t.Run("get task history with malformed id should return error", func() {
+		records, _, err := t.service.GetTaskHistory(context.Background(), "task_id", m.Pagination{Page: 1, Limit: 10})
+		t.Nil(records)
+		t.ErrorIs(err, ErrInvalidTaskID)
+	})
+
+	t.Run("get task history should return records with total", func() {
+		t.mockMongo.EXPECT().Find(context.Background(), bson.M{"task_id": "645b9183fcfbc11433e23ab3"}, fOpt).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).SetArg(1, []HistoryDoc{{ID: "645b9183fcfbc11433e23ab4", Field: "status"}}).Return(nil)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), bson.M{"task_id": "645b9183fcfbc11433e23ab3"}).Return(int64(1), nil)
+		records, pageInfo, err := t.service.GetTaskHistory(context.Background(), "645b9183fcfbc11433e23ab3", m.Pagination{Page: 1, Limit: 10})
+		t.NoError(err)
+		t.Len(records, 1)
+		t.Equal(int64(1), *pageInfo.Total)
+		t.False(pageInfo.HasNext)
+	})
+
+	t.Run("get task history should list the oldest change first", func() {
+		t.mockMongo.EXPECT().Find(context.Background(), bson.M{"task_id": "645b9183fcfbc11433e23ab3"}, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ interface{}, opts ...*options.FindOptions) (m.Cursor, error) {
+				t.Equal(bson.D{{Key: "_id", Value: 1}}, opts[0].Sort)
+				return t.cursor, nil
+			})
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).SetArg(1, []HistoryDoc{
+			{ID: "645b9183fcfbc11433e23ab4", Field: "topic"},
+			{ID: "645b9183fcfbc11433e23ab5", Field: "status"},
+		}).Return(nil)
+		records, _, err := t.service.GetTaskHistory(context.Background(), "645b9183fcfbc11433e23ab3", m.Pagination{Limit: 10, SkipCount: true})
+		t.NoError(err)
+		t.Equal([]string{"645b9183fcfbc11433e23ab4", "645b9183fcfbc11433e23ab5"}, []string{records[0].ID, records[1].ID})
+	})
+
+	t.Run("get task history but find has error should return error", func() {
+		t.mockMongo.EXPECT().Find(context.Background(), gomock.Any(), gomock.Any()).Return(nil, errors.New("find error"))
+		_, _, err := t.service.GetTaskHistory(context.Background(), "645b9183fcfbc11433e23ab3", m.Pagination{Page: 1, Limit: 10})
+		t.EqualError(err, "find error")
+	})
+}
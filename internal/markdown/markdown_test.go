@@ -0,0 +1,133 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MarkdownTestSuite struct {
+	suite.Suite
+}
+
+func TestMarkdownTestSuite(t *testing.T) {
+	suite.Run(t, new(MarkdownTestSuite))
+}
+
+func (t *MarkdownTestSuite) TestRenderBlocks() {
+	t.Run("render paragraphs with soft and hard line breaks", func() {
+		t.Equal("<p>first line\nsecond<br>\nthird</p>\n<p>next</p>\n", Render("first line\nsecond  \nthird\r\n\r\nnext"))
+	})
+
+	t.Run("render headings without the closing sequence", func() {
+		t.Equal("<h1>Title</h1>\n<h3>Section</h3>\n<p>#hashtag</p>\n", Render("# Title ##\n###   Section\n#hashtag"))
+	})
+
+	t.Run("render fenced code with a language class", func() {
+		t.Equal("<pre><code class=\"language-go\">if a &lt; b {\n}\n</code></pre>\n", Render("```go\nif a < b {\n}\n```"))
+	})
+
+	t.Run("render fenced code without an unsafe language class", func() {
+		t.Equal("<pre><code>x\n</code></pre>\n", Render("```go\" onclick=\"x\nx\n```"))
+	})
+
+	t.Run("render unclosed fence to the end", func() {
+		t.Equal("<pre><code>~~~ not closed</code></pre>\n", Render("~~~~\n~~~ not closed"))
+	})
+
+	t.Run("render nested quotes with lazy lines", func() {
+		t.Equal("<blockquote>\n<p>quote\nlazy</p>\n<blockquote>\n<p>nested</p>\n</blockquote>\n</blockquote>\n", Render("> quote\nlazy\n>\n> > nested"))
+	})
+
+	t.Run("render tight lists with nested items", func() {
+		t.Equal("<ul>\n<li>a</li>\n<li>b\n<ul>\n<li>c</li>\n</ul>\n</li>\n</ul>\n", Render("- a\n- b\n  - c"))
+	})
+
+	t.Run("render loose ordered list with its start", func() {
+		t.Equal("<ol start=\"3\">\n<li>\n<p>three</p>\n</li>\n<li>\n<p>four</p>\n</li>\n</ol>\n", Render("3. three\n\n4. four"))
+	})
+
+	t.Run("render numbers inside a paragraph as text", func() {
+		t.Equal("<p>We shipped in\n2019. It was late</p>\n", Render("We shipped in\n2019. It was late"))
+	})
+
+	t.Run("render thematic breaks", func() {
+		t.Equal("<hr>\n<hr>\n", Render("* * *\n___"))
+	})
+}
+
+func (t *MarkdownTestSuite) TestRenderInline() {
+	t.Run("render emphasis", func() {
+		t.Equal("<p><em>a</em> <strong>b</strong> <em><strong>c</strong></em> <em>d</em></p>\n", Render("*a* **b** ***c*** _d_"))
+	})
+
+	t.Run("render underscores inside words as text", func() {
+		t.Equal("<p>snake_case_name and 2 * 3 * 4</p>\n", Render("snake_case_name and 2 * 3 * 4"))
+	})
+
+	t.Run("render code spans without markup", func() {
+		t.Equal("<p><code>*a* &lt;b&gt;</code> and <code>a ` b</code></p>\n", Render("`*a* <b>` and `` a ` b ``"))
+	})
+
+	t.Run("render backslash escapes and entities", func() {
+		t.Equal("<p>*not em* &amp; &lt;b&gt; \\d</p>\n", Render("\\*not em\\* &amp; &lt;b&gt; \\d"))
+	})
+
+	t.Run("render links with titles", func() {
+		t.Equal(`<p><a href="https://example.com/a%20b" title="a &#34;title&#34;" rel="nofollow">see <em>this</em></a></p>`+"\n",
+			Render(`[see *this*](<https://example.com/a b> "a \"title\"")`))
+	})
+
+	t.Run("render relative links", func() {
+		t.Equal(`<p><a href="/tasks/1?tab=a:b" rel="nofollow">task</a></p>`+"\n", Render("[task](/tasks/1?tab=a:b)"))
+	})
+
+	t.Run("render autolinks", func() {
+		t.Equal(`<p><a href="https://example.com" rel="nofollow">https://example.com</a> <a href="mailto:jane@example.com" rel="nofollow">jane@example.com</a></p>`+"\n",
+			Render("<https://example.com> <jane@example.com>"))
+	})
+}
+
+func (t *MarkdownTestSuite) TestRenderSanitize() {
+	t.Run("render raw html as text", func() {
+		t.Equal("<p>&lt;script&gt;alert(1)&lt;/script&gt;&lt;img src=x onerror=&#34;alert(1)&#34;&gt;</p>\n",
+			Render(`<script>alert(1)</script><img src=x onerror="alert(1)">`))
+	})
+
+	t.Run("render links with disallowed schemes as text", func() {
+		for _, src := range []string{
+			"[click](javascript:alert(1))",
+			"[click](JavaScript:alert(1))",
+			"[click](&#106;avascript:alert(1))",
+			"[click](<java script:alert(1)>)",
+			"[click](data:text/html;base64,PHNjcmlwdD4=)",
+			"[click](vbscript:msgbox)",
+		} {
+			t.Equal("<p>click</p>\n", Render(src), src)
+		}
+	})
+
+	t.Run("render autolinks with disallowed schemes as text", func() {
+		t.Equal("<p>javascript:alert(1)</p>\n", Render("<javascript:alert(1)>"))
+	})
+
+	t.Run("render html in link text and titles escaped", func() {
+		t.Equal(`<p><a href="https://x" title="&lt;b&gt;" rel="nofollow">&lt;img src=x onerror=alert(1)&gt;</a></p>`+"\n",
+			Render(`[<img src=x onerror=alert(1)>](https://x "<b>")`))
+	})
+
+	t.Run("render html blocks as text", func() {
+		t.Equal("<p>&lt;div onclick=&#34;x&#34;&gt;\nhi\n&lt;/div&gt;</p>\n", Render("<div onclick=\"x\">\nhi\n</div>"))
+	})
+
+	t.Run("render images as their alt text", func() {
+		t.Equal("<p>a <em>chart</em></p>\n", Render("![a *chart*](https://example.com/chart.png)"))
+	})
+
+	t.Run("render deep nesting", func() {
+		html := Render(strings.Repeat(">", 100) + " deep")
+		t.Equal(100, strings.Count(html, "<blockquote>"))
+		t.Equal(100, strings.Count(html, "</blockquote>"))
+	})
+}
@@ -0,0 +1,173 @@
+package mention
+
+import (
+	"context"
+	"task-manager-api/internal/apperror"
+	m "task-manager-api/internal/mongo"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//go:generate mockgen -source=./mention.go -destination=./mock/mention.go
+type IMongo interface {
+	InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error)
+	UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+	Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (m.Cursor, error)
+	CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error)
+	CreateIndexes(ctx context.Context, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error)
+	DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
+	Aggregate(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (m.Cursor, error)
+}
+
+var ErrInvalidMentionID = apperror.Validation("invalid_mention_id", "Invalid mention id")
+
+// MentionDoc tells OwnerID that AuthorID mentioned them in a comment,
+// ReadDate is set once they have seen it.
+type MentionDoc struct {
+	ID         string `json:"id" bson:"_id,omitempty"`
+	OwnerID    string `json:"owner_id" bson:"owner_id"`
+	AuthorID   string `json:"author_id" bson:"author_id"`
+	TaskID     string `json:"task_id" bson:"task_id"`
+	CommentID  string `json:"comment_id" bson:"comment_id"`
+	CreateDate int64  `json:"create_date" bson:"create_date"`
+	ReadDate   *int64 `json:"read_date" bson:"read_date"`
+}
+
+type Mentions struct {
+	mongo IMongo
+	time  func() time.Time
+}
+
+func NewMentionService(mongo IMongo) *Mentions {
+	return &Mentions{mongo: mongo}
+}
+
+// EnsureIndexes creates the indexes backing the unread inbox and the
+// deletion of tasks, and the unique index keeping a person from being
+// notified twice of the same comment.
+func (s *Mentions) EnsureIndexes(ctx context.Context) error {
+	models := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "read_date", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "task_id", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "comment_id", Value: 1}, {Key: "owner_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	if _, err := s.mongo.CreateIndexes(ctx, models); err != nil {
+		return m.WrapError(err)
+	}
+	return nil
+}
+
+// Notify adds a comment to the inbox of the people it mentions, except its
+// author. People already notified of the comment are skipped.
+func (s *Mentions) Notify(ctx context.Context, taskId string, commentId string, authorId string, ownerIds []string) error {
+	now := s.now().Unix()
+	docs := make([]interface{}, 0, len(ownerIds))
+	for _, ownerId := range ownerIds {
+		if ownerId == authorId {
+			continue
+		}
+		docs = append(docs, MentionDoc{
+			OwnerID:    ownerId,
+			AuthorID:   authorId,
+			TaskID:     taskId,
+			CommentID:  commentId,
+			CreateDate: now,
+		})
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+	_, err := s.mongo.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return m.WrapError(err)
+	}
+	return nil
+}
+
+// GetUnread lists the mentions of an owner that are not read yet, oldest first.
+func (s *Mentions) GetUnread(ctx context.Context, ownerId string, page m.Pagination) ([]MentionDoc, *m.PageInfo, error) {
+	filter := bson.M{
+		"owner_id":  ownerId,
+		"read_date": nil,
+	}
+	sort := bson.D{{Key: "_id", Value: 1}}
+	query, opts, err := page.Apply(filter, sort)
+	if err != nil {
+		return nil, nil, err
+	}
+	curr, err := s.mongo.Find(ctx, query, opts)
+	if err != nil {
+		return nil, nil, m.WrapError(err)
+	}
+
+	var mentions = make([]MentionDoc, 0)
+	if err := curr.All(ctx, &mentions); err != nil {
+		return nil, nil, m.WrapError(err)
+	}
+	mentions, pageInfo, err := m.Paginate(page, sort, mentions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !page.SkipCount {
+		total, err := s.mongo.CountDocuments(ctx, filter)
+		if err != nil {
+			return nil, nil, m.WrapError(err)
+		}
+		pageInfo.Total = &total
+	}
+	return mentions, pageInfo, nil
+}
+
+// MarkRead removes a mention from the unread inbox of its owner, marking a
+// read mention again keeps its first read date.
+func (s *Mentions) MarkRead(ctx context.Context, ownerId string, id string) (int, error) {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return 0, ErrInvalidMentionID.Wrap(err)
+	}
+	result, err := s.mongo.UpdateOne(ctx, bson.M{
+		"_id":      objectId,
+		"owner_id": ownerId,
+	}, bson.A{
+		bson.M{"$set": bson.M{
+			"read_date": bson.M{"$ifNull": bson.A{"$read_date", s.now().Unix()}},
+		}},
+	})
+	if err != nil {
+		return 0, m.WrapError(err)
+	}
+	return int(result.MatchedCount), nil
+}
+
+// DeleteTaskMentions removes the mentions in the comments of deleted tasks.
+func (s *Mentions) DeleteTaskMentions(ctx context.Context, taskIds []string) (int64, error) {
+	result, err := s.mongo.DeleteMany(ctx, bson.M{
+		"task_id": bson.M{"$in": taskIds},
+	})
+	if err != nil {
+		return 0, m.WrapError(err)
+	}
+	return result.DeletedCount, nil
+}
+
+// TaskIDs returns, in order, up to limit ids of the tasks with mentions that
+// sort after the given id.
+func (s *Mentions) TaskIDs(ctx context.Context, after string, limit int64) ([]string, error) {
+	return m.DistinctAfter(ctx, s.mongo, "task_id", after, limit)
+}
+
+func (s *Mentions) now() time.Time {
+	if s.time == nil {
+		return time.Now()
+	}
+
+	return s.time()
+}
@@ -0,0 +1,144 @@
+package mention
+
+import (
+	"context"
+	"errors"
+	mock_mention "task-manager-api/internal/mention/mock"
+	m "task-manager-api/internal/mongo"
+	mock "task-manager-api/internal/mongo/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MentionTestSuite struct {
+	suite.Suite
+	ctrl      *gomock.Controller
+	mockMongo *mock_mention.MockIMongo
+	service   *Mentions
+	cursor    *mock.MockCursor
+}
+
+func (t *MentionTestSuite) SetupTest() {
+	t.ctrl = gomock.NewController(t.T())
+	t.mockMongo = mock_mention.NewMockIMongo(t.ctrl)
+	t.service = NewMentionService(t.mockMongo)
+	t.cursor = mock.NewMockCursor(t.ctrl)
+	t.service.time = func() time.Time {
+		loc, _ := time.LoadLocation("Asia/Bangkok")
+		return time.Date(2019, 9, 22, 12, 42, 31, 0, loc)
+	}
+}
+
+func (t *MentionTestSuite) TearDownTest() {
+	t.ctrl.Finish()
+	t.mockMongo = nil
+	t.service = nil
+	t.cursor = nil
+}
+
+func TestMentionTestSuite(t *testing.T) {
+	suite.Run(t, new(MentionTestSuite))
+}
+
+func (t *MentionTestSuite) TestNotify() {
+	t.Run("notify should skip the author", func() {
+		t.mockMongo.EXPECT().InsertMany(context.Background(), []interface{}{
+			MentionDoc{OwnerID: "5678", AuthorID: "1234", TaskID: "task_id", CommentID: "comment_id", CreateDate: 1569130951},
+		}, options.InsertMany().SetOrdered(false)).Return(&mongo.InsertManyResult{}, nil)
+		t.NoError(t.service.Notify(context.Background(), "task_id", "comment_id", "1234", []string{"1234", "5678"}))
+	})
+
+	t.Run("notify only the author should not write", func() {
+		t.NoError(t.service.Notify(context.Background(), "task_id", "comment_id", "1234", []string{"1234"}))
+	})
+
+	t.Run("notify someone already notified should succeed", func() {
+		t.mockMongo.EXPECT().InsertMany(context.Background(), gomock.Any(), gomock.Any()).Return(nil, mongo.BulkWriteException{
+			WriteErrors: []mongo.BulkWriteError{{WriteError: mongo.WriteError{Code: 11000}}},
+		})
+		t.NoError(t.service.Notify(context.Background(), "task_id", "comment_id", "1234", []string{"5678"}))
+	})
+
+	t.Run("notify but insert got error should return error", func() {
+		t.mockMongo.EXPECT().InsertMany(context.Background(), gomock.Any(), gomock.Any()).Return(nil, errors.New("insert many error"))
+		t.EqualError(t.service.Notify(context.Background(), "task_id", "comment_id", "1234", []string{"5678"}), "insert many error")
+	})
+}
+
+func (t *MentionTestSuite) TestGetUnread() {
+	t.Run("get unread mentions should only list unread mentions of the owner oldest first", func() {
+		l, skip := int64(11), int64(0)
+		t.mockMongo.EXPECT().Find(context.Background(), bson.M{
+			"owner_id":  "5678",
+			"read_date": nil,
+		}, (&options.FindOptions{Limit: &l, Skip: &skip}).SetSort(bson.D{{Key: "_id", Value: 1}})).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).SetArg(1, []MentionDoc{{ID: "mention_id", OwnerID: "5678"}}).Return(nil)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), bson.M{
+			"owner_id":  "5678",
+			"read_date": nil,
+		}).Return(int64(1), nil)
+		mentions, pageInfo, err := t.service.GetUnread(context.Background(), "5678", m.Pagination{Page: 1, Limit: 10})
+		t.NoError(err)
+		t.Equal([]MentionDoc{{ID: "mention_id", OwnerID: "5678"}}, mentions)
+		t.Equal(int64(1), *pageInfo.Total)
+	})
+
+	t.Run("get unread mentions but find got error should return error", func() {
+		t.mockMongo.EXPECT().Find(context.Background(), gomock.Any(), gomock.Any()).Return(nil, errors.New("find error"))
+		_, _, err := t.service.GetUnread(context.Background(), "5678", m.Pagination{Page: 1, Limit: 10})
+		t.EqualError(err, "find error")
+	})
+}
+
+func (t *MentionTestSuite) TestMarkRead() {
+	t.Run("mark read with malformed id should return error", func() {
+		_, err := t.service.MarkRead(context.Background(), "5678", "mention_id")
+		t.ErrorIs(err, ErrInvalidMentionID)
+	})
+
+	t.Run("mark read should keep the first read date", func() {
+		objectId, _ := primitive.ObjectIDFromHex("645b9183fcfbc11433e23ab3")
+		t.mockMongo.EXPECT().UpdateOne(context.Background(), bson.M{
+			"_id":      objectId,
+			"owner_id": "5678",
+		}, bson.A{
+			bson.M{"$set": bson.M{
+				"read_date": bson.M{"$ifNull": bson.A{"$read_date", int64(1569130951)}},
+			}},
+		}).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+		count, err := t.service.MarkRead(context.Background(), "5678", "645b9183fcfbc11433e23ab3")
+		t.NoError(err)
+		t.Equal(1, count)
+	})
+}
+
+func (t *MentionTestSuite) TestDeleteTaskMentions() {
+	t.Run("delete task mentions should delete the mentions of the tasks", func() {
+		t.mockMongo.EXPECT().DeleteMany(context.Background(), bson.M{
+			"task_id": bson.M{"$in": []string{"task_1", "task_2"}},
+		}).Return(&mongo.DeleteResult{DeletedCount: 3}, nil)
+		deleted, err := t.service.DeleteTaskMentions(context.Background(), []string{"task_1", "task_2"})
+		t.NoError(err)
+		t.Equal(int64(3), deleted)
+	})
+
+	t.Run("delete task mentions but delete got error should return error", func() {
+		t.mockMongo.EXPECT().DeleteMany(context.Background(), gomock.Any()).Return(nil, errors.New("delete many error"))
+		_, err := t.service.DeleteTaskMentions(context.Background(), []string{"task_1"})
+		t.EqualError(err, "delete many error")
+	})
+}
+
+func (t *MentionTestSuite) TestEnsureIndexes() {
+	t.Run("ensure indexes but create got error should return error", func() {
+		t.mockMongo.EXPECT().CreateIndexes(context.Background(), gomock.Any()).Return(nil, errors.New("create indexes error"))
+		t.EqualError(t.service.EnsureIndexes(context.Background()), "create indexes error")
+	})
+}
@@ -0,0 +1,65 @@
+package mention
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxMentions bounds the number of people a single comment can mention,
+// further mentions are ignored.
+const MaxMentions = 20
+
+// maxNameLength bounds a quoted display name such as @"Jane Doe".
+const maxNameLength = 64
+
+// Parse returns the distinct mentions of a text in order of appearance.
+// A mention is an @ followed by an owner id or a display name, names with
+// spaces are quoted as in @"Jane Doe". An @ preceded by a letter or digit
+// is part of an email address and is not a mention.
+func Parse(text string) []string {
+	var mentions []string
+	seen := make(map[string]bool)
+	for i := 0; i < len(text) && len(mentions) < MaxMentions; i++ {
+		if text[i] != '@' || (i > 0 && isWord(lastRune(text[:i]))) {
+			continue
+		}
+		var token string
+		if rest := text[i+1:]; strings.HasPrefix(rest, `"`) {
+			end := strings.IndexAny(rest[1:], "\"\n")
+			if end < 0 || rest[1+end] != '"' {
+				continue
+			}
+			token = strings.TrimSpace(rest[1 : 1+end])
+			i += 1 + end + 1
+			if utf8.RuneCountInString(token) > maxNameLength {
+				continue
+			}
+		} else {
+			end := strings.IndexFunc(rest, func(r rune) bool {
+				return !isWord(r) && r != '.' && r != '-'
+			})
+			if end < 0 {
+				end = len(rest)
+			}
+			// a sentence may end right after a mention
+			token = strings.TrimRight(rest[:end], ".-")
+			i += end
+		}
+		if token == "" || seen[token] {
+			continue
+		}
+		seen[token] = true
+		mentions = append(mentions, token)
+	}
+	return mentions
+}
+
+func isWord(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func lastRune(s string) rune {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
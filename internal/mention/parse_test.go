@@ -0,0 +1,53 @@
+package mention
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ParseTestSuite struct {
+	suite.Suite
+}
+
+func TestParseTestSuite(t *testing.T) {
+	suite.Run(t, new(ParseTestSuite))
+}
+
+func (t *ParseTestSuite) TestParse() {
+	t.Run("parse owner ids and names in order without duplicates", func() {
+		t.Equal([]string{"5678", "jane", "1234"}, Parse("@5678 can you ask @jane? cc @1234 @5678"))
+	})
+
+	t.Run("parse quoted display names", func() {
+		t.Equal([]string{"Jane Doe", "Sam"}, Parse(`thanks @"Jane Doe" and @"  Sam "`))
+	})
+
+	t.Run("parse should drop the punctuation ending a sentence", func() {
+		t.Equal([]string{"jane.doe", "sam"}, Parse("ask @jane.doe. Or @sam-"))
+	})
+
+	t.Run("parse should ignore email addresses", func() {
+		t.Nil(Parse("mail jane@example.com"))
+	})
+
+	t.Run("parse should ignore unterminated or empty mentions", func() {
+		t.Nil(Parse(`@ @"" @"Jane` + "\n" + `Doe"`))
+	})
+
+	t.Run("parse should ignore names that are too long", func() {
+		t.Nil(Parse(`@"` + strings.Repeat("a", maxNameLength+1) + `"`))
+	})
+
+	t.Run("parse should stop at the mention limit", func() {
+		var text strings.Builder
+		for i := 0; i < MaxMentions+5; i++ {
+			text.WriteString(" @" + strconv.Itoa(i))
+		}
+		mentions := Parse(text.String())
+		t.Len(mentions, MaxMentions)
+		t.Equal("0", mentions[0])
+	})
+}
@@ -28,6 +28,18 @@ func (c *CollectionHelper) UpdateOne(ctx context.Context, filter interface{}, up
 	return c.collection.UpdateOne(ctx, filter, update, opts...)
 }
 
+func (c *CollectionHelper) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...*options.FindOneAndUpdateOptions) SingleResult {
+	return c.collection.FindOneAndUpdate(ctx, filter, update, opts...)
+}
+
+func (c *CollectionHelper) FindOneAndDelete(ctx context.Context, filter interface{}, opts ...*options.FindOneAndDeleteOptions) SingleResult {
+	return c.collection.FindOneAndDelete(ctx, filter, opts...)
+}
+
+func (c *CollectionHelper) InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+	return c.collection.InsertMany(ctx, documents, opts...)
+}
+
 func (c *CollectionHelper) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
 	return c.collection.InsertOne(ctx, document, opts...)
 }
@@ -36,6 +48,26 @@ func (c *CollectionHelper) Find(ctx context.Context, filter interface{}, opts ..
 	return c.collection.Find(ctx, filter, opts...)
 }
 
+func (c *CollectionHelper) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	return c.collection.CountDocuments(ctx, filter, opts...)
+}
+
+func (c *CollectionHelper) Aggregate(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (Cursor, error) {
+	return c.collection.Aggregate(ctx, pipeline, opts...)
+}
+
+func (c *CollectionHelper) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	return c.collection.DeleteOne(ctx, filter, opts...)
+}
+
+func (c *CollectionHelper) DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	return c.collection.DeleteMany(ctx, filter, opts...)
+}
+
+func (c *CollectionHelper) CreateIndexes(ctx context.Context, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error) {
+	return c.collection.Indexes().CreateMany(ctx, models, opts...)
+}
+
 func NewMongoPaginate(limit, page int) *mongoPaginate {
 	return &mongoPaginate{
 		limit: int64(limit),
@@ -0,0 +1,39 @@
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Aggregator runs aggregation pipelines on a collection.
+type Aggregator interface {
+	Aggregate(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (Cursor, error)
+}
+
+// DistinctAfter returns, in order, up to limit distinct values of a string
+// field that sort after the given value, so that callers can page through
+// all of them.
+func DistinctAfter(ctx context.Context, c Aggregator, field string, after string, limit int64) ([]string, error) {
+	curr, err := c.Aggregate(ctx, []bson.M{
+		{"$match": bson.M{field: bson.M{"$gt": after}}},
+		{"$group": bson.M{"_id": "$" + field}},
+		{"$sort": bson.M{"_id": 1}},
+		{"$limit": limit},
+	})
+	if err != nil {
+		return nil, WrapError(err)
+	}
+	var results []struct {
+		ID string `bson:"_id"`
+	}
+	if err := curr.All(ctx, &results); err != nil {
+		return nil, WrapError(err)
+	}
+	values := make([]string, 0, len(results))
+	for _, result := range results {
+		values = append(values, result.ID)
+	}
+	return values, nil
+}
@@ -0,0 +1,29 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"task-manager-api/internal/apperror"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// WrapError classifies a driver error into the shared error taxonomy.
+// Errors that are already classified are returned unchanged.
+func WrapError(err error) error {
+	var appErr *apperror.Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &appErr):
+		return err
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return apperror.ErrNotFound.Wrap(err)
+	case mongo.IsDuplicateKeyError(err):
+		return apperror.ErrConflict.Wrap(err)
+	case mongo.IsTimeout(err), mongo.IsNetworkError(err), errors.Is(err, context.DeadlineExceeded):
+		return apperror.ErrUnavailable.Wrap(err)
+	default:
+		return apperror.ErrInternal.Wrap(err)
+	}
+}
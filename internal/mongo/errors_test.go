@@ -0,0 +1,50 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"task-manager-api/internal/apperror"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ErrorsTestSuite struct {
+	suite.Suite
+}
+
+func TestErrorsTestSuite(t *testing.T) {
+	suite.Run(t, new(ErrorsTestSuite))
+}
+
+func (t *ErrorsTestSuite) TestWrapError() {
+	t.Run("nil should stay nil", func() {
+		t.NoError(WrapError(nil))
+	})
+
+	t.Run("no documents should be not found", func() {
+		t.ErrorIs(WrapError(mongo.ErrNoDocuments), apperror.ErrNotFound)
+	})
+
+	t.Run("duplicate key should be conflict", func() {
+		err := mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "E11000 duplicate key error"}}}
+		t.ErrorIs(WrapError(err), apperror.ErrConflict)
+	})
+
+	t.Run("deadline exceeded should be unavailable", func() {
+		t.ErrorIs(WrapError(context.DeadlineExceeded), apperror.ErrUnavailable)
+	})
+
+	t.Run("unknown error should be internal and keep the cause", func() {
+		cause := errors.New("something wrong")
+		err := WrapError(cause)
+		t.ErrorIs(err, apperror.ErrInternal)
+		t.ErrorIs(err, cause)
+	})
+
+	t.Run("classified error should be returned unchanged", func() {
+		notFound := apperror.NotFound("task_not_found", "Task not found")
+		t.Equal(notFound, WrapError(notFound))
+	})
+}
@@ -0,0 +1,203 @@
+package mongo
+
+import (
+	"encoding/base64"
+	"task-manager-api/internal/apperror"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrInvalidCursor = apperror.Validation("invalid_cursor", "Invalid cursor")
+
+// Pagination selects a page by number (skip/limit) or, when Page is zero,
+// by the opaque Cursor returned as PageInfo.NextCursor of the previous page.
+// An empty Cursor in keyset mode selects the first page.
+// SkipCount avoids counting matching documents on very large collections.
+type Pagination struct {
+	Page      int
+	Limit     int
+	Cursor    string
+	SkipCount bool
+}
+
+// PageInfo describes the returned page, Total is nil when counting was skipped.
+type PageInfo struct {
+	Total      *int64
+	HasNext    bool
+	NextCursor string
+}
+
+// cursorDoc is the content of an opaque cursor. Keys records the sort the
+// cursor was issued for so it cannot be replayed against another ordering.
+type cursorDoc struct {
+	Keys   []string `bson:"k"`
+	Values bson.A   `bson:"v"`
+}
+
+func (p Pagination) IsKeyset() bool {
+	return p.Page == 0
+}
+
+// Apply returns the query and find options for the page. One extra document is
+// fetched to detect a next page and in keyset mode the sort always ends with _id.
+func (p Pagination) Apply(query bson.M, sort bson.D) (bson.M, *options.FindOptions, error) {
+	if !p.IsKeyset() {
+		opts := NewMongoPaginate(p.Limit, p.Page).GetPaginatedOpts()
+		opts.SetLimit(int64(p.Limit) + 1)
+		if sort != nil {
+			opts.SetSort(sort)
+		}
+		return query, opts, nil
+	}
+
+	sort = keysetSort(sort)
+	opts := options.Find().SetSort(sort).SetLimit(int64(p.Limit) + 1)
+	if p.Cursor == "" {
+		return query, opts, nil
+	}
+
+	cursor, err := decodeCursor(p.Cursor, sort)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bson.M{"$and": []bson.M{query, keysetFilter(sort, cursor.Values)}}, opts, nil
+}
+
+// Paginate trims the extra document fetched by Apply and, in keyset mode, builds
+// the next cursor from the last document of the page.
+func Paginate[T any](p Pagination, sort bson.D, docs []T) ([]T, *PageInfo, error) {
+	info := new(PageInfo)
+	if len(docs) <= p.Limit {
+		return docs, info, nil
+	}
+
+	docs = docs[:p.Limit]
+	info.HasNext = true
+	if !p.IsKeyset() {
+		return docs, info, nil
+	}
+	cursor, err := encodeCursor(keysetSort(sort), docs[len(docs)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	info.NextCursor = cursor
+	return docs, info, nil
+}
+
+func keysetSort(sort bson.D) bson.D {
+	if len(sort) == 0 {
+		return bson.D{{Key: "_id", Value: 1}}
+	}
+	if sort[len(sort)-1].Key != "_id" {
+		return append(sort[:len(sort):len(sort)], bson.E{Key: "_id", Value: sort[len(sort)-1].Value})
+	}
+	return sort
+}
+
+func sortKeys(sort bson.D) []string {
+	keys := make([]string, 0, len(sort))
+	for _, e := range sort {
+		if isDesc(e) {
+			keys = append(keys, "-"+e.Key)
+		} else {
+			keys = append(keys, e.Key)
+		}
+	}
+	return keys
+}
+
+func isDesc(e bson.E) bool {
+	dir, _ := e.Value.(int)
+	return dir < 0
+}
+
+func encodeCursor(sort bson.D, last interface{}) (string, error) {
+	raw, err := bson.Marshal(last)
+	if err != nil {
+		return "", err
+	}
+	values := make(bson.A, 0, len(sort))
+	for _, e := range sort {
+		v, err := bson.Raw(raw).LookupErr(e.Key)
+		if err != nil || v.Type == bsontype.Null {
+			values = append(values, nil)
+			continue
+		}
+		// documents keep _id as a hex string, the collection stores an ObjectID
+		if e.Key == "_id" && v.Type == bsontype.String {
+			oid, err := primitive.ObjectIDFromHex(v.StringValue())
+			if err != nil {
+				return "", err
+			}
+			values = append(values, oid)
+			continue
+		}
+		values = append(values, v)
+	}
+
+	b, err := bson.Marshal(cursorDoc{Keys: sortKeys(sort), Values: values})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(cursor string, sort bson.D) (*cursorDoc, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor.Wrap(err)
+	}
+	doc := new(cursorDoc)
+	if err := bson.Unmarshal(b, doc); err != nil {
+		return nil, ErrInvalidCursor.Wrap(err)
+	}
+
+	keys := sortKeys(sort)
+	if len(doc.Keys) != len(keys) || len(doc.Values) != len(keys) {
+		return nil, ErrInvalidCursor
+	}
+	for i := range keys {
+		if doc.Keys[i] != keys[i] {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return doc, nil
+}
+
+// keysetFilter matches documents strictly after the cursor values in sort order:
+// (k1 > v1) or (k1 = v1 and k2 > v2) or ...
+// Nulls sort first ascending and last descending, like MongoDB does.
+func keysetFilter(sort bson.D, values bson.A) bson.M {
+	branches := make([]bson.M, 0, len(sort))
+	for i, e := range sort {
+		after := afterValue(values[i], isDesc(e))
+		if after == nil {
+			continue
+		}
+		branch := bson.M{}
+		for j := 0; j < i; j++ {
+			branch[sort[j].Key] = values[j]
+		}
+		branch[e.Key] = after
+		branches = append(branches, branch)
+	}
+	return bson.M{"$or": branches}
+}
+
+func afterValue(v interface{}, desc bool) interface{} {
+	switch {
+	case v == nil && desc:
+		// nothing sorts after null in descending order
+		return nil
+	case v == nil:
+		return bson.M{"$ne": nil}
+	case desc:
+		// "$lt" alone would skip null values that come last
+		return bson.M{"$not": bson.M{"$gte": v}}
+	default:
+		return bson.M{"$gt": v}
+	}
+}
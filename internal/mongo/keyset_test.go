@@ -0,0 +1,138 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type keysetDoc struct {
+	ID         string `bson:"_id,omitempty"`
+	CreateDate int64  `bson:"create_date"`
+	UpdateDate *int64 `bson:"update_date"`
+}
+
+type KeysetTestSuite struct {
+	suite.Suite
+}
+
+func TestKeysetTestSuite(t *testing.T) {
+	suite.Run(t, new(KeysetTestSuite))
+}
+
+func (t *KeysetTestSuite) TestApplyPageMode() {
+	query := bson.M{"owner_id": "1234"}
+	sort := bson.D{{Key: "create_date", Value: -1}, {Key: "_id", Value: -1}}
+
+	q, opts, err := Pagination{Page: 2, Limit: 10}.Apply(query, sort)
+	t.NoError(err)
+	t.Equal(query, q)
+	l, skip := int64(11), int64(10)
+	t.Equal((&options.FindOptions{Limit: &l, Skip: &skip}).SetSort(sort), opts)
+}
+
+func (t *KeysetTestSuite) TestApplyKeysetMode() {
+	query := bson.M{"owner_id": "1234"}
+
+	t.Run("first page should sort by _id and fetch one extra document", func() {
+		q, opts, err := Pagination{Limit: 10}.Apply(query, nil)
+		t.NoError(err)
+		t.Equal(query, q)
+		t.Equal(int64(11), *opts.Limit)
+		t.Nil(opts.Skip)
+		t.Equal(bson.D{{Key: "_id", Value: 1}}, opts.Sort)
+	})
+
+	t.Run("invalid cursor should return error", func() {
+		_, _, err := Pagination{Limit: 10, Cursor: "not a cursor"}.Apply(query, nil)
+		t.ErrorIs(err, ErrInvalidCursor)
+	})
+
+	t.Run("cursor issued for another sort should return error", func() {
+		cursor, err := encodeCursor(bson.D{{Key: "_id", Value: 1}}, keysetDoc{ID: "6041c3a6cfcba2fb9c4a4fd2"})
+		t.Require().NoError(err)
+		_, _, err = Pagination{Limit: 10, Cursor: cursor}.Apply(query, bson.D{{Key: "create_date", Value: 1}})
+		t.ErrorIs(err, ErrInvalidCursor)
+	})
+
+	t.Run("cursor should restrict query to documents after the last one", func() {
+		oid, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+		sort := bson.D{{Key: "create_date", Value: 1}}
+		cursor, err := encodeCursor(keysetSort(sort), keysetDoc{ID: oid.Hex(), CreateDate: 1614962551})
+		t.Require().NoError(err)
+
+		q, _, err := Pagination{Limit: 10, Cursor: cursor}.Apply(query, sort)
+		t.NoError(err)
+		t.Equal(bson.M{"$and": []bson.M{query, {
+			"$or": []bson.M{
+				{"create_date": bson.M{"$gt": int64(1614962551)}},
+				{"create_date": int64(1614962551), "_id": bson.M{"$gt": oid}},
+			},
+		}}}, q)
+	})
+}
+
+func (t *KeysetTestSuite) TestKeysetFilterNulls() {
+	oid, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+
+	t.Run("null ascending should match every non null value", func() {
+		sort := bson.D{{Key: "update_date", Value: 1}, {Key: "_id", Value: 1}}
+		t.Equal(bson.M{"$or": []bson.M{
+			{"update_date": bson.M{"$ne": nil}},
+			{"update_date": nil, "_id": bson.M{"$gt": oid}},
+		}}, keysetFilter(sort, bson.A{nil, oid}))
+	})
+
+	t.Run("null descending should only continue within nulls", func() {
+		sort := bson.D{{Key: "update_date", Value: -1}, {Key: "_id", Value: -1}}
+		t.Equal(bson.M{"$or": []bson.M{
+			{"update_date": nil, "_id": bson.M{"$not": bson.M{"$gte": oid}}},
+		}}, keysetFilter(sort, bson.A{nil, oid}))
+	})
+}
+
+func (t *KeysetTestSuite) TestPaginate() {
+	docs := []keysetDoc{
+		{ID: "6041c3a6cfcba2fb9c4a4fd1", CreateDate: 1},
+		{ID: "6041c3a6cfcba2fb9c4a4fd2", CreateDate: 2},
+		{ID: "6041c3a6cfcba2fb9c4a4fd3", CreateDate: 3},
+	}
+
+	t.Run("page mode should trim extra document without cursor", func() {
+		page, info, err := Paginate(Pagination{Page: 1, Limit: 2}, nil, docs)
+		t.NoError(err)
+		t.Len(page, 2)
+		t.Equal(&PageInfo{HasNext: true}, info)
+	})
+
+	t.Run("last page mode page should have no next page", func() {
+		page, info, err := Paginate(Pagination{Page: 2, Limit: 3}, nil, docs)
+		t.NoError(err)
+		t.Len(page, 3)
+		t.Equal(&PageInfo{}, info)
+	})
+
+	t.Run("last keyset page should have no next cursor", func() {
+		page, info, err := Paginate(Pagination{Limit: 3}, nil, docs)
+		t.NoError(err)
+		t.Len(page, 3)
+		t.False(info.HasNext)
+		t.Empty(info.NextCursor)
+	})
+
+	t.Run("keyset page with extra document should return next cursor", func() {
+		sort := bson.D{{Key: "create_date", Value: 1}}
+		page, info, err := Paginate(Pagination{Limit: 2}, sort, docs)
+		t.NoError(err)
+		t.Len(page, 2)
+		t.True(info.HasNext)
+
+		cursor, err := decodeCursor(info.NextCursor, keysetSort(sort))
+		t.NoError(err)
+		oid, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+		t.Equal(bson.A{int64(2), oid}, cursor.Values)
+	})
+}
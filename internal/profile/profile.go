@@ -3,6 +3,7 @@ package profile
 import (
 	"context"
 	"errors"
+	"task-manager-api/internal/apperror"
 	iMongo "task-manager-api/internal/mongo"
 	m "task-manager-api/internal/mongo"
 	"time"
@@ -18,6 +19,8 @@ type IMongo interface {
 	Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (m.Cursor, error)
 }
 
+var ErrProfileNotFound = apperror.NotFound("profile_not_found", "Profile not found")
+
 type ProfileDoc struct {
 	OwnerId     string `json:"owner_id" bson:"owner_id"`
 	DisplayName string `json:"display_name" bson:"display_name"`
@@ -36,14 +39,16 @@ func NewProfileService(mongo IMongo) *Profile {
 	return &Profile{mongo: mongo}
 }
 
+// GetProfile returns the profile of an owner, ErrProfileNotFound when there
+// is none.
 func (p *Profile) GetProfile(ctx context.Context, ownerId string) (*ProfileDoc, error) {
 	result := p.mongo.FindOne(ctx, bson.M{"owner_id": ownerId})
 	profile := new(ProfileDoc)
 	if err := result.Decode(profile); err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, nil
+			return nil, ErrProfileNotFound.Wrap(err)
 		}
-		return nil, err
+		return nil, m.WrapError(err)
 	}
 	return profile, nil
 }
@@ -52,12 +57,25 @@ func (p *Profile) GetProfileList(ctx context.Context, ownerId []string) ([]Profi
 	curr, err := p.mongo.Find(ctx, bson.M{"owner_id": bson.M{"$in": ownerId}})
 
 	if err != nil {
-		// TODO: log error
-		return nil, err
+		return nil, m.WrapError(err)
+	}
+	var profiles = make([]ProfileDoc, 0)
+	if err := curr.All(ctx, &profiles); err != nil {
+		return nil, m.WrapError(err)
+	}
+	return profiles, nil
+}
+
+// GetProfilesByDisplayName returns the profiles with one of the display
+// names, several profiles may share a name.
+func (p *Profile) GetProfilesByDisplayName(ctx context.Context, names []string) ([]ProfileDoc, error) {
+	curr, err := p.mongo.Find(ctx, bson.M{"display_name": bson.M{"$in": names}})
+	if err != nil {
+		return nil, m.WrapError(err)
 	}
 	var profiles = make([]ProfileDoc, 0)
 	if err := curr.All(ctx, &profiles); err != nil {
-		return nil, err
+		return nil, m.WrapError(err)
 	}
 	return profiles, nil
 }
@@ -61,13 +61,13 @@ func (t *ProfileTestSuite) TestGetProfile() {
 		t.EqualError(err, "decode error")
 	})
 
-	t.Run("get profile but error no document should return nil", func() {
+	t.Run("get profile but error no document should return not found", func() {
 		t.singleResult.EXPECT().Decode(gomock.Any()).Return(mongo.ErrNoDocuments)
 		t.mockMongo.EXPECT().FindOne(context.Background(), bson.M{
 			"owner_id": "user_id",
 		}).Return(t.singleResult)
 		profile, err := t.service.GetProfile(context.Background(), "user_id")
-		t.Nil(err)
+		t.ErrorIs(err, ErrProfileNotFound)
 		t.Nil(profile)
 	})
 	t.Run("get profile success", func() {
@@ -163,3 +163,24 @@ func (t *ProfileTestSuite) TestGetProgileList() {
 	})
 
 }
+
+func (t *ProfileTestSuite) TestGetProfilesByDisplayName() {
+	t.Run("get profiles by display name but find has error should return error", func() {
+		t.mockMongo.EXPECT().Find(context.Background(), bson.M{
+			"display_name": bson.M{"$in": []string{"Jane Doe"}},
+		}).Return(nil, errors.New("find error"))
+		profiles, err := t.service.GetProfilesByDisplayName(context.Background(), []string{"Jane Doe"})
+		t.Nil(profiles)
+		t.EqualError(err, "find error")
+	})
+
+	t.Run("get profiles by display name success", func() {
+		t.mockMongo.EXPECT().Find(context.Background(), bson.M{
+			"display_name": bson.M{"$in": []string{"Jane Doe"}},
+		}).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).SetArg(1, []ProfileDoc{{OwnerId: "5678", DisplayName: "Jane Doe"}}).Return(nil)
+		profiles, err := t.service.GetProfilesByDisplayName(context.Background(), []string{"Jane Doe"})
+		t.NoError(err)
+		t.Equal([]ProfileDoc{{OwnerId: "5678", DisplayName: "Jane Doe"}}, profiles)
+	})
+}
@@ -0,0 +1,216 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./reaction.go
+
+// Package mock_reaction is a generated GoMock package.
+package mock_reaction
+
+import (
+	context "context"
+	reflect "reflect"
+	comment "task-manager-api/internal/comment"
+	mongo0 "task-manager-api/internal/mongo"
+	taskmanager "task-manager-api/internal/taskmanager"
+
+	gomock "github.com/golang/mock/gomock"
+	mongo "go.mongodb.org/mongo-driver/mongo"
+	options "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MockIMongo is a mock of IMongo interface.
+type MockIMongo struct {
+	ctrl     *gomock.Controller
+	recorder *MockIMongoMockRecorder
+}
+
+// MockIMongoMockRecorder is the mock recorder for MockIMongo.
+type MockIMongoMockRecorder struct {
+	mock *MockIMongo
+}
+
+// NewMockIMongo creates a new mock instance.
+func NewMockIMongo(ctrl *gomock.Controller) *MockIMongo {
+	mock := &MockIMongo{ctrl: ctrl}
+	mock.recorder = &MockIMongoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIMongo) EXPECT() *MockIMongoMockRecorder {
+	return m.recorder
+}
+
+// Aggregate mocks base method.
+func (m *MockIMongo) Aggregate(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (mongo0.Cursor, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, pipeline}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Aggregate", varargs...)
+	ret0, _ := ret[0].(mongo0.Cursor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Aggregate indicates an expected call of Aggregate.
+func (mr *MockIMongoMockRecorder) Aggregate(ctx, pipeline interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, pipeline}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Aggregate", reflect.TypeOf((*MockIMongo)(nil).Aggregate), varargs...)
+}
+
+// CreateIndexes mocks base method.
+func (m *MockIMongo) CreateIndexes(ctx context.Context, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, models}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CreateIndexes", varargs...)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIndexes indicates an expected call of CreateIndexes.
+func (mr *MockIMongoMockRecorder) CreateIndexes(ctx, models interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, models}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIndexes", reflect.TypeOf((*MockIMongo)(nil).CreateIndexes), varargs...)
+}
+
+// DeleteMany mocks base method.
+func (m *MockIMongo) DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, filter}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteMany", varargs...)
+	ret0, _ := ret[0].(*mongo.DeleteResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteMany indicates an expected call of DeleteMany.
+func (mr *MockIMongoMockRecorder) DeleteMany(ctx, filter interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, filter}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMany", reflect.TypeOf((*MockIMongo)(nil).DeleteMany), varargs...)
+}
+
+// DeleteOne mocks base method.
+func (m *MockIMongo) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, filter}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteOne", varargs...)
+	ret0, _ := ret[0].(*mongo.DeleteResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteOne indicates an expected call of DeleteOne.
+func (mr *MockIMongoMockRecorder) DeleteOne(ctx, filter interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, filter}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOne", reflect.TypeOf((*MockIMongo)(nil).DeleteOne), varargs...)
+}
+
+// InsertOne mocks base method.
+func (m *MockIMongo) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, document}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "InsertOne", varargs...)
+	ret0, _ := ret[0].(*mongo.InsertOneResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertOne indicates an expected call of InsertOne.
+func (mr *MockIMongoMockRecorder) InsertOne(ctx, document interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, document}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertOne", reflect.TypeOf((*MockIMongo)(nil).InsertOne), varargs...)
+}
+
+// MockITaskLookup is a mock of ITaskLookup interface.
+type MockITaskLookup struct {
+	ctrl     *gomock.Controller
+	recorder *MockITaskLookupMockRecorder
+}
+
+// MockITaskLookupMockRecorder is the mock recorder for MockITaskLookup.
+type MockITaskLookupMockRecorder struct {
+	mock *MockITaskLookup
+}
+
+// NewMockITaskLookup creates a new mock instance.
+func NewMockITaskLookup(ctrl *gomock.Controller) *MockITaskLookup {
+	mock := &MockITaskLookup{ctrl: ctrl}
+	mock.recorder = &MockITaskLookupMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockITaskLookup) EXPECT() *MockITaskLookupMockRecorder {
+	return m.recorder
+}
+
+// FindTask mocks base method.
+func (m *MockITaskLookup) FindTask(ctx context.Context, id string) (*taskmanager.TaskDoc, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindTask", ctx, id)
+	ret0, _ := ret[0].(*taskmanager.TaskDoc)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindTask indicates an expected call of FindTask.
+func (mr *MockITaskLookupMockRecorder) FindTask(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindTask", reflect.TypeOf((*MockITaskLookup)(nil).FindTask), ctx, id)
+}
+
+// MockICommentLookup is a mock of ICommentLookup interface.
+type MockICommentLookup struct {
+	ctrl     *gomock.Controller
+	recorder *MockICommentLookupMockRecorder
+}
+
+// MockICommentLookupMockRecorder is the mock recorder for MockICommentLookup.
+type MockICommentLookupMockRecorder struct {
+	mock *MockICommentLookup
+}
+
+// NewMockICommentLookup creates a new mock instance.
+func NewMockICommentLookup(ctrl *gomock.Controller) *MockICommentLookup {
+	mock := &MockICommentLookup{ctrl: ctrl}
+	mock.recorder = &MockICommentLookupMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockICommentLookup) EXPECT() *MockICommentLookupMockRecorder {
+	return m.recorder
+}
+
+// FindComment mocks base method.
+func (m *MockICommentLookup) FindComment(ctx context.Context, taskId, id string) (*comment.CommentDoc, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindComment", ctx, taskId, id)
+	ret0, _ := ret[0].(*comment.CommentDoc)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindComment indicates an expected call of FindComment.
+func (mr *MockICommentLookupMockRecorder) FindComment(ctx, taskId, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindComment", reflect.TypeOf((*MockICommentLookup)(nil).FindComment), ctx, taskId, id)
+}
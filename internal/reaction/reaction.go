@@ -0,0 +1,258 @@
+package reaction
+
+import (
+	"context"
+	"task-manager-api/internal/apperror"
+	"task-manager-api/internal/comment"
+	m "task-manager-api/internal/mongo"
+	"task-manager-api/internal/taskmanager"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//go:generate mockgen -source=./reaction.go -destination=./mock/reaction.go
+type IMongo interface {
+	InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error)
+	DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
+	DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
+	Aggregate(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (m.Cursor, error)
+	CreateIndexes(ctx context.Context, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error)
+}
+
+type ITaskLookup interface {
+	FindTask(ctx context.Context, id string) (*taskmanager.TaskDoc, error)
+}
+
+type ICommentLookup interface {
+	FindComment(ctx context.Context, taskId string, id string) (*comment.CommentDoc, error)
+}
+
+var (
+	ErrInvalidEmoji   = apperror.Validation("invalid_emoji", "Invalid emoji")
+	ErrAlreadyReacted = apperror.Conflict("already_reacted", "Already reacted with this emoji")
+)
+
+// ReactionDoc is an emoji left by OwnerID on a task, or on a comment of the
+// task when CommentID is set. CommentID is stored empty for tasks so that the
+// unique index also covers reactions on tasks.
+type ReactionDoc struct {
+	ID         string `json:"id" bson:"_id,omitempty"`
+	TaskID     string `json:"task_id" bson:"task_id"`
+	CommentID  string `json:"comment_id" bson:"comment_id"`
+	OwnerID    string `json:"owner_id" bson:"owner_id"`
+	Emoji      string `json:"emoji" bson:"emoji"`
+	CreateDate int64  `json:"create_date" bson:"create_date"`
+}
+
+// Summary counts the reactions with an emoji, ReactedByMe tells whether
+// the viewer is one of them.
+type Summary struct {
+	Emoji       string `json:"emoji"`
+	Count       int64  `json:"count"`
+	ReactedByMe bool   `json:"reacted_by_me"`
+}
+
+type Reactions struct {
+	mongo    IMongo
+	tasks    ITaskLookup
+	comments ICommentLookup
+	emojis   []string
+	time     func() time.Time
+}
+
+// NewReactionService creates a service accepting the configured emojis,
+// summaries list them in that order.
+func NewReactionService(mongo IMongo, tasks ITaskLookup, comments ICommentLookup, emojis []string) *Reactions {
+	return &Reactions{mongo: mongo, tasks: tasks, comments: comments, emojis: emojis}
+}
+
+// EnsureIndexes creates the unique index allowing one reaction per person
+// and emoji, it also backs the summaries by task and comment.
+func (r *Reactions) EnsureIndexes(ctx context.Context) error {
+	models := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "task_id", Value: 1},
+				{Key: "comment_id", Value: 1},
+				{Key: "emoji", Value: 1},
+				{Key: "owner_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	if _, err := r.mongo.CreateIndexes(ctx, models); err != nil {
+		return m.WrapError(err)
+	}
+	return nil
+}
+
+// AddReaction reacts to a task, or to one of its comments when commentId is
+// set. Archived tasks and deleted comments take no reactions.
+func (r *Reactions) AddReaction(ctx context.Context, ownerId string, taskId string, commentId string, emoji string) (*ReactionDoc, error) {
+	if !r.valid(emoji) {
+		return nil, ErrInvalidEmoji
+	}
+	task, err := r.tasks.FindTask(ctx, taskId)
+	if err != nil {
+		return nil, err
+	}
+	if task.ArchiveDate != nil {
+		return nil, comment.ErrTaskArchived
+	}
+	if commentId != "" {
+		c, err := r.comments.FindComment(ctx, task.ID, commentId)
+		if err != nil {
+			return nil, err
+		}
+		if c.DeleteDate != nil {
+			return nil, comment.ErrCommentNotFound
+		}
+		commentId = c.ID
+	}
+	doc := ReactionDoc{
+		TaskID:     task.ID,
+		CommentID:  commentId,
+		OwnerID:    ownerId,
+		Emoji:      emoji,
+		CreateDate: r.now().Unix(),
+	}
+	result, err := r.mongo.InsertOne(ctx, doc)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrAlreadyReacted.Wrap(err)
+		}
+		return nil, m.WrapError(err)
+	}
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		doc.ID = oid.Hex()
+	}
+	return &doc, nil
+}
+
+// RemoveReaction takes back a reaction of the owner, it returns the number
+// of reactions removed.
+func (r *Reactions) RemoveReaction(ctx context.Context, ownerId string, taskId string, commentId string, emoji string) (int, error) {
+	if !r.valid(emoji) {
+		return 0, ErrInvalidEmoji
+	}
+	result, err := r.mongo.DeleteOne(ctx, bson.M{
+		"task_id":    taskId,
+		"comment_id": commentId,
+		"emoji":      emoji,
+		"owner_id":   ownerId,
+	})
+	if err != nil {
+		return 0, m.WrapError(err)
+	}
+	return int(result.DeletedCount), nil
+}
+
+// TaskSummary counts the reactions on a task, viewer may be empty.
+func (r *Reactions) TaskSummary(ctx context.Context, taskId string, viewer string) ([]Summary, error) {
+	summaries, err := r.summaries(ctx, bson.M{
+		"task_id":    taskId,
+		"comment_id": "",
+	}, "$task_id", viewer)
+	if err != nil {
+		return nil, err
+	}
+	return summaries[taskId], nil
+}
+
+// CommentSummaries counts the reactions on comments of a task by comment id.
+func (r *Reactions) CommentSummaries(ctx context.Context, taskId string, commentIds []string, viewer string) (map[string][]Summary, error) {
+	if len(commentIds) == 0 {
+		return map[string][]Summary{}, nil
+	}
+	return r.summaries(ctx, bson.M{
+		"task_id":    taskId,
+		"comment_id": bson.M{"$in": commentIds},
+	}, "$comment_id", viewer)
+}
+
+// DeleteTaskReactions removes the reactions on deleted tasks and their comments.
+func (r *Reactions) DeleteTaskReactions(ctx context.Context, taskIds []string) (int64, error) {
+	result, err := r.mongo.DeleteMany(ctx, bson.M{
+		"task_id": bson.M{"$in": taskIds},
+	})
+	if err != nil {
+		return 0, m.WrapError(err)
+	}
+	return result.DeletedCount, nil
+}
+
+// TaskIDs returns, in order, up to limit ids of the tasks with reactions that
+// sort after the given id.
+func (r *Reactions) TaskIDs(ctx context.Context, after string, limit int64) ([]string, error) {
+	return m.DistinctAfter(ctx, r.mongo, "task_id", after, limit)
+}
+
+func (r *Reactions) summaries(ctx context.Context, match bson.M, target string, viewer string) (map[string][]Summary, error) {
+	curr, err := r.mongo.Aggregate(ctx, []bson.M{
+		{"$match": match},
+		{"$group": bson.M{
+			"_id":   bson.M{"target": target, "emoji": "$emoji"},
+			"count": bson.M{"$sum": 1},
+			"mine": bson.M{"$max": bson.M{
+				"$eq": bson.A{"$owner_id", viewer},
+			}},
+		}},
+	})
+	if err != nil {
+		return nil, m.WrapError(err)
+	}
+	var results []struct {
+		ID struct {
+			Target string `bson:"target"`
+			Emoji  string `bson:"emoji"`
+		} `bson:"_id"`
+		Count int64 `bson:"count"`
+		Mine  bool  `bson:"mine"`
+	}
+	if err := curr.All(ctx, &results); err != nil {
+		return nil, m.WrapError(err)
+	}
+
+	counts := make(map[string]map[string]Summary)
+	for _, result := range results {
+		if counts[result.ID.Target] == nil {
+			counts[result.ID.Target] = make(map[string]Summary)
+		}
+		counts[result.ID.Target][result.ID.Emoji] = Summary{
+			Emoji:       result.ID.Emoji,
+			Count:       result.Count,
+			ReactedByMe: viewer != "" && result.Mine,
+		}
+	}
+	// emojis removed from the configuration are no longer shown
+	summaries := make(map[string][]Summary, len(counts))
+	for target, byEmoji := range counts {
+		for _, emoji := range r.emojis {
+			if summary, ok := byEmoji[emoji]; ok {
+				summaries[target] = append(summaries[target], summary)
+			}
+		}
+	}
+	return summaries, nil
+}
+
+func (r *Reactions) valid(emoji string) bool {
+	for _, e := range r.emojis {
+		if e == emoji {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reactions) now() time.Time {
+	if r.time == nil {
+		return time.Now()
+	}
+
+	return r.time()
+}
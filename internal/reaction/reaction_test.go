@@ -0,0 +1,200 @@
+package reaction
+
+import (
+	"context"
+	"errors"
+	"task-manager-api/internal/comment"
+	mock "task-manager-api/internal/mongo/mock"
+	mock_reaction "task-manager-api/internal/reaction/mock"
+	"task-manager-api/internal/taskmanager"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ReactionTestSuite struct {
+	suite.Suite
+	ctrl      *gomock.Controller
+	mockMongo *mock_reaction.MockIMongo
+	tasks     *mock_reaction.MockITaskLookup
+	comments  *mock_reaction.MockICommentLookup
+	service   *Reactions
+	cursor    *mock.MockCursor
+}
+
+func (t *ReactionTestSuite) SetupTest() {
+	t.ctrl = gomock.NewController(t.T())
+	t.mockMongo = mock_reaction.NewMockIMongo(t.ctrl)
+	t.tasks = mock_reaction.NewMockITaskLookup(t.ctrl)
+	t.comments = mock_reaction.NewMockICommentLookup(t.ctrl)
+	t.service = NewReactionService(t.mockMongo, t.tasks, t.comments, []string{"thumbs_up", "heart", "tada"})
+	t.cursor = mock.NewMockCursor(t.ctrl)
+	t.service.time = func() time.Time {
+		loc, _ := time.LoadLocation("Asia/Bangkok")
+		return time.Date(2019, 9, 22, 12, 42, 31, 0, loc)
+	}
+}
+
+func (t *ReactionTestSuite) TearDownTest() {
+	t.ctrl.Finish()
+	t.mockMongo = nil
+	t.tasks = nil
+	t.comments = nil
+	t.service = nil
+	t.cursor = nil
+}
+
+func TestReactionTestSuite(t *testing.T) {
+	suite.Run(t, new(ReactionTestSuite))
+}
+
+func (t *ReactionTestSuite) TestAddReaction() {
+	task := &taskmanager.TaskDoc{ID: "645b9183fcfbc11433e23ab3"}
+
+	t.Run("add reaction with unknown emoji should return error", func() {
+		_, err := t.service.AddReaction(context.Background(), "5678", "645b9183fcfbc11433e23ab3", "", "rocket")
+		t.ErrorIs(err, ErrInvalidEmoji)
+	})
+
+	t.Run("add reaction to task should insert the reaction", func() {
+		oid, _ := primitive.ObjectIDFromHex("645b9183fcfbc11433e23ab9")
+		t.tasks.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		t.mockMongo.EXPECT().InsertOne(context.Background(), ReactionDoc{
+			TaskID:     "645b9183fcfbc11433e23ab3",
+			OwnerID:    "5678",
+			Emoji:      "heart",
+			CreateDate: 1569130951,
+		}).Return(&mongo.InsertOneResult{InsertedID: oid}, nil)
+		reaction, err := t.service.AddReaction(context.Background(), "5678", "645b9183fcfbc11433e23ab3", "", "heart")
+		t.NoError(err)
+		t.Equal("645b9183fcfbc11433e23ab9", reaction.ID)
+	})
+
+	t.Run("add reaction to archived task should return error", func() {
+		archived := int64(1569130000)
+		t.tasks.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(&taskmanager.TaskDoc{ID: "645b9183fcfbc11433e23ab3", ArchiveDate: &archived}, nil)
+		_, err := t.service.AddReaction(context.Background(), "5678", "645b9183fcfbc11433e23ab3", "", "heart")
+		t.ErrorIs(err, comment.ErrTaskArchived)
+	})
+
+	t.Run("add reaction to comment should insert the comment id", func() {
+		t.tasks.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		t.comments.EXPECT().FindComment(context.Background(), "645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab5").Return(&comment.CommentDoc{ID: "645b9183fcfbc11433e23ab5"}, nil)
+		t.mockMongo.EXPECT().InsertOne(context.Background(), ReactionDoc{
+			TaskID:     "645b9183fcfbc11433e23ab3",
+			CommentID:  "645b9183fcfbc11433e23ab5",
+			OwnerID:    "5678",
+			Emoji:      "tada",
+			CreateDate: 1569130951,
+		}).Return(&mongo.InsertOneResult{}, nil)
+		_, err := t.service.AddReaction(context.Background(), "5678", "645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab5", "tada")
+		t.NoError(err)
+	})
+
+	t.Run("add reaction to deleted comment should return not found", func() {
+		deleted := int64(1569130000)
+		t.tasks.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		t.comments.EXPECT().FindComment(context.Background(), "645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab5").Return(&comment.CommentDoc{ID: "645b9183fcfbc11433e23ab5", DeleteDate: &deleted}, nil)
+		_, err := t.service.AddReaction(context.Background(), "5678", "645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab5", "tada")
+		t.ErrorIs(err, comment.ErrCommentNotFound)
+	})
+
+	t.Run("add reaction twice should return already reacted", func() {
+		t.tasks.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		t.mockMongo.EXPECT().InsertOne(context.Background(), gomock.Any()).Return(nil, mongo.WriteException{
+			WriteErrors: []mongo.WriteError{{Code: 11000}},
+		})
+		_, err := t.service.AddReaction(context.Background(), "5678", "645b9183fcfbc11433e23ab3", "", "heart")
+		t.ErrorIs(err, ErrAlreadyReacted)
+	})
+
+	t.Run("add reaction but insert got error should return error", func() {
+		t.tasks.EXPECT().FindTask(context.Background(), "645b9183fcfbc11433e23ab3").Return(task, nil)
+		t.mockMongo.EXPECT().InsertOne(context.Background(), gomock.Any()).Return(nil, errors.New("insert error"))
+		_, err := t.service.AddReaction(context.Background(), "5678", "645b9183fcfbc11433e23ab3", "", "heart")
+		t.EqualError(err, "insert error")
+	})
+}
+
+func (t *ReactionTestSuite) TestRemoveReaction() {
+	t.Run("remove reaction should only delete the reaction of the owner", func() {
+		t.mockMongo.EXPECT().DeleteOne(context.Background(), bson.M{
+			"task_id":    "645b9183fcfbc11433e23ab3",
+			"comment_id": "",
+			"emoji":      "heart",
+			"owner_id":   "5678",
+		}).Return(&mongo.DeleteResult{DeletedCount: 1}, nil)
+		deleted, err := t.service.RemoveReaction(context.Background(), "5678", "645b9183fcfbc11433e23ab3", "", "heart")
+		t.NoError(err)
+		t.Equal(1, deleted)
+	})
+
+	t.Run("remove reaction with unknown emoji should return error", func() {
+		_, err := t.service.RemoveReaction(context.Background(), "5678", "645b9183fcfbc11433e23ab3", "", "rocket")
+		t.ErrorIs(err, ErrInvalidEmoji)
+	})
+}
+
+func (t *ReactionTestSuite) TestSummaries() {
+	t.Run("task summary should follow the configured order", func() {
+		t.mockMongo.EXPECT().Aggregate(context.Background(), []bson.M{
+			{"$match": bson.M{
+				"task_id":    "645b9183fcfbc11433e23ab3",
+				"comment_id": "",
+			}},
+			{"$group": bson.M{
+				"_id":   bson.M{"target": "$task_id", "emoji": "$emoji"},
+				"count": bson.M{"$sum": 1},
+				"mine": bson.M{"$max": bson.M{
+					"$eq": bson.A{"$owner_id", "5678"},
+				}},
+			}},
+		}).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, result interface{}) error {
+			return bson.UnmarshalExtJSON([]byte(`[
+				{"_id":{"target":"645b9183fcfbc11433e23ab3","emoji":"tada"},"count":1,"mine":false},
+				{"_id":{"target":"645b9183fcfbc11433e23ab3","emoji":"retired"},"count":4,"mine":false},
+				{"_id":{"target":"645b9183fcfbc11433e23ab3","emoji":"thumbs_up"},"count":2,"mine":true}
+			]`), false, result)
+		})
+		summaries, err := t.service.TaskSummary(context.Background(), "645b9183fcfbc11433e23ab3", "5678")
+		t.NoError(err)
+		t.Equal([]Summary{
+			{Emoji: "thumbs_up", Count: 2, ReactedByMe: true},
+			{Emoji: "tada", Count: 1},
+		}, summaries)
+	})
+
+	t.Run("comment summaries should group by comment", func() {
+		t.mockMongo.EXPECT().Aggregate(context.Background(), gomock.Any()).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, result interface{}) error {
+			return bson.UnmarshalExtJSON([]byte(`[
+				{"_id":{"target":"645b9183fcfbc11433e23ab5","emoji":"heart"},"count":1,"mine":false},
+				{"_id":{"target":"645b9183fcfbc11433e23ab6","emoji":"heart"},"count":3,"mine":false}
+			]`), false, result)
+		})
+		summaries, err := t.service.CommentSummaries(context.Background(), "645b9183fcfbc11433e23ab3", []string{"645b9183fcfbc11433e23ab5", "645b9183fcfbc11433e23ab6"}, "")
+		t.NoError(err)
+		t.Equal(map[string][]Summary{
+			"645b9183fcfbc11433e23ab5": {{Emoji: "heart", Count: 1}},
+			"645b9183fcfbc11433e23ab6": {{Emoji: "heart", Count: 3}},
+		}, summaries)
+	})
+
+	t.Run("comment summaries without comments should not query", func() {
+		summaries, err := t.service.CommentSummaries(context.Background(), "645b9183fcfbc11433e23ab3", nil, "5678")
+		t.NoError(err)
+		t.Empty(summaries)
+	})
+
+	t.Run("task summary but aggregate got error should return error", func() {
+		t.mockMongo.EXPECT().Aggregate(context.Background(), gomock.Any()).Return(nil, errors.New("aggregate error"))
+		_, err := t.service.TaskSummary(context.Background(), "645b9183fcfbc11433e23ab3", "")
+		t.EqualError(err, "aggregate error")
+	})
+}
@@ -0,0 +1,201 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./recurrence.go
+
+// Package mock_recurrence is a generated GoMock package.
+package mock_recurrence
+
+import (
+	context "context"
+	reflect "reflect"
+	mongo0 "task-manager-api/internal/mongo"
+	taskmanager "task-manager-api/internal/taskmanager"
+
+	gomock "github.com/golang/mock/gomock"
+	mongo "go.mongodb.org/mongo-driver/mongo"
+	options "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MockIMongo is a mock of IMongo interface.
+type MockIMongo struct {
+	ctrl     *gomock.Controller
+	recorder *MockIMongoMockRecorder
+}
+
+// MockIMongoMockRecorder is the mock recorder for MockIMongo.
+type MockIMongoMockRecorder struct {
+	mock *MockIMongo
+}
+
+// NewMockIMongo creates a new mock instance.
+func NewMockIMongo(ctrl *gomock.Controller) *MockIMongo {
+	mock := &MockIMongo{ctrl: ctrl}
+	mock.recorder = &MockIMongoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIMongo) EXPECT() *MockIMongoMockRecorder {
+	return m.recorder
+}
+
+// CreateIndexes mocks base method.
+func (m *MockIMongo) CreateIndexes(ctx context.Context, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, models}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CreateIndexes", varargs...)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIndexes indicates an expected call of CreateIndexes.
+func (mr *MockIMongoMockRecorder) CreateIndexes(ctx, models interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, models}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIndexes", reflect.TypeOf((*MockIMongo)(nil).CreateIndexes), varargs...)
+}
+
+// Find mocks base method.
+func (m *MockIMongo) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (mongo0.Cursor, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, filter}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Find", varargs...)
+	ret0, _ := ret[0].(mongo0.Cursor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Find indicates an expected call of Find.
+func (mr *MockIMongoMockRecorder) Find(ctx, filter interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, filter}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockIMongo)(nil).Find), varargs...)
+}
+
+// InsertOne mocks base method.
+func (m *MockIMongo) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, document}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "InsertOne", varargs...)
+	ret0, _ := ret[0].(*mongo.InsertOneResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertOne indicates an expected call of InsertOne.
+func (mr *MockIMongoMockRecorder) InsertOne(ctx, document interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, document}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertOne", reflect.TypeOf((*MockIMongo)(nil).InsertOne), varargs...)
+}
+
+// UpdateOne mocks base method.
+func (m *MockIMongo) UpdateOne(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, filter, update}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UpdateOne", varargs...)
+	ret0, _ := ret[0].(*mongo.UpdateResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateOne indicates an expected call of UpdateOne.
+func (mr *MockIMongoMockRecorder) UpdateOne(ctx, filter, update interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, filter, update}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOne", reflect.TypeOf((*MockIMongo)(nil).UpdateOne), varargs...)
+}
+
+// MockITasks is a mock of ITasks interface.
+type MockITasks struct {
+	ctrl     *gomock.Controller
+	recorder *MockITasksMockRecorder
+}
+
+// MockITasksMockRecorder is the mock recorder for MockITasks.
+type MockITasksMockRecorder struct {
+	mock *MockITasks
+}
+
+// NewMockITasks creates a new mock instance.
+func NewMockITasks(ctrl *gomock.Controller) *MockITasks {
+	mock := &MockITasks{ctrl: ctrl}
+	mock.recorder = &MockITasksMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockITasks) EXPECT() *MockITasksMockRecorder {
+	return m.recorder
+}
+
+// CreateTask mocks base method.
+func (m *MockITasks) CreateTask(ctx context.Context, ownerId string, task taskmanager.NewTask) (*taskmanager.TaskDoc, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTask", ctx, ownerId, task)
+	ret0, _ := ret[0].(*taskmanager.TaskDoc)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTask indicates an expected call of CreateTask.
+func (mr *MockITasksMockRecorder) CreateTask(ctx, ownerId, task interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTask", reflect.TypeOf((*MockITasks)(nil).CreateTask), ctx, ownerId, task)
+}
+
+// FindOccurrence mocks base method.
+func (m *MockITasks) FindOccurrence(ctx context.Context, recurrenceId string, occurrence int64) (*taskmanager.TaskDoc, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindOccurrence", ctx, recurrenceId, occurrence)
+	ret0, _ := ret[0].(*taskmanager.TaskDoc)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindOccurrence indicates an expected call of FindOccurrence.
+func (mr *MockITasksMockRecorder) FindOccurrence(ctx, recurrenceId, occurrence interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOccurrence", reflect.TypeOf((*MockITasks)(nil).FindOccurrence), ctx, recurrenceId, occurrence)
+}
+
+// FindTask mocks base method.
+func (m *MockITasks) FindTask(ctx context.Context, id string) (*taskmanager.TaskDoc, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindTask", ctx, id)
+	ret0, _ := ret[0].(*taskmanager.TaskDoc)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindTask indicates an expected call of FindTask.
+func (mr *MockITasksMockRecorder) FindTask(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindTask", reflect.TypeOf((*MockITasks)(nil).FindTask), ctx, id)
+}
+
+// Workflow mocks base method.
+func (m *MockITasks) Workflow() *taskmanager.Workflow {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Workflow")
+	ret0, _ := ret[0].(*taskmanager.Workflow)
+	return ret0
+}
+
+// Workflow indicates an expected call of Workflow.
+func (mr *MockITasksMockRecorder) Workflow() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Workflow", reflect.TypeOf((*MockITasks)(nil).Workflow))
+}
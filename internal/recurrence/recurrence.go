@@ -0,0 +1,307 @@
+package recurrence
+
+import (
+	"context"
+	"errors"
+	"log"
+	"task-manager-api/internal/apperror"
+	m "task-manager-api/internal/mongo"
+	"task-manager-api/internal/taskmanager"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//go:generate mockgen -source=./recurrence.go -destination=./mock/recurrence.go
+type IMongo interface {
+	InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error)
+	UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+	Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (m.Cursor, error)
+	CreateIndexes(ctx context.Context, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error)
+}
+
+type ITasks interface {
+	CreateTask(ctx context.Context, ownerId string, task taskmanager.NewTask) (*taskmanager.TaskDoc, error)
+	FindTask(ctx context.Context, id string) (*taskmanager.TaskDoc, error)
+	FindOccurrence(ctx context.Context, recurrenceId string, occurrence int64) (*taskmanager.TaskDoc, error)
+	Workflow() *taskmanager.Workflow
+}
+
+var (
+	ErrInvalidRecurrenceID = apperror.Validation("invalid_recurrence_id", "Invalid recurrence id")
+	ErrInvalidTimezone     = apperror.Validation("invalid_tz", "Invalid tz")
+)
+
+// Template holds the fields every task of a series is created with.
+type Template struct {
+	Topic       string `json:"topic" bson:"topic"`
+	Description string `json:"description" bson:"description"`
+	Priority    int    `json:"priority" bson:"priority"`
+}
+
+// RecurrenceDoc is a series of tasks created from a template following a rule.
+// Generated counts the occurrences passed, skipped ones included, while
+// LastOccurrence and LastTaskID track the latest task of the series.
+type RecurrenceDoc struct {
+	ID             string   `json:"id" bson:"_id,omitempty"`
+	OwnerID        string   `json:"owner_id" bson:"owner_id"`
+	Template       Template `json:"template" bson:"template"`
+	Rule           string   `json:"rule" bson:"rule"`
+	TZ             string   `json:"tz" bson:"tz"`
+	StartDate      int64    `json:"start_date" bson:"start_date"`
+	Generated      int      `json:"generated" bson:"generated"`
+	LastOccurrence *int64   `json:"last_occurrence" bson:"last_occurrence"`
+	LastTaskID     string   `json:"last_task_id,omitempty" bson:"last_task_id,omitempty"`
+	Ended          bool     `json:"ended" bson:"ended"`
+	CreateDate     int64    `json:"create_date" bson:"create_date"`
+}
+
+// NewRecurrence holds the fields a series can be created with.
+type NewRecurrence struct {
+	Template  Template
+	Rule      string
+	TZ        string
+	StartDate int64
+}
+
+type Recurrences struct {
+	mongo IMongo
+	tasks ITasks
+	time  func() time.Time
+}
+
+func NewRecurrenceService(mongo IMongo, tasks ITasks) *Recurrences {
+	return &Recurrences{mongo: mongo, tasks: tasks}
+}
+
+// EnsureIndexes creates the indexes backing series listing and the scheduler.
+func (r *Recurrences) EnsureIndexes(ctx context.Context) error {
+	models := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "ended", Value: 1}}},
+	}
+	if _, err := r.mongo.CreateIndexes(ctx, models); err != nil {
+		return m.WrapError(err)
+	}
+	return nil
+}
+
+// CreateRecurrence stores a series and creates its first task right away.
+func (r *Recurrences) CreateRecurrence(ctx context.Context, ownerId string, recurrence NewRecurrence) (*RecurrenceDoc, error) {
+	rule, err := Parse(recurrence.Rule)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := loadLocation(recurrence.TZ); err != nil {
+		return nil, err
+	}
+	if !taskmanager.ValidPriority(recurrence.Template.Priority) {
+		return nil, taskmanager.ErrInvalidPriority
+	}
+	doc := RecurrenceDoc{
+		OwnerID:    ownerId,
+		Template:   recurrence.Template,
+		Rule:       rule.String(),
+		TZ:         recurrence.TZ,
+		StartDate:  recurrence.StartDate,
+		CreateDate: r.now().Unix(),
+	}
+	result, err := r.mongo.InsertOne(ctx, doc)
+	if err != nil {
+		return nil, m.WrapError(err)
+	}
+	oid, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, apperror.ErrInternal.Wrap(errors.New("cannot convert inserted id to object id"))
+	}
+	doc.ID = oid.Hex()
+
+	// the series is stored, a failure here is retried by the scheduler
+	if err := r.advance(ctx, &doc); err != nil {
+		log.Printf("recurrence %v: first occurrence not created: %v", doc.ID, err)
+	}
+	return &doc, nil
+}
+
+func (r *Recurrences) GetRecurrences(ctx context.Context, ownerId string) ([]RecurrenceDoc, error) {
+	curr, err := r.mongo.Find(ctx, bson.M{"owner_id": ownerId}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, m.WrapError(err)
+	}
+	recurrences := make([]RecurrenceDoc, 0)
+	if err := curr.All(ctx, &recurrences); err != nil {
+		return nil, m.WrapError(err)
+	}
+	return recurrences, nil
+}
+
+// EndRecurrence stops a series, tasks already created are kept.
+func (r *Recurrences) EndRecurrence(ctx context.Context, ownerId string, id string) (int, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return 0, ErrInvalidRecurrenceID.Wrap(err)
+	}
+	result, err := r.mongo.UpdateOne(ctx, bson.M{"_id": oid, "owner_id": ownerId}, bson.M{"$set": bson.M{"ended": true}})
+	if err != nil {
+		return 0, m.WrapError(err)
+	}
+	return int(result.MatchedCount), nil
+}
+
+// Run creates the due occurrence of every active series. A failing series
+// does not stop the others, the first error is returned.
+func (r *Recurrences) Run(ctx context.Context) error {
+	curr, err := r.mongo.Find(ctx, bson.M{"ended": false})
+	if err != nil {
+		return m.WrapError(err)
+	}
+	var recurrences []RecurrenceDoc
+	if err := curr.All(ctx, &recurrences); err != nil {
+		return m.WrapError(err)
+	}
+
+	var first error
+	for i := range recurrences {
+		if err := r.advance(ctx, &recurrences[i]); err != nil {
+			log.Printf("recurrence %v: %v", recurrences[i].ID, err)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// advance creates the next occurrence of a series when its date has arrived
+// or when the previous occurrence is finished. Occurrences whose date has
+// passed are skipped up to the latest one. The unique occurrence index of
+// tasks claims the occurrence, a run finding its task already created, by
+// another instance or before a restart, picks it up, and the series moves
+// on with a conditional update on generated. This makes it safe to run
+// again after a crash at any point or from several instances.
+func (r *Recurrences) advance(ctx context.Context, doc *RecurrenceDoc) error {
+	rule, err := Parse(doc.Rule)
+	if err != nil {
+		return err
+	}
+	loc, err := loadLocation(doc.TZ)
+	if err != nil {
+		return err
+	}
+	start := time.Unix(doc.StartDate, 0).In(loc)
+	var after time.Time
+	if doc.LastOccurrence != nil {
+		after = time.Unix(*doc.LastOccurrence, 0)
+	}
+	next, ok := rule.Next(start, after, doc.Generated)
+	if !ok {
+		return r.end(ctx, doc)
+	}
+	if doc.LastOccurrence != nil && r.now().Before(next) {
+		finished, err := r.finished(ctx, doc.LastTaskID)
+		if err != nil || !finished {
+			return err
+		}
+	}
+
+	// a series starting in the past, or left behind while the scheduler was
+	// down, catches up with its latest due date instead of creating a task
+	// for every missed one
+	count := 1
+	for {
+		following, ok := rule.Next(start, next, doc.Generated+count)
+		if !ok || following.After(r.now()) {
+			break
+		}
+		next = following
+		count++
+	}
+
+	oid, err := primitive.ObjectIDFromHex(doc.ID)
+	if err != nil {
+		return ErrInvalidRecurrenceID.Wrap(err)
+	}
+	occurrence := next.Unix()
+	task, err := r.tasks.CreateTask(ctx, doc.OwnerID, taskmanager.NewTask{
+		Topic:        doc.Template.Topic,
+		Description:  doc.Template.Description,
+		Priority:     doc.Template.Priority,
+		DueDate:      &occurrence,
+		RecurrenceID: doc.ID,
+		Occurrence:   &occurrence,
+	})
+	if errors.Is(err, taskmanager.ErrOccurrenceExists) {
+		task, err = r.tasks.FindOccurrence(ctx, doc.ID, occurrence)
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := r.mongo.UpdateOne(ctx,
+		bson.M{"_id": oid, "generated": doc.Generated},
+		bson.M{
+			"$set": bson.M{"last_occurrence": occurrence, "last_task_id": task.ID},
+			"$inc": bson.M{"generated": count},
+		})
+	if err != nil {
+		return m.WrapError(err)
+	}
+	if result.MatchedCount == 0 {
+		// another run moved the series on first
+		return nil
+	}
+	doc.Generated += count
+	doc.LastOccurrence = &occurrence
+	doc.LastTaskID = task.ID
+	return nil
+}
+
+// finished reports whether the latest task of a series is done, archived or gone.
+func (r *Recurrences) finished(ctx context.Context, taskId string) (bool, error) {
+	if taskId == "" {
+		return false, nil
+	}
+	task, err := r.tasks.FindTask(ctx, taskId)
+	if errors.Is(err, taskmanager.ErrTaskNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return task.ArchiveDate != nil || r.tasks.Workflow().IsTerminal(task.Status), nil
+}
+
+func (r *Recurrences) end(ctx context.Context, doc *RecurrenceDoc) error {
+	oid, err := primitive.ObjectIDFromHex(doc.ID)
+	if err != nil {
+		return ErrInvalidRecurrenceID.Wrap(err)
+	}
+	if _, err := r.mongo.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": bson.M{"ended": true}}); err != nil {
+		return m.WrapError(err)
+	}
+	doc.Ended = true
+	return nil
+}
+
+// loadLocation returns the timezone of a series, defaulting to UTC.
+func loadLocation(tz string) (*time.Location, error) {
+	if tz == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil || tz == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+	return loc, nil
+}
+
+func (r *Recurrences) now() time.Time {
+	if r.time == nil {
+		return time.Now()
+	}
+
+	return r.time()
+}
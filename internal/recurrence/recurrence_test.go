@@ -0,0 +1,253 @@
+package recurrence
+
+import (
+	"context"
+	"errors"
+	mock "task-manager-api/internal/mongo/mock"
+	mock_recurrence "task-manager-api/internal/recurrence/mock"
+	"task-manager-api/internal/taskmanager"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type RecurrenceTestSuite struct {
+	suite.Suite
+	ctrl      *gomock.Controller
+	mockMongo *mock_recurrence.MockIMongo
+	tasks     *mock_recurrence.MockITasks
+	service   *Recurrences
+	cursor    *mock.MockCursor
+}
+
+func (t *RecurrenceTestSuite) SetupTest() {
+	t.ctrl = gomock.NewController(t.T())
+	t.mockMongo = mock_recurrence.NewMockIMongo(t.ctrl)
+	t.tasks = mock_recurrence.NewMockITasks(t.ctrl)
+	t.service = NewRecurrenceService(t.mockMongo, t.tasks)
+	t.cursor = mock.NewMockCursor(t.ctrl)
+	t.service.time = func() time.Time {
+		loc, _ := time.LoadLocation("Asia/Bangkok")
+		return time.Date(2023, 5, 11, 12, 0, 0, 0, loc)
+	}
+	t.tasks.EXPECT().Workflow().Return(taskmanager.DefaultWorkflow()).AnyTimes()
+}
+
+func (t *RecurrenceTestSuite) TearDownTest() {
+	t.ctrl.Finish()
+	t.mockMongo = nil
+	t.tasks = nil
+	t.service = nil
+	t.cursor = nil
+}
+
+func TestRecurrenceTestSuite(t *testing.T) {
+	suite.Run(t, new(RecurrenceTestSuite))
+}
+
+const (
+	recurrenceID = "645b9183fcfbc11433e23ab3"
+	// 2023-05-10 09:00 and 2023-05-17 09:00 in Asia/Bangkok
+	firstOccurrence  = int64(1683684000)
+	secondOccurrence = int64(1684288800)
+)
+
+func (t *RecurrenceTestSuite) series(generated int, lastTaskId string) RecurrenceDoc {
+	doc := RecurrenceDoc{
+		ID:        recurrenceID,
+		OwnerID:   "owner_id",
+		Template:  Template{Topic: "topic", Description: "description"},
+		Rule:      "FREQ=WEEKLY",
+		TZ:        "Asia/Bangkok",
+		StartDate: firstOccurrence,
+		Generated: generated,
+	}
+	if generated > 0 {
+		last := firstOccurrence
+		doc.LastOccurrence = &last
+		doc.LastTaskID = lastTaskId
+	}
+	return doc
+}
+
+func (t *RecurrenceTestSuite) expectRun(docs ...RecurrenceDoc) {
+	t.mockMongo.EXPECT().Find(context.Background(), bson.M{"ended": false}).Return(t.cursor, nil)
+	t.cursor.EXPECT().All(context.Background(), gomock.Any()).SetArg(1, docs).Return(nil)
+}
+
+// expectAdvance expects the series to move on from generated occurrences
+// to the given one and its task.
+func (t *RecurrenceTestSuite) expectAdvance(generated int, occurrence int64, taskId string) {
+	t.expectSave(generated, 1, occurrence, taskId, 1)
+}
+
+func (t *RecurrenceTestSuite) expectSave(generated int, count int, occurrence int64, taskId string, matched int64) {
+	oid, _ := primitive.ObjectIDFromHex(recurrenceID)
+	t.mockMongo.EXPECT().UpdateOne(context.Background(),
+		bson.M{"_id": oid, "generated": generated},
+		bson.M{
+			"$set": bson.M{"last_occurrence": occurrence, "last_task_id": taskId},
+			"$inc": bson.M{"generated": count},
+		}).Return(&mongo.UpdateResult{MatchedCount: matched}, nil)
+}
+
+func (t *RecurrenceTestSuite) newTask(occurrence int64) taskmanager.NewTask {
+	return taskmanager.NewTask{
+		Topic:        "topic",
+		Description:  "description",
+		DueDate:      &occurrence,
+		RecurrenceID: recurrenceID,
+		Occurrence:   &occurrence,
+	}
+}
+
+func (t *RecurrenceTestSuite) TestCreateRecurrence() {
+	t.Run("create recurrence with invalid rule should return error", func() {
+		doc, err := t.service.CreateRecurrence(context.Background(), "owner_id", NewRecurrence{Rule: "FREQ=HOURLY"})
+		t.Nil(doc)
+		t.ErrorIs(err, ErrInvalidRule)
+	})
+
+	t.Run("create recurrence with invalid tz should return error", func() {
+		doc, err := t.service.CreateRecurrence(context.Background(), "owner_id", NewRecurrence{Rule: "FREQ=DAILY", TZ: "Mars/Olympus"})
+		t.Nil(doc)
+		t.ErrorIs(err, ErrInvalidTimezone)
+	})
+
+	t.Run("create recurrence creates the first occurrence", func() {
+		oid, _ := primitive.ObjectIDFromHex(recurrenceID)
+		t.mockMongo.EXPECT().InsertOne(context.Background(), RecurrenceDoc{
+			OwnerID:    "owner_id",
+			Template:   Template{Topic: "topic", Description: "description"},
+			Rule:       "FREQ=WEEKLY",
+			TZ:         "Asia/Bangkok",
+			StartDate:  firstOccurrence,
+			CreateDate: t.service.now().Unix(),
+		}).Return(&mongo.InsertOneResult{InsertedID: oid}, nil)
+		t.tasks.EXPECT().CreateTask(context.Background(), "owner_id", t.newTask(firstOccurrence)).Return(&taskmanager.TaskDoc{ID: "task_1"}, nil)
+		t.expectAdvance(0, firstOccurrence, "task_1")
+
+		doc, err := t.service.CreateRecurrence(context.Background(), "owner_id", NewRecurrence{
+			Template:  Template{Topic: "topic", Description: "description"},
+			Rule:      "freq=weekly",
+			TZ:        "Asia/Bangkok",
+			StartDate: firstOccurrence,
+		})
+		t.Nil(err)
+		t.Equal(recurrenceID, doc.ID)
+		t.Equal(1, doc.Generated)
+		t.Equal("task_1", doc.LastTaskID)
+	})
+}
+
+func (t *RecurrenceTestSuite) TestRun() {
+	t.Run("run waits while the last task is open and the next date is ahead", func() {
+		t.expectRun(t.series(1, "task_1"))
+		t.tasks.EXPECT().FindTask(context.Background(), "task_1").Return(&taskmanager.TaskDoc{ID: "task_1", Status: taskmanager.TaskStatusOpen}, nil)
+		t.Nil(t.service.Run(context.Background()))
+	})
+
+	t.Run("run creates the next occurrence once the last task is done", func() {
+		t.expectRun(t.series(1, "task_1"))
+		t.tasks.EXPECT().FindTask(context.Background(), "task_1").Return(&taskmanager.TaskDoc{ID: "task_1", Status: taskmanager.TaskStatusDone}, nil)
+		t.tasks.EXPECT().CreateTask(context.Background(), "owner_id", t.newTask(secondOccurrence)).Return(&taskmanager.TaskDoc{ID: "task_2"}, nil)
+		t.expectAdvance(1, secondOccurrence, "task_2")
+		t.Nil(t.service.Run(context.Background()))
+	})
+
+	t.Run("run creates the next occurrence when the last task is gone", func() {
+		t.expectRun(t.series(1, "task_1"))
+		t.tasks.EXPECT().FindTask(context.Background(), "task_1").Return(nil, taskmanager.ErrTaskNotFound)
+		t.tasks.EXPECT().CreateTask(context.Background(), "owner_id", t.newTask(secondOccurrence)).Return(&taskmanager.TaskDoc{ID: "task_2"}, nil)
+		t.expectAdvance(1, secondOccurrence, "task_2")
+		t.Nil(t.service.Run(context.Background()))
+	})
+
+	t.Run("run creates the next occurrence when its date arrives", func() {
+		t.service.time = func() time.Time { return time.Unix(secondOccurrence, 0) }
+		t.expectRun(t.series(1, "task_1"))
+		t.tasks.EXPECT().CreateTask(context.Background(), "owner_id", t.newTask(secondOccurrence)).Return(&taskmanager.TaskDoc{ID: "task_2"}, nil)
+		t.expectAdvance(1, secondOccurrence, "task_2")
+		t.Nil(t.service.Run(context.Background()))
+	})
+
+	t.Run("run after a restart picks up the task of an occurrence created before", func() {
+		t.service.time = func() time.Time { return time.Unix(secondOccurrence, 0) }
+		t.expectRun(t.series(1, "task_1"))
+		t.tasks.EXPECT().CreateTask(context.Background(), "owner_id", t.newTask(secondOccurrence)).Return(nil, taskmanager.ErrOccurrenceExists)
+		t.tasks.EXPECT().FindOccurrence(context.Background(), recurrenceID, secondOccurrence).Return(&taskmanager.TaskDoc{ID: "task_2"}, nil)
+		t.expectAdvance(1, secondOccurrence, "task_2")
+		t.Nil(t.service.Run(context.Background()))
+	})
+
+	t.Run("run of a series starting in the past creates only its latest due occurrence", func() {
+		// three weeks after the first occurrence
+		third := secondOccurrence + 7*24*3600
+		t.service.time = func() time.Time { return time.Unix(third+3600, 0) }
+		t.expectRun(t.series(0, ""))
+		t.tasks.EXPECT().CreateTask(context.Background(), "owner_id", t.newTask(third)).Return(&taskmanager.TaskDoc{ID: "task_3"}, nil)
+		t.expectSave(0, 3, third, "task_3", 1)
+		t.Nil(t.service.Run(context.Background()))
+	})
+
+	t.Run("run racing another run should create the occurrence once", func() {
+		t.service.time = func() time.Time { return time.Unix(secondOccurrence, 0) }
+		t.expectRun(t.series(1, "task_1"))
+		t.tasks.EXPECT().CreateTask(context.Background(), "owner_id", t.newTask(secondOccurrence)).Return(nil, taskmanager.ErrOccurrenceExists)
+		t.tasks.EXPECT().FindOccurrence(context.Background(), recurrenceID, secondOccurrence).Return(&taskmanager.TaskDoc{ID: "task_2"}, nil)
+		t.expectSave(1, 1, secondOccurrence, "task_2", 0)
+		t.Nil(t.service.Run(context.Background()))
+	})
+
+	t.Run("run failing to create the occurrence should leave the series for the next run", func() {
+		t.service.time = func() time.Time { return time.Unix(secondOccurrence, 0) }
+		t.expectRun(t.series(1, "task_1"))
+		t.tasks.EXPECT().CreateTask(context.Background(), "owner_id", t.newTask(secondOccurrence)).Return(nil, errors.New("insert error"))
+		t.EqualError(t.service.Run(context.Background()), "insert error")
+	})
+
+	t.Run("run ends a series past its count", func() {
+		doc := t.series(1, "task_1")
+		doc.Rule = "FREQ=WEEKLY;COUNT=1"
+		t.expectRun(doc)
+		oid, _ := primitive.ObjectIDFromHex(recurrenceID)
+		t.mockMongo.EXPECT().UpdateOne(context.Background(), bson.M{"_id": oid}, bson.M{"$set": bson.M{"ended": true}}).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+		t.Nil(t.service.Run(context.Background()))
+	})
+
+	t.Run("run keeps going when a series fails", func() {
+		t.service.time = func() time.Time { return time.Unix(secondOccurrence, 0) }
+		failing := t.series(1, "task_1")
+		failing.Rule = "FREQ=SECONDLY"
+		t.expectRun(failing, t.series(1, "task_1"))
+		t.tasks.EXPECT().CreateTask(context.Background(), "owner_id", t.newTask(secondOccurrence)).Return(&taskmanager.TaskDoc{ID: "task_2"}, nil)
+		t.expectAdvance(1, secondOccurrence, "task_2")
+		t.ErrorIs(t.service.Run(context.Background()), ErrInvalidRule)
+	})
+
+	t.Run("run but find has error should return error", func() {
+		t.mockMongo.EXPECT().Find(context.Background(), bson.M{"ended": false}).Return(nil, errors.New("find error"))
+		t.EqualError(t.service.Run(context.Background()), "find error")
+	})
+}
+
+func (t *RecurrenceTestSuite) TestEndRecurrence() {
+	t.Run("end recurrence with invalid id should return error", func() {
+		matched, err := t.service.EndRecurrence(context.Background(), "owner_id", "recurrence")
+		t.Equal(0, matched)
+		t.ErrorIs(err, ErrInvalidRecurrenceID)
+	})
+
+	t.Run("end recurrence success", func() {
+		oid, _ := primitive.ObjectIDFromHex(recurrenceID)
+		t.mockMongo.EXPECT().UpdateOne(context.Background(), bson.M{"_id": oid, "owner_id": "owner_id"}, bson.M{"$set": bson.M{"ended": true}}).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+		matched, err := t.service.EndRecurrence(context.Background(), "owner_id", recurrenceID)
+		t.Nil(err)
+		t.Equal(1, matched)
+	})
+}
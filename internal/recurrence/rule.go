@@ -0,0 +1,231 @@
+package recurrence
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"task-manager-api/internal/apperror"
+	"time"
+)
+
+var ErrInvalidRule = apperror.Validation("invalid_rule", "Invalid recurrence rule")
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Rule is the supported subset of an RFC 5545 RRULE: FREQ of DAILY, WEEKLY
+// or MONTHLY, INTERVAL, BYDAY for weekly rules, and UNTIL or COUNT.
+type Rule struct {
+	Freq      Frequency
+	Interval  int
+	ByWeekday []time.Weekday
+	Until     *time.Time
+	Count     int
+}
+
+// Parse reads a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10".
+// UNTIL accepts "20060102" or "20060102T150405Z".
+func Parse(value string) (Rule, error) {
+	rule := Rule{Interval: 1}
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	for _, part := range strings.Split(value, ";") {
+		key, val, found := strings.Cut(part, "=")
+		if !found {
+			return rule, invalidRule("malformed part %q", part)
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = Frequency(strings.ToUpper(val))
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 {
+				return rule, invalidRule("invalid INTERVAL %q", val)
+			}
+			rule.Interval = interval
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				weekday, ok := weekdays[strings.ToUpper(day)]
+				if !ok {
+					return rule, invalidRule("invalid BYDAY %q", day)
+				}
+				rule.ByWeekday = append(rule.ByWeekday, weekday)
+			}
+		case "UNTIL":
+			until, err := parseUntil(val)
+			if err != nil {
+				return rule, invalidRule("invalid UNTIL %q", val)
+			}
+			rule.Until = &until
+		case "COUNT":
+			count, err := strconv.Atoi(val)
+			if err != nil || count < 1 {
+				return rule, invalidRule("invalid COUNT %q", val)
+			}
+			rule.Count = count
+		default:
+			return rule, invalidRule("unsupported part %q", key)
+		}
+	}
+
+	switch {
+	case rule.Freq != Daily && rule.Freq != Weekly && rule.Freq != Monthly:
+		return rule, invalidRule("unsupported FREQ %q", rule.Freq)
+	case len(rule.ByWeekday) > 0 && rule.Freq != Weekly:
+		return rule, invalidRule("BYDAY is only supported with FREQ=WEEKLY")
+	case rule.Until != nil && rule.Count > 0:
+		return rule, invalidRule("UNTIL and COUNT cannot be combined")
+	}
+	sort.Slice(rule.ByWeekday, func(i, j int) bool {
+		return mondayOffset(rule.ByWeekday[i]) < mondayOffset(rule.ByWeekday[j])
+	})
+	return rule, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	// a date only UNTIL includes the whole day
+	t, err := time.Parse("20060102", value)
+	if err != nil {
+		return t, err
+	}
+	return t.AddDate(0, 0, 1).Add(-time.Second), nil
+}
+
+func invalidRule(format string, args ...interface{}) error {
+	return ErrInvalidRule.Wrap(fmt.Errorf(format, args...))
+}
+
+// String formats the rule back to RRULE syntax.
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByWeekday) > 0 {
+		days := make([]string, 0, len(r.ByWeekday))
+		for _, weekday := range r.ByWeekday {
+			for name, day := range weekdays {
+				if day == weekday {
+					days = append(days, name)
+				}
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence of the series starting at start that is
+// strictly after after, given that generated occurrences were already made.
+// The time of day and the calendar come from start, including its location.
+// It returns false when the series has ended.
+func (r Rule) Next(start time.Time, after time.Time, generated int) (time.Time, bool) {
+	if r.Count > 0 && generated >= r.Count {
+		return time.Time{}, false
+	}
+	if after.Before(start) {
+		after = start.Add(-time.Nanosecond)
+	}
+	after = after.In(start.Location())
+
+	var next time.Time
+	switch r.Freq {
+	case Daily:
+		next = r.nextDaily(start, after)
+	case Weekly:
+		next = r.nextWeekly(start, after)
+	case Monthly:
+		next = r.nextMonthly(start, after)
+	default:
+		return time.Time{}, false
+	}
+	if r.Until != nil && next.After(*r.Until) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+func (r Rule) nextDaily(start time.Time, after time.Time) time.Time {
+	steps := daysBetween(start, after) / r.Interval
+	for {
+		next := start.AddDate(0, 0, steps*r.Interval)
+		if next.After(after) {
+			return next
+		}
+		steps++
+	}
+}
+
+func (r Rule) nextWeekly(start time.Time, after time.Time) time.Time {
+	days := r.ByWeekday
+	if len(days) == 0 {
+		days = []time.Weekday{start.Weekday()}
+	}
+	weekStart := start.AddDate(0, 0, -mondayOffset(start.Weekday()))
+	week := daysBetween(weekStart, after) / 7 / r.Interval * r.Interval
+	for {
+		for _, day := range days {
+			next := weekStart.AddDate(0, 0, week*7+mondayOffset(day))
+			if !next.Before(start) && next.After(after) {
+				return next
+			}
+		}
+		week += r.Interval
+	}
+}
+
+func (r Rule) nextMonthly(start time.Time, after time.Time) time.Time {
+	months := (after.Year()-start.Year())*12 + int(after.Month()-start.Month())
+	months = months / r.Interval * r.Interval
+	if months < 0 {
+		months = 0
+	}
+	for {
+		// months without the start day, such as the 31st, are skipped
+		next := time.Date(start.Year(), start.Month()+time.Month(months), start.Day(),
+			start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+		if next.Day() == start.Day() && next.After(after) {
+			return next
+		}
+		months += r.Interval
+	}
+}
+
+// daysBetween counts calendar days from a to b, ignoring daylight saving shifts.
+func daysBetween(a time.Time, b time.Time) int {
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(db.Sub(da).Hours() / 24)
+	if days < 0 {
+		return 0
+	}
+	return days
+}
+
+func mondayOffset(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
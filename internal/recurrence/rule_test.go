@@ -0,0 +1,124 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RuleTestSuite struct {
+	suite.Suite
+	loc *time.Location
+}
+
+func (t *RuleTestSuite) SetupTest() {
+	t.loc, _ = time.LoadLocation("Asia/Bangkok")
+}
+
+func TestRuleTestSuite(t *testing.T) {
+	suite.Run(t, new(RuleTestSuite))
+}
+
+func (t *RuleTestSuite) TestParse() {
+	t.Run("parse weekly rule", func() {
+		rule, err := Parse("RRULE:FREQ=weekly;INTERVAL=2;BYDAY=TH,MO;COUNT=4")
+		t.Nil(err)
+		t.Equal(Weekly, rule.Freq)
+		t.Equal(2, rule.Interval)
+		t.Equal([]time.Weekday{time.Monday, time.Thursday}, rule.ByWeekday)
+		t.Equal(4, rule.Count)
+		t.Equal("FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=4", rule.String())
+	})
+
+	t.Run("parse until date includes the whole day", func() {
+		rule, err := Parse("FREQ=DAILY;UNTIL=20230510")
+		t.Nil(err)
+		t.Equal(time.Date(2023, 5, 10, 23, 59, 59, 0, time.UTC), *rule.Until)
+		t.Equal("FREQ=DAILY;UNTIL=20230510T235959Z", rule.String())
+	})
+
+	for _, value := range []string{
+		"",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=DAILY;COUNT=2;UNTIL=20230510",
+		"FREQ=DAILY;BYMONTHDAY=1",
+		"FREQ=DAILY;UNTIL=tomorrow",
+	} {
+		t.Run("parse invalid rule "+value+" should return error", func() {
+			_, err := Parse(value)
+			t.ErrorIs(err, ErrInvalidRule)
+		})
+	}
+}
+
+func (t *RuleTestSuite) TestNext() {
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 0, 0, 0, t.loc)
+	}
+
+	t.Run("first occurrence is the start", func() {
+		rule, _ := Parse("FREQ=DAILY")
+		next, ok := rule.Next(at(2023, 5, 10), time.Time{}, 0)
+		t.True(ok)
+		t.Equal(at(2023, 5, 10), next)
+	})
+
+	t.Run("daily with interval", func() {
+		rule, _ := Parse("FREQ=DAILY;INTERVAL=3")
+		next, ok := rule.Next(at(2023, 5, 10), at(2023, 5, 10), 1)
+		t.True(ok)
+		t.Equal(at(2023, 5, 13), next)
+	})
+
+	t.Run("daily skips to the first occurrence after", func() {
+		rule, _ := Parse("FREQ=DAILY;INTERVAL=2")
+		next, _ := rule.Next(at(2023, 5, 10), at(2023, 6, 1).Add(time.Hour), 5)
+		t.Equal(at(2023, 6, 3), next)
+	})
+
+	t.Run("weekly by weekday", func() {
+		// 2023-05-10 is a wednesday
+		rule, _ := Parse("FREQ=WEEKLY;BYDAY=MO,FR")
+		next, _ := rule.Next(at(2023, 5, 10), time.Time{}, 0)
+		t.Equal(at(2023, 5, 12), next)
+		next, _ = rule.Next(at(2023, 5, 10), next, 1)
+		t.Equal(at(2023, 5, 15), next)
+	})
+
+	t.Run("weekly with interval keeps the start week", func() {
+		rule, _ := Parse("FREQ=WEEKLY;INTERVAL=2;BYDAY=MO")
+		next, _ := rule.Next(at(2023, 5, 10), at(2023, 5, 15), 1)
+		t.Equal(at(2023, 5, 22), next)
+	})
+
+	t.Run("weekly without weekday uses the start day", func() {
+		rule, _ := Parse("FREQ=WEEKLY")
+		next, _ := rule.Next(at(2023, 5, 10), at(2023, 5, 10), 1)
+		t.Equal(at(2023, 5, 17), next)
+	})
+
+	t.Run("monthly skips months without the start day", func() {
+		rule, _ := Parse("FREQ=MONTHLY")
+		next, _ := rule.Next(at(2023, 1, 31), at(2023, 1, 31), 1)
+		t.Equal(at(2023, 3, 31), next)
+	})
+
+	t.Run("count ends the series", func() {
+		rule, _ := Parse("FREQ=DAILY;COUNT=2")
+		_, ok := rule.Next(at(2023, 5, 10), at(2023, 5, 11), 2)
+		t.False(ok)
+	})
+
+	t.Run("until ends the series", func() {
+		rule, _ := Parse("FREQ=DAILY;UNTIL=20230511T000000Z")
+		next, ok := rule.Next(at(2023, 5, 10), time.Time{}, 0)
+		t.True(ok)
+		t.Equal(at(2023, 5, 10), next)
+		_, ok = rule.Next(at(2023, 5, 10), next, 1)
+		t.False(ok)
+	})
+}
@@ -0,0 +1,239 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./retention.go
+
+// Package mock_retention is a generated GoMock package.
+package mock_retention
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockITasks is a mock of ITasks interface.
+type MockITasks struct {
+	ctrl     *gomock.Controller
+	recorder *MockITasksMockRecorder
+}
+
+// MockITasksMockRecorder is the mock recorder for MockITasks.
+type MockITasksMockRecorder struct {
+	mock *MockITasks
+}
+
+// NewMockITasks creates a new mock instance.
+func NewMockITasks(ctrl *gomock.Controller) *MockITasks {
+	mock := &MockITasks{ctrl: ctrl}
+	mock.recorder = &MockITasksMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockITasks) EXPECT() *MockITasksMockRecorder {
+	return m.recorder
+}
+
+// ArchivedBefore mocks base method.
+func (m *MockITasks) ArchivedBefore(ctx context.Context, before, limit int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchivedBefore", ctx, before, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ArchivedBefore indicates an expected call of ArchivedBefore.
+func (mr *MockITasksMockRecorder) ArchivedBefore(ctx, before, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchivedBefore", reflect.TypeOf((*MockITasks)(nil).ArchivedBefore), ctx, before, limit)
+}
+
+// DeleteTasks mocks base method.
+func (m *MockITasks) DeleteTasks(ctx context.Context, ids []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTasks", ctx, ids)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteTasks indicates an expected call of DeleteTasks.
+func (mr *MockITasksMockRecorder) DeleteTasks(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTasks", reflect.TypeOf((*MockITasks)(nil).DeleteTasks), ctx, ids)
+}
+
+// MissingTasks mocks base method.
+func (m *MockITasks) MissingTasks(ctx context.Context, ids []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MissingTasks", ctx, ids)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MissingTasks indicates an expected call of MissingTasks.
+func (mr *MockITasksMockRecorder) MissingTasks(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MissingTasks", reflect.TypeOf((*MockITasks)(nil).MissingTasks), ctx, ids)
+}
+
+// MockIComments is a mock of IComments interface.
+type MockIComments struct {
+	ctrl     *gomock.Controller
+	recorder *MockICommentsMockRecorder
+}
+
+// MockICommentsMockRecorder is the mock recorder for MockIComments.
+type MockICommentsMockRecorder struct {
+	mock *MockIComments
+}
+
+// NewMockIComments creates a new mock instance.
+func NewMockIComments(ctrl *gomock.Controller) *MockIComments {
+	mock := &MockIComments{ctrl: ctrl}
+	mock.recorder = &MockICommentsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIComments) EXPECT() *MockICommentsMockRecorder {
+	return m.recorder
+}
+
+// DeleteTaskComments mocks base method.
+func (m *MockIComments) DeleteTaskComments(ctx context.Context, taskIds []string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTaskComments", ctx, taskIds)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteTaskComments indicates an expected call of DeleteTaskComments.
+func (mr *MockICommentsMockRecorder) DeleteTaskComments(ctx, taskIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTaskComments", reflect.TypeOf((*MockIComments)(nil).DeleteTaskComments), ctx, taskIds)
+}
+
+// TaskIDs mocks base method.
+func (m *MockIComments) TaskIDs(ctx context.Context, after string, limit int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TaskIDs", ctx, after, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TaskIDs indicates an expected call of TaskIDs.
+func (mr *MockICommentsMockRecorder) TaskIDs(ctx, after, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskIDs", reflect.TypeOf((*MockIComments)(nil).TaskIDs), ctx, after, limit)
+}
+
+// MockIReactions is a mock of IReactions interface.
+type MockIReactions struct {
+	ctrl     *gomock.Controller
+	recorder *MockIReactionsMockRecorder
+}
+
+// MockIReactionsMockRecorder is the mock recorder for MockIReactions.
+type MockIReactionsMockRecorder struct {
+	mock *MockIReactions
+}
+
+// NewMockIReactions creates a new mock instance.
+func NewMockIReactions(ctrl *gomock.Controller) *MockIReactions {
+	mock := &MockIReactions{ctrl: ctrl}
+	mock.recorder = &MockIReactionsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIReactions) EXPECT() *MockIReactionsMockRecorder {
+	return m.recorder
+}
+
+// DeleteTaskReactions mocks base method.
+func (m *MockIReactions) DeleteTaskReactions(ctx context.Context, taskIds []string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTaskReactions", ctx, taskIds)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteTaskReactions indicates an expected call of DeleteTaskReactions.
+func (mr *MockIReactionsMockRecorder) DeleteTaskReactions(ctx, taskIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTaskReactions", reflect.TypeOf((*MockIReactions)(nil).DeleteTaskReactions), ctx, taskIds)
+}
+
+// TaskIDs mocks base method.
+func (m *MockIReactions) TaskIDs(ctx context.Context, after string, limit int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TaskIDs", ctx, after, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TaskIDs indicates an expected call of TaskIDs.
+func (mr *MockIReactionsMockRecorder) TaskIDs(ctx, after, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskIDs", reflect.TypeOf((*MockIReactions)(nil).TaskIDs), ctx, after, limit)
+}
+
+// MockIMentions is a mock of IMentions interface.
+type MockIMentions struct {
+	ctrl     *gomock.Controller
+	recorder *MockIMentionsMockRecorder
+}
+
+// MockIMentionsMockRecorder is the mock recorder for MockIMentions.
+type MockIMentionsMockRecorder struct {
+	mock *MockIMentions
+}
+
+// NewMockIMentions creates a new mock instance.
+func NewMockIMentions(ctrl *gomock.Controller) *MockIMentions {
+	mock := &MockIMentions{ctrl: ctrl}
+	mock.recorder = &MockIMentionsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIMentions) EXPECT() *MockIMentionsMockRecorder {
+	return m.recorder
+}
+
+// DeleteTaskMentions mocks base method.
+func (m *MockIMentions) DeleteTaskMentions(ctx context.Context, taskIds []string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTaskMentions", ctx, taskIds)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteTaskMentions indicates an expected call of DeleteTaskMentions.
+func (mr *MockIMentionsMockRecorder) DeleteTaskMentions(ctx, taskIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTaskMentions", reflect.TypeOf((*MockIMentions)(nil).DeleteTaskMentions), ctx, taskIds)
+}
+
+// TaskIDs mocks base method.
+func (m *MockIMentions) TaskIDs(ctx context.Context, after string, limit int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TaskIDs", ctx, after, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TaskIDs indicates an expected call of TaskIDs.
+func (mr *MockIMentionsMockRecorder) TaskIDs(ctx, after, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskIDs", reflect.TypeOf((*MockIMentions)(nil).TaskIDs), ctx, after, limit)
+}
@@ -0,0 +1,163 @@
+package retention
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+//go:generate mockgen -source=./retention.go -destination=./mock/retention.go
+type ITasks interface {
+	ArchivedBefore(ctx context.Context, before int64, limit int64) ([]string, error)
+	DeleteTasks(ctx context.Context, ids []string) ([]string, error)
+	MissingTasks(ctx context.Context, ids []string) ([]string, error)
+}
+
+type IComments interface {
+	TaskIDs(ctx context.Context, after string, limit int64) ([]string, error)
+	DeleteTaskComments(ctx context.Context, taskIds []string) (int64, error)
+}
+
+type IReactions interface {
+	TaskIDs(ctx context.Context, after string, limit int64) ([]string, error)
+	DeleteTaskReactions(ctx context.Context, taskIds []string) (int64, error)
+}
+
+type IMentions interface {
+	TaskIDs(ctx context.Context, after string, limit int64) ([]string, error)
+	DeleteTaskMentions(ctx context.Context, taskIds []string) (int64, error)
+}
+
+// batchSize bounds the number of tasks deleted per round trip.
+const batchSize = 100
+
+// Purger hard deletes tasks, and their comments, reactions and mentions,
+// archived for longer than the retention period. It also removes the
+// comments, reactions and mentions left behind by deleted tasks whose
+// cleanup failed, a zero retention only does the latter.
+type Purger struct {
+	tasks     ITasks
+	comments  IComments
+	reactions IReactions
+	mentions  IMentions
+	retention time.Duration
+	time      func() time.Time
+}
+
+func NewPurger(tasks ITasks, comments IComments, reactions IReactions, mentions IMentions, retention time.Duration) *Purger {
+	return &Purger{tasks: tasks, comments: comments, reactions: reactions, mentions: mentions, retention: retention}
+}
+
+// Run deletes expired tasks, then the comments, reactions and mentions of
+// tasks that no longer exist.
+func (p *Purger) Run(ctx context.Context) error {
+	if p.retention > 0 {
+		if err := p.purgeArchived(ctx); err != nil {
+			return err
+		}
+	}
+	return p.purgeOrphans(ctx)
+}
+
+// purgeArchived deletes expired tasks in batches until none are left. Tasks
+// go first and only the comments, reactions and mentions of the tasks
+// actually deleted follow, so that a task unarchived meanwhile keeps them.
+func (p *Purger) purgeArchived(ctx context.Context) error {
+	before := p.now().Add(-p.retention).Unix()
+	for {
+		ids, err := p.tasks.ArchivedBefore(ctx, before, batchSize)
+		if err != nil {
+			log.Printf("purge archived tasks: %v", err)
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		// tasks deleted before a failure still lose their comments and the rest
+		deleted, deleteErr := p.tasks.DeleteTasks(ctx, ids)
+		if err := p.deleteChildren(ctx, deleted); err != nil {
+			return err
+		}
+		if deleteErr != nil {
+			log.Printf("purge archived tasks: %v", deleteErr)
+			return deleteErr
+		}
+		log.Printf("purged %v archived tasks", len(deleted))
+		if len(ids) < batchSize || len(deleted) == 0 {
+			return nil
+		}
+	}
+}
+
+// purgeOrphans pages through the tasks having comments, reactions or
+// mentions and deletes those of tasks that no longer exist. Deleting a task
+// and its children is not atomic, this retries a cleanup that failed after
+// the task was gone.
+func (p *Purger) purgeOrphans(ctx context.Context) error {
+	children := []struct {
+		name    string
+		taskIds func(ctx context.Context, after string, limit int64) ([]string, error)
+		delete  func(ctx context.Context, taskIds []string) (int64, error)
+	}{
+		{"comments", p.comments.TaskIDs, p.comments.DeleteTaskComments},
+		{"reactions", p.reactions.TaskIDs, p.reactions.DeleteTaskReactions},
+		{"mentions", p.mentions.TaskIDs, p.mentions.DeleteTaskMentions},
+	}
+	for _, child := range children {
+		after := ""
+		for {
+			ids, err := child.taskIds(ctx, after, batchSize)
+			if err != nil {
+				log.Printf("purge %v of deleted tasks: %v", child.name, err)
+				return err
+			}
+			if len(ids) == 0 {
+				break
+			}
+			missing, err := p.tasks.MissingTasks(ctx, ids)
+			if err != nil {
+				log.Printf("purge %v of deleted tasks: %v", child.name, err)
+				return err
+			}
+			if len(missing) > 0 {
+				if _, err := child.delete(ctx, missing); err != nil {
+					log.Printf("purge %v of deleted tasks: %v", child.name, err)
+					return err
+				}
+				log.Printf("purged %v of %v deleted tasks", child.name, len(missing))
+			}
+			if len(ids) < batchSize {
+				break
+			}
+			after = ids[len(ids)-1]
+		}
+	}
+	return nil
+}
+
+func (p *Purger) deleteChildren(ctx context.Context, taskIds []string) error {
+	if len(taskIds) == 0 {
+		return nil
+	}
+	if _, err := p.comments.DeleteTaskComments(ctx, taskIds); err != nil {
+		log.Printf("purge comments of archived tasks: %v", err)
+		return err
+	}
+	if _, err := p.reactions.DeleteTaskReactions(ctx, taskIds); err != nil {
+		log.Printf("purge reactions of archived tasks: %v", err)
+		return err
+	}
+	if _, err := p.mentions.DeleteTaskMentions(ctx, taskIds); err != nil {
+		log.Printf("purge mentions of archived tasks: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (p *Purger) now() time.Time {
+	if p.time == nil {
+		return time.Now()
+	}
+
+	return p.time()
+}
@@ -0,0 +1,184 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	mock_retention "task-manager-api/internal/retention/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+)
+
+type RetentionTestSuite struct {
+	suite.Suite
+	ctrl      *gomock.Controller
+	tasks     *mock_retention.MockITasks
+	comments  *mock_retention.MockIComments
+	reactions *mock_retention.MockIReactions
+	mentions  *mock_retention.MockIMentions
+	purger    *Purger
+}
+
+func (t *RetentionTestSuite) SetupTest() {
+	t.ctrl = gomock.NewController(t.T())
+	t.tasks = mock_retention.NewMockITasks(t.ctrl)
+	t.comments = mock_retention.NewMockIComments(t.ctrl)
+	t.reactions = mock_retention.NewMockIReactions(t.ctrl)
+	t.mentions = mock_retention.NewMockIMentions(t.ctrl)
+	t.purger = NewPurger(t.tasks, t.comments, t.reactions, t.mentions, 24*time.Hour)
+	t.purger.time = func() time.Time {
+		return time.Unix(1569130951, 0)
+	}
+}
+
+func (t *RetentionTestSuite) TearDownTest() {
+	t.ctrl.Finish()
+	t.tasks = nil
+	t.comments = nil
+	t.reactions = nil
+	t.mentions = nil
+	t.purger = nil
+}
+
+func TestRetentionTestSuite(t *testing.T) {
+	suite.Run(t, new(RetentionTestSuite))
+}
+
+func (t *RetentionTestSuite) expectNoOrphans() *gomock.Call {
+	t.comments.EXPECT().TaskIDs(context.Background(), "", int64(batchSize)).Return([]string{}, nil)
+	t.reactions.EXPECT().TaskIDs(context.Background(), "", int64(batchSize)).Return([]string{}, nil)
+	return t.mentions.EXPECT().TaskIDs(context.Background(), "", int64(batchSize)).Return([]string{}, nil)
+}
+
+func (t *RetentionTestSuite) TestRun() {
+	before := int64(1569130951 - 24*60*60)
+
+	t.Run("run without expired tasks deletes nothing", func() {
+		t.tasks.EXPECT().ArchivedBefore(context.Background(), before, int64(batchSize)).Return([]string{}, nil)
+		t.expectNoOrphans()
+		t.NoError(t.purger.Run(context.Background()))
+	})
+
+	t.Run("run deletes comments, reactions and mentions after tasks", func() {
+		ids := []string{"645b9183fcfbc11433e23ab3"}
+		gomock.InOrder(
+			t.tasks.EXPECT().ArchivedBefore(context.Background(), before, int64(batchSize)).Return(ids, nil),
+			t.tasks.EXPECT().DeleteTasks(context.Background(), ids).Return(ids, nil),
+			t.comments.EXPECT().DeleteTaskComments(context.Background(), ids).Return(int64(2), nil),
+			t.reactions.EXPECT().DeleteTaskReactions(context.Background(), ids).Return(int64(0), nil),
+			t.mentions.EXPECT().DeleteTaskMentions(context.Background(), ids).Return(int64(0), nil),
+			t.expectNoOrphans(),
+		)
+		t.NoError(t.purger.Run(context.Background()))
+	})
+
+	t.Run("run only deletes comments, reactions and mentions of deleted tasks", func() {
+		ids := []string{"645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab4"}
+		deleted := []string{"645b9183fcfbc11433e23ab4"}
+		gomock.InOrder(
+			t.tasks.EXPECT().ArchivedBefore(context.Background(), before, int64(batchSize)).Return(ids, nil),
+			t.tasks.EXPECT().DeleteTasks(context.Background(), ids).Return(deleted, nil),
+			t.comments.EXPECT().DeleteTaskComments(context.Background(), deleted).Return(int64(1), nil),
+			t.reactions.EXPECT().DeleteTaskReactions(context.Background(), deleted).Return(int64(0), nil),
+			t.mentions.EXPECT().DeleteTaskMentions(context.Background(), deleted).Return(int64(0), nil),
+			t.expectNoOrphans(),
+		)
+		t.NoError(t.purger.Run(context.Background()))
+	})
+
+	t.Run("run continues with the next batch when a batch is full", func() {
+		full := make([]string, batchSize)
+		rest := []string{"645b9183fcfbc11433e23ab3"}
+		gomock.InOrder(
+			t.tasks.EXPECT().ArchivedBefore(context.Background(), before, int64(batchSize)).Return(full, nil),
+			t.tasks.EXPECT().DeleteTasks(context.Background(), full).Return(full, nil),
+			t.comments.EXPECT().DeleteTaskComments(context.Background(), full).Return(int64(0), nil),
+			t.reactions.EXPECT().DeleteTaskReactions(context.Background(), full).Return(int64(0), nil),
+			t.mentions.EXPECT().DeleteTaskMentions(context.Background(), full).Return(int64(0), nil),
+			t.tasks.EXPECT().ArchivedBefore(context.Background(), before, int64(batchSize)).Return(rest, nil),
+			t.tasks.EXPECT().DeleteTasks(context.Background(), rest).Return(rest, nil),
+			t.comments.EXPECT().DeleteTaskComments(context.Background(), rest).Return(int64(0), nil),
+			t.reactions.EXPECT().DeleteTaskReactions(context.Background(), rest).Return(int64(0), nil),
+			t.mentions.EXPECT().DeleteTaskMentions(context.Background(), rest).Return(int64(0), nil),
+			t.expectNoOrphans(),
+		)
+		t.NoError(t.purger.Run(context.Background()))
+	})
+
+	t.Run("run keeps comments when deleting tasks fails", func() {
+		ids := []string{"645b9183fcfbc11433e23ab3"}
+		t.tasks.EXPECT().ArchivedBefore(context.Background(), before, int64(batchSize)).Return(ids, nil)
+		t.tasks.EXPECT().DeleteTasks(context.Background(), ids).Return(nil, errors.New("find one and delete error"))
+		t.EqualError(t.purger.Run(context.Background()), "find one and delete error")
+	})
+
+	t.Run("run deletes comments of tasks deleted before a failure", func() {
+		ids := []string{"645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab4"}
+		deleted := []string{"645b9183fcfbc11433e23ab3"}
+		t.tasks.EXPECT().ArchivedBefore(context.Background(), before, int64(batchSize)).Return(ids, nil)
+		t.tasks.EXPECT().DeleteTasks(context.Background(), ids).Return(deleted, errors.New("find one and delete error"))
+		t.comments.EXPECT().DeleteTaskComments(context.Background(), deleted).Return(int64(1), nil)
+		t.reactions.EXPECT().DeleteTaskReactions(context.Background(), deleted).Return(int64(0), nil)
+		t.mentions.EXPECT().DeleteTaskMentions(context.Background(), deleted).Return(int64(0), nil)
+		t.EqualError(t.purger.Run(context.Background()), "find one and delete error")
+	})
+
+	t.Run("run stops when deleting reactions fails", func() {
+		ids := []string{"645b9183fcfbc11433e23ab3"}
+		t.tasks.EXPECT().ArchivedBefore(context.Background(), before, int64(batchSize)).Return(ids, nil)
+		t.tasks.EXPECT().DeleteTasks(context.Background(), ids).Return(ids, nil)
+		t.comments.EXPECT().DeleteTaskComments(context.Background(), ids).Return(int64(0), nil)
+		t.reactions.EXPECT().DeleteTaskReactions(context.Background(), ids).Return(int64(0), errors.New("delete many error"))
+		t.EqualError(t.purger.Run(context.Background()), "delete many error")
+	})
+}
+
+func (t *RetentionTestSuite) TestPurgeOrphans() {
+	t.Run("run without retention only deletes children of deleted tasks", func() {
+		purger := NewPurger(t.tasks, t.comments, t.reactions, t.mentions, 0)
+		t.expectNoOrphans()
+		t.NoError(purger.Run(context.Background()))
+	})
+
+	t.Run("run deletes comments, reactions and mentions of tasks that no longer exist", func() {
+		purger := NewPurger(t.tasks, t.comments, t.reactions, t.mentions, 0)
+		ids := []string{"645b9183fcfbc11433e23ab3", "645b9183fcfbc11433e23ab4"}
+		missing := []string{"645b9183fcfbc11433e23ab4"}
+		gomock.InOrder(
+			t.comments.EXPECT().TaskIDs(context.Background(), "", int64(batchSize)).Return(ids, nil),
+			t.tasks.EXPECT().MissingTasks(context.Background(), ids).Return(missing, nil),
+			t.comments.EXPECT().DeleteTaskComments(context.Background(), missing).Return(int64(3), nil),
+			t.reactions.EXPECT().TaskIDs(context.Background(), "", int64(batchSize)).Return(missing, nil),
+			t.tasks.EXPECT().MissingTasks(context.Background(), missing).Return(missing, nil),
+			t.reactions.EXPECT().DeleteTaskReactions(context.Background(), missing).Return(int64(1), nil),
+			t.mentions.EXPECT().TaskIDs(context.Background(), "", int64(batchSize)).Return(ids[:1], nil),
+			t.tasks.EXPECT().MissingTasks(context.Background(), ids[:1]).Return([]string{}, nil),
+		)
+		t.NoError(purger.Run(context.Background()))
+	})
+
+	t.Run("run pages through the task ids after a full batch", func() {
+		purger := NewPurger(t.tasks, t.comments, t.reactions, t.mentions, 0)
+		full := make([]string, batchSize)
+		full[batchSize-1] = "645b9183fcfbc11433e23ab3"
+		gomock.InOrder(
+			t.comments.EXPECT().TaskIDs(context.Background(), "", int64(batchSize)).Return(full, nil),
+			t.tasks.EXPECT().MissingTasks(context.Background(), full).Return([]string{}, nil),
+			t.comments.EXPECT().TaskIDs(context.Background(), "645b9183fcfbc11433e23ab3", int64(batchSize)).Return([]string{}, nil),
+		)
+		t.reactions.EXPECT().TaskIDs(context.Background(), "", int64(batchSize)).Return([]string{}, nil)
+		t.mentions.EXPECT().TaskIDs(context.Background(), "", int64(batchSize)).Return([]string{}, nil)
+		t.NoError(purger.Run(context.Background()))
+	})
+
+	t.Run("run stops when deleting orphaned comments fails", func() {
+		purger := NewPurger(t.tasks, t.comments, t.reactions, t.mentions, 0)
+		ids := []string{"645b9183fcfbc11433e23ab3"}
+		t.comments.EXPECT().TaskIDs(context.Background(), "", int64(batchSize)).Return(ids, nil)
+		t.tasks.EXPECT().MissingTasks(context.Background(), ids).Return(ids, nil)
+		t.comments.EXPECT().DeleteTaskComments(context.Background(), ids).Return(int64(0), errors.New("delete many error"))
+		t.EqualError(purger.Run(context.Background()), "delete many error")
+	})
+}
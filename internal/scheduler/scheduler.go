@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Runner is a background job, it logs its own failures.
+type Runner interface {
+	Run(ctx context.Context) error
+}
+
+// Scheduler runs a job on an interval in the background.
+type Scheduler struct {
+	runner   Runner
+	interval time.Duration
+	timeout  time.Duration
+	stop     chan struct{}
+	done     sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler running every interval, each run is
+// cancelled after timeout.
+func NewScheduler(runner Runner, interval time.Duration, timeout time.Duration) *Scheduler {
+	return &Scheduler{runner: runner, interval: interval, timeout: timeout, stop: make(chan struct{})}
+}
+
+// Start runs once right away and then on every tick until Stop is called.
+func (s *Scheduler) Start() {
+	s.done.Add(1)
+	go func() {
+		defer s.done.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			s.run()
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop waits for a run in progress to finish.
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	s.done.Wait()
+}
+
+func (s *Scheduler) run() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	_ = s.runner.Run(ctx)
+}
@@ -0,0 +1,49 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SchedulerTestSuite struct {
+	suite.Suite
+}
+
+func TestSchedulerTestSuite(t *testing.T) {
+	suite.Run(t, new(SchedulerTestSuite))
+}
+
+type countingRunner struct {
+	runs int32
+}
+
+func (r *countingRunner) Run(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return nil
+	}
+	atomic.AddInt32(&r.runs, 1)
+	return nil
+}
+
+func (t *SchedulerTestSuite) TestStartStop() {
+	t.Run("start runs right away and stop waits for the loop", func() {
+		runner := &countingRunner{}
+		s := NewScheduler(runner, time.Hour, time.Second)
+		s.Start()
+		t.Eventually(func() bool { return atomic.LoadInt32(&runner.runs) == 1 }, time.Second, time.Millisecond)
+		s.Stop()
+		t.Equal(int32(1), atomic.LoadInt32(&runner.runs))
+	})
+
+	t.Run("start runs again on every tick", func() {
+		runner := &countingRunner{}
+		s := NewScheduler(runner, time.Millisecond, time.Second)
+		s.Start()
+		t.Eventually(func() bool { return atomic.LoadInt32(&runner.runs) >= 3 }, time.Second, time.Millisecond)
+		s.Stop()
+	})
+}
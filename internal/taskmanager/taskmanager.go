@@ -3,6 +3,11 @@ package taskmanager
 import (
 	"context"
 	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"task-manager-api/internal/apperror"
+	"task-manager-api/internal/history"
 	m "task-manager-api/internal/mongo"
 	"time"
 
@@ -17,18 +22,94 @@ type IMongo interface {
 	InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error)
 	FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) m.SingleResult
 	UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+	FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...*options.FindOneAndUpdateOptions) m.SingleResult
+	FindOneAndDelete(ctx context.Context, filter interface{}, opts ...*options.FindOneAndDeleteOptions) m.SingleResult
 	Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (m.Cursor, error)
+	CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error)
+	Aggregate(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (m.Cursor, error)
+	CreateIndexes(ctx context.Context, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error)
+}
+
+type IHistory interface {
+	Record(ctx context.Context, changes ...history.Change) error
 }
 
 type TaskManager struct {
-	mongo IMongo
-	time  func() time.Time
+	mongo    IMongo
+	workflow *Workflow
+	history  IHistory
+	time     func() time.Time
 }
 
-func NewTaskManager(mongo IMongo) *TaskManager {
-	return &TaskManager{mongo: mongo}
+type Option func(*TaskManager)
+
+// WithWorkflow replaces the default workflow used for status changes.
+func WithWorkflow(workflow *Workflow) Option {
+	return func(t *TaskManager) {
+		t.workflow = workflow
+	}
+}
+
+// WithHistory records every change made to tasks.
+func WithHistory(history IHistory) Option {
+	return func(t *TaskManager) {
+		t.history = history
+	}
 }
 
+func NewTaskManager(mongo IMongo, opts ...Option) *TaskManager {
+	t := &TaskManager{mongo: mongo, workflow: DefaultWorkflow()}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+var (
+	ErrTaskNotFound     = apperror.NotFound("task_not_found", "Task not found")
+	ErrInvalidTaskID    = apperror.Validation("invalid_task_id", "Invalid task id")
+	ErrInvalidSort      = apperror.Validation("invalid_sort", "Invalid sort field")
+	ErrNothingToUpdate  = apperror.Validation("nothing_to_update", "Nothing to update")
+	ErrInvalidSchedule  = apperror.Validation("invalid_schedule", "Start date must not be after due date")
+	ErrInvalidPriority  = apperror.Validation("invalid_priority", "Invalid priority")
+	ErrInvalidLabel     = apperror.Validation("invalid_label", "Invalid label")
+	ErrInvalidParent    = apperror.Validation("invalid_parent", "Parent task not found")
+	ErrInvalidBlocker   = apperror.Validation("invalid_blocker", "Blocking task not found")
+	ErrDependencyCycle  = apperror.Conflict("dependency_cycle", "Dependency would create a cycle")
+	ErrTaskBlocked      = apperror.Conflict("task_blocked", "Task is blocked by unfinished tasks")
+	ErrOccurrenceExists = apperror.Conflict("occurrence_exists", "Occurrence was already created")
+	ErrVersionMismatch  = apperror.Precondition("version_mismatch", "Task was modified, reload it and try again")
+	ErrTaskArchived     = apperror.Conflict("task_archived", "Task is archived")
+	ErrTaskNotArchived  = apperror.Conflict("task_not_archived", "Task is not archived")
+)
+
+// Priority levels, a task without priority is PriorityNone.
+const (
+	PriorityNone = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+	PriorityUrgent
+)
+
+var priorityNames = []string{"none", "low", "medium", "high", "urgent"}
+
+// ParsePriority accepts a priority level by name or number.
+func ParsePriority(value string) (int, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for level, name := range priorityNames {
+		if value == name || value == strconv.Itoa(level) {
+			return level, nil
+		}
+	}
+	return 0, ErrInvalidPriority
+}
+
+func ValidPriority(priority int) bool {
+	return priority >= PriorityNone && priority <= PriorityUrgent
+}
+
+// Statuses of the default workflow.
 const (
 	TaskStatusOpen = iota + 1
 	TaskStatusInProgress
@@ -36,50 +117,150 @@ const (
 )
 
 type TaskDoc struct {
-	ID          string `json:"id" bson:"_id,omitempty"`
-	Topic       string `json:"topic" bson:"topic"`
-	Description string `json:"description" bson:"description"`
-	Status      int    `json:"status" bson:"status"`
-	CreateDate  int64  `json:"create_date" bson:"create_date"`
-	OwnerID     string `json:"owner_id" bson:"owner_id"`
-	ArchiveDate *int64 `json:"archive_date" bson:"archive_date"`
-	UpdateDate  *int64 `json:"update_date" bson:"update_date"`
+	ID          string    `json:"id" bson:"_id,omitempty"`
+	Topic       string    `json:"topic" bson:"topic"`
+	Description string    `json:"description" bson:"description"`
+	Status      int       `json:"status" bson:"status"`
+	CreateDate  int64     `json:"create_date" bson:"create_date"`
+	OwnerID     string    `json:"owner_id" bson:"owner_id"`
+	ArchiveDate *int64    `json:"archive_date" bson:"archive_date"`
+	UpdateDate  *int64    `json:"update_date" bson:"update_date"`
+	Assignees   []string  `json:"assignees,omitempty" bson:"assignees,omitempty"`
+	StartDate   *int64    `json:"start_date,omitempty" bson:"start_date,omitempty"`
+	DueDate     *int64    `json:"due_date,omitempty" bson:"due_date,omitempty"`
+	Priority    int       `json:"priority" bson:"priority"`
+	Labels      []string  `json:"labels,omitempty" bson:"labels,omitempty"`
+	ParentID    string    `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	Progress    *Progress `json:"progress,omitempty" bson:"-"`
+	BlockedBy   []string  `json:"blocked_by,omitempty" bson:"blocked_by,omitempty"`
+	Blocks      []string  `json:"blocks,omitempty" bson:"-"`
+	// RecurrenceID and Occurrence identify a task generated by a recurring series.
+	RecurrenceID string `json:"recurrence_id,omitempty" bson:"recurrence_id,omitempty"`
+	Occurrence   *int64 `json:"occurrence,omitempty" bson:"occurrence,omitempty"`
+	// Version goes up by one on every change, tasks created before it existed start at 0.
+	Version int64 `json:"version" bson:"version"`
 }
 
-func (t *TaskManager) CreateTask(ctx context.Context, ownerId string, topic string, desc string) (*TaskDoc, error) {
-	// create new task
-	// TODO: some other business logic here
-	now := t.now().Unix()
-	result, err := t.mongo.InsertOne(ctx, TaskDoc{
-		Topic:       topic,
-		Description: desc,
-		Status:      TaskStatusOpen,
-		CreateDate:  now,
-		OwnerID:     ownerId,
-	})
-	if err != nil {
-		// TODO: log error
-		return nil, err
+// Progress summarises the subtasks of a task, Done counts subtasks in a terminal state.
+type Progress struct {
+	Total   int64 `json:"total" bson:"total"`
+	Done    int64 `json:"done" bson:"done"`
+	Percent int   `json:"percent" bson:"-"`
+}
+
+// LabelCount is the number of tasks carrying a label.
+type LabelCount struct {
+	Label string `json:"label" bson:"_id"`
+	Count int64  `json:"count" bson:"count"`
+}
+
+const maxLabelLength = 50
+
+// NormalizeLabels trims and lower cases labels so "Bug " and "bug" are the
+// same label, and drops duplicates. Empty or overlong labels are rejected.
+func NormalizeLabels(labels []string) ([]string, error) {
+	normalized := make([]string, 0, len(labels))
+	seen := map[string]bool{}
+	for _, label := range labels {
+		label = strings.ToLower(strings.TrimSpace(label))
+		if label == "" || len(label) > maxLabelLength {
+			return nil, ErrInvalidLabel
+		}
+		if !seen[label] {
+			seen[label] = true
+			normalized = append(normalized, label)
+		}
 	}
+	return normalized, nil
+}
 
-	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
-		return &TaskDoc{
-			ID:          oid.Hex(),
-			Topic:       topic,
-			Description: desc,
-			Status:      TaskStatusOpen,
-			CreateDate:  now,
-			OwnerID:     ownerId,
-		}, nil
-	} else {
-		// TODO: log error
-		return nil, errors.New("cannot convert inserted id to object id")
+// NewTask holds the fields a task can be created with.
+type NewTask struct {
+	Topic       string
+	Description string
+	Priority    int
+	// ParentID makes the task a subtask of another task of the same owner.
+	ParentID  string
+	StartDate *int64
+	DueDate   *int64
+	// RecurrenceID and Occurrence are set for tasks of a recurring series,
+	// each occurrence can only be created once.
+	RecurrenceID string
+	Occurrence   *int64
+}
+
+// sortableFields is the whitelist of fields GetAllTask can sort on.
+var sortableFields = map[string]bool{
+	"create_date":  true,
+	"update_date":  true,
+	"status":       true,
+	"topic":        true,
+	"start_date":   true,
+	"due_date":     true,
+	"archive_date": true,
+	"priority":     true,
+}
+
+// sortPresets are named orderings usable in place of a field in ParseSort.
+var sortPresets = map[string][]SortField{
+	// triage shows the most urgent work first, then the earliest due.
+	// Tasks without a due date come first within a priority because
+	// MongoDB sorts missing values before any date.
+	"triage": {{Field: "priority", Desc: true}, {Field: "due_date"}},
+}
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort parses a comma separated sort expression such as "-create_date,topic",
+// a leading "-" sorts descending. Only whitelisted fields and presets are accepted.
+func ParseSort(value string) ([]SortField, error) {
+	var fields []SortField
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if preset, ok := sortPresets[item]; ok {
+			fields = append(fields, preset...)
+			continue
+		}
+		field := SortField{Field: strings.TrimPrefix(item, "-"), Desc: strings.HasPrefix(item, "-")}
+		if !sortableFields[field.Field] {
+			return nil, ErrInvalidSort
+		}
+		fields = append(fields, field)
 	}
+	return fields, nil
 }
 
-func (t *TaskManager) GetAllTask(ctx context.Context, page int, limit int) ([]TaskDoc, error) {
-	// find all task with pagination
-	curr, err := t.mongo.Find(ctx, bson.M{
+// TaskFilter narrows GetAllTask, zero values are ignored.
+type TaskFilter struct {
+	OwnerID  string
+	Assignee string
+	ParentID string
+	Status   []int
+	Priority []int
+	Labels   []string
+	// AllLabels requires every label to match instead of any of them.
+	AllLabels   bool
+	CreatedFrom *int64
+	CreatedTo   *int64
+	UpdatedFrom *int64
+	UpdatedTo   *int64
+	DueAfter    *int64
+	DueBefore   *int64
+	// Overdue keeps tasks not in a terminal state whose due date has passed.
+	Overdue bool
+	// Archived lists archived tasks instead of active ones.
+	Archived bool
+	Sort     []SortField
+}
+
+func (f TaskFilter) query(now time.Time, terminal []int) bson.M {
+	query := bson.M{
 		"$or": []bson.M{
 			{
 				"archive_date": bson.M{
@@ -90,22 +271,321 @@ func (t *TaskManager) GetAllTask(ctx context.Context, page int, limit int) ([]Ta
 				"archive_date": nil,
 			},
 		},
-	}, m.NewMongoPaginate(limit, page).GetPaginatedOpts())
+	}
+	if f.Archived {
+		query = bson.M{"archive_date": bson.M{"$ne": nil}}
+	}
+	if f.OwnerID != "" {
+		query["owner_id"] = f.OwnerID
+	}
+	if f.Assignee != "" {
+		query["assignees"] = f.Assignee
+	}
+	if f.ParentID != "" {
+		query["parent_id"] = f.ParentID
+	}
+	if len(f.Status) > 0 {
+		query["status"] = bson.M{"$in": f.Status}
+	}
+	if len(f.Priority) > 0 {
+		priority := bson.M{"priority": bson.M{"$in": f.Priority}}
+		if f.hasPriority(PriorityNone) {
+			// tasks created before priorities have no priority field
+			query["$and"] = []bson.M{{"$or": []bson.M{priority, {"priority": bson.M{"$exists": false}}}}}
+		} else {
+			query["priority"] = priority["priority"]
+		}
+	}
+	if len(f.Labels) > 0 {
+		if f.AllLabels {
+			query["labels"] = bson.M{"$all": f.Labels}
+		} else {
+			query["labels"] = bson.M{"$in": f.Labels}
+		}
+	}
+	if r := dateRange(f.CreatedFrom, f.CreatedTo); r != nil {
+		query["create_date"] = r
+	}
+	if r := dateRange(f.UpdatedFrom, f.UpdatedTo); r != nil {
+		query["update_date"] = r
+	}
+	if r := dateRange(f.DueAfter, f.DueBefore); r != nil || f.Overdue {
+		if r == nil {
+			r = bson.M{}
+		}
+		if f.Overdue {
+			r["$lt"] = now.Unix()
+		}
+		query["due_date"] = r
+	}
+	if f.Overdue {
+		status, _ := query["status"].(bson.M)
+		if status == nil {
+			status = bson.M{}
+		}
+		status["$nin"] = terminal
+		query["status"] = status
+	}
+	return query
+}
 
+func (f TaskFilter) hasPriority(priority int) bool {
+	for _, p := range f.Priority {
+		if p == priority {
+			return true
+		}
+	}
+	return false
+}
+
+// sort appends _id as a tie breaker so pages stay stable when sort keys repeat.
+func (f TaskFilter) sort() bson.D {
+	if len(f.Sort) == 0 {
+		return nil
+	}
+	sort := make(bson.D, 0, len(f.Sort)+1)
+	for _, field := range f.Sort {
+		sort = append(sort, bson.E{Key: field.Field, Value: direction(field.Desc)})
+	}
+	return append(sort, bson.E{Key: "_id", Value: direction(f.Sort[len(f.Sort)-1].Desc)})
+}
+
+func dateRange(from *int64, to *int64) bson.M {
+	if from == nil && to == nil {
+		return nil
+	}
+	r := bson.M{}
+	if from != nil {
+		r["$gte"] = *from
+	}
+	if to != nil {
+		r["$lte"] = *to
+	}
+	return r
+}
+
+func direction(desc bool) int {
+	if desc {
+		return -1
+	}
+	return 1
+}
+
+// TaskUpdate holds the editable fields of a task, nil fields are left untouched.
+// A zero StartDate or DueDate clears the date. Status follows the rules of
+// UpdateTaskStatus, Force letting it leave the initial state of a blocked task.
+type TaskUpdate struct {
+	Topic       *string
+	Description *string
+	Priority    *int
+	StartDate   *int64
+	DueDate     *int64
+	Status      *int
+	Force       bool
+}
+
+func (u TaskUpdate) fields() bson.M {
+	fields := bson.M{}
+	if u.Topic != nil {
+		fields["topic"] = *u.Topic
+	}
+	if u.Description != nil {
+		fields["description"] = *u.Description
+	}
+	if u.Priority != nil {
+		fields["priority"] = *u.Priority
+	}
+	if u.StartDate != nil && *u.StartDate != 0 {
+		fields["start_date"] = *u.StartDate
+	}
+	if u.DueDate != nil && *u.DueDate != 0 {
+		fields["due_date"] = *u.DueDate
+	}
+	if u.Status != nil {
+		fields["status"] = *u.Status
+	}
+	return fields
+}
+
+// changes lists the supplied fields with their value before the update.
+func (u TaskUpdate) changes(before *TaskDoc) []history.Change {
+	var changes []history.Change
+	if u.Topic != nil {
+		changes = append(changes, history.Change{Field: "topic", Old: before.Topic, New: *u.Topic})
+	}
+	if u.Description != nil {
+		changes = append(changes, history.Change{Field: "description", Old: before.Description, New: *u.Description})
+	}
+	if u.Priority != nil {
+		changes = append(changes, history.Change{Field: "priority", Old: before.Priority, New: *u.Priority})
+	}
+	if u.StartDate != nil {
+		changes = append(changes, history.Change{Field: "start_date", Old: before.StartDate, New: nonZero(u.StartDate)})
+	}
+	if u.DueDate != nil {
+		changes = append(changes, history.Change{Field: "due_date", Old: before.DueDate, New: nonZero(u.DueDate)})
+	}
+	if u.Status != nil {
+		changes = append(changes, history.Change{Field: "status", Old: before.Status, New: *u.Status})
+	}
+	return changes
+}
+
+// nonZero returns nil for a cleared date.
+func nonZero(date *int64) *int64 {
+	if date == nil || *date == 0 {
+		return nil
+	}
+	return date
+}
+
+// cleared returns the dates the update removes.
+func (u TaskUpdate) cleared() bson.M {
+	fields := bson.M{}
+	if u.StartDate != nil && *u.StartDate == 0 {
+		fields["start_date"] = ""
+	}
+	if u.DueDate != nil && *u.DueDate == 0 {
+		fields["due_date"] = ""
+	}
+	return fields
+}
+
+// validSchedule reports whether start is not after due, a missing date is always valid.
+func validSchedule(start *int64, due *int64) bool {
+	return start == nil || due == nil || *start == 0 || *due == 0 || *start <= *due
+}
+
+// scheduleGuard checks a date set alone against the date stored on the task,
+// the dates set together are checked by validSchedule.
+func (u TaskUpdate) scheduleGuard() []guard {
+	switch {
+	case u.StartDate != nil && *u.StartDate != 0 && u.DueDate == nil:
+		return []guard{{filter: bson.M{"due_date": bson.M{"$not": bson.M{"$lt": *u.StartDate}}}, err: ErrInvalidSchedule}}
+	case u.DueDate != nil && *u.DueDate != 0 && u.StartDate == nil:
+		return []guard{{filter: bson.M{"start_date": bson.M{"$not": bson.M{"$gt": *u.DueDate}}}, err: ErrInvalidSchedule}}
+	}
+	return nil
+}
+
+// EnsureIndexes creates the indexes backing the GetAllTask filters and sorts.
+func (t *TaskManager) EnsureIndexes(ctx context.Context) error {
+	models := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "create_date", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "update_date", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "topic", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "create_date", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "create_date", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "update_date", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "assignees", Value: 1}, {Key: "create_date", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "due_date", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "start_date", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "priority", Value: -1}, {Key: "due_date", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "labels", Value: 1}, {Key: "create_date", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "parent_id", Value: 1}, {Key: "create_date", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "blocked_by", Value: 1}}},
+		{Keys: bson.D{{Key: "archive_date", Value: 1}}},
+		{
+			Keys: bson.D{{Key: "recurrence_id", Value: 1}, {Key: "occurrence", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"recurrence_id": bson.M{"$exists": true}}),
+		},
+	}
+	if _, err := t.mongo.CreateIndexes(ctx, models); err != nil {
+		return m.WrapError(err)
+	}
+	return nil
+}
+
+func (t *TaskManager) CreateTask(ctx context.Context, ownerId string, task NewTask) (*TaskDoc, error) {
+	// create new task
+	// TODO: some other business logic here
+	if !validSchedule(task.StartDate, task.DueDate) {
+		return nil, ErrInvalidSchedule
+	}
+	if !ValidPriority(task.Priority) {
+		return nil, ErrInvalidPriority
+	}
+	if task.ParentID != "" {
+		parent, err := t.FindTask(ctx, task.ParentID)
+		if err != nil {
+			if errors.Is(err, ErrTaskNotFound) || errors.Is(err, ErrInvalidTaskID) {
+				return nil, ErrInvalidParent.Wrap(err)
+			}
+			return nil, err
+		}
+		if parent.OwnerID != ownerId || parent.ArchiveDate != nil {
+			return nil, ErrInvalidParent
+		}
+		task.ParentID = parent.ID
+	}
+	doc := TaskDoc{
+		Topic:        task.Topic,
+		Description:  task.Description,
+		Status:       t.workflow.Initial(),
+		Priority:     task.Priority,
+		CreateDate:   t.now().Unix(),
+		OwnerID:      ownerId,
+		StartDate:    task.StartDate,
+		DueDate:      task.DueDate,
+		ParentID:     task.ParentID,
+		RecurrenceID: task.RecurrenceID,
+		Occurrence:   task.Occurrence,
+		Version:      1,
+	}
+	result, err := t.mongo.InsertOne(ctx, doc)
 	if err != nil {
-		// TODO: log error
-		return nil, err
+		if task.RecurrenceID != "" && mongo.IsDuplicateKeyError(err) {
+			return nil, ErrOccurrenceExists.Wrap(err)
+		}
+		return nil, m.WrapError(err)
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		doc.ID = oid.Hex()
+		t.record(ctx, ownerId, doc.ID, history.Change{Field: history.FieldCreated, New: doc.Topic})
+		return &doc, nil
+	} else {
+		return nil, apperror.ErrInternal.Wrap(errors.New("cannot convert inserted id to object id"))
+	}
+}
+
+func (t *TaskManager) GetAllTask(ctx context.Context, filter TaskFilter, page m.Pagination) ([]TaskDoc, *m.PageInfo, error) {
+	// find all task with pagination
+	sort := filter.sort()
+	query, opts, err := page.Apply(filter.query(t.now(), t.workflow.Terminal()), sort)
+	if err != nil {
+		return nil, nil, err
+	}
+	curr, err := t.mongo.Find(ctx, query, opts)
+	if err != nil {
+		return nil, nil, m.WrapError(err)
 	}
 	var tasks = make([]TaskDoc, 0)
 	if err := curr.All(ctx, &tasks); err != nil {
-		return nil, err
+		return nil, nil, m.WrapError(err)
+	}
+	tasks, pageInfo, err := m.Paginate(page, sort, tasks)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !page.SkipCount {
+		total, err := t.mongo.CountDocuments(ctx, filter.query(t.now(), t.workflow.Terminal()))
+		if err != nil {
+			return nil, nil, m.WrapError(err)
+		}
+		pageInfo.Total = &total
 	}
-	return tasks, nil
+	return tasks, pageInfo, nil
 }
 
 func (t *TaskManager) GetTask(ctx context.Context, id string) (*TaskDoc, error) {
 	// find task by id
-	objectId, _ := primitive.ObjectIDFromHex(id)
+	objectId, err := ParseTaskID(id)
+	if err != nil {
+		return nil, err
+	}
 	curr := t.mongo.FindOne(ctx, bson.M{
 		"_id": objectId,
 		"$or": []bson.M{
@@ -125,45 +605,770 @@ func (t *TaskManager) GetTask(ctx context.Context, id string) (*TaskDoc, error)
 	})
 	var task TaskDoc
 	if err := curr.Decode(&task); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrTaskNotFound.Wrap(err)
+		}
+		return nil, m.WrapError(err)
+	}
+	if task.Progress, err = t.progress(ctx, task.ID); err != nil {
+		return nil, err
+	}
+	if task.Blocks, err = t.blocks(ctx, task.ID); err != nil {
 		return nil, err
 	}
 	return &task, nil
 }
 
-func (t *TaskManager) UpdateTaskStatus(ctx context.Context, ownerId string, id string, status int) error {
+// blocks returns the ids of the tasks the task is blocking.
+func (t *TaskManager) blocks(ctx context.Context, id string) ([]string, error) {
+	query := TaskFilter{}.query(t.now(), t.workflow.Terminal())
+	query["blocked_by"] = id
+	curr, err := t.mongo.Find(ctx, query, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, m.WrapError(err)
+	}
+	var tasks []TaskDoc
+	if err := curr.All(ctx, &tasks); err != nil {
+		return nil, m.WrapError(err)
+	}
+	var ids []string
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	return ids, nil
+}
+
+// progress counts the subtasks of a task, it is nil when there are none.
+func (t *TaskManager) progress(ctx context.Context, id string) (*Progress, error) {
+	match := TaskFilter{ParentID: id}.query(t.now(), t.workflow.Terminal())
+	curr, err := t.mongo.Aggregate(ctx, []bson.M{
+		{"$match": match},
+		{"$group": bson.M{
+			"_id":   nil,
+			"total": bson.M{"$sum": 1},
+			"done": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$in": bson.A{"$status", t.workflow.Terminal()}}, 1, 0},
+			}},
+		}},
+	})
+	if err != nil {
+		return nil, m.WrapError(err)
+	}
+	var results []Progress
+	if err := curr.All(ctx, &results); err != nil {
+		return nil, m.WrapError(err)
+	}
+	if len(results) == 0 || results[0].Total == 0 {
+		return nil, nil
+	}
+	progress := results[0]
+	progress.Percent = int(progress.Done * 100 / progress.Total)
+	return &progress, nil
+}
+
+// FindTask returns the task whether or not it is archived.
+func (t *TaskManager) FindTask(ctx context.Context, id string) (*TaskDoc, error) {
+	objectId, err := ParseTaskID(id)
+	if err != nil {
+		return nil, err
+	}
+	var task TaskDoc
+	if err := t.mongo.FindOne(ctx, bson.M{"_id": objectId}).Decode(&task); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrTaskNotFound.Wrap(err)
+		}
+		return nil, m.WrapError(err)
+	}
+	return &task, nil
+}
+
+// FindOccurrence returns the task created for an occurrence of a recurring
+// series.
+func (t *TaskManager) FindOccurrence(ctx context.Context, recurrenceId string, occurrence int64) (*TaskDoc, error) {
+	var task TaskDoc
+	if err := t.mongo.FindOne(ctx, bson.M{
+		"recurrence_id": recurrenceId,
+		"occurrence":    occurrence,
+	}).Decode(&task); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrTaskNotFound.Wrap(err)
+		}
+		return nil, m.WrapError(err)
+	}
+	return &task, nil
+}
+
+// UpdateTaskStatus lets the owner or any assignee of the task change its status
+// along a transition allowed by the workflow. A task cannot leave the initial
+// state while a blocking task is unfinished unless force is set.
+func (t *TaskManager) UpdateTaskStatus(ctx context.Context, ownerId string, id string, status int, force bool, version *int64) error {
 	// update task status
-	objectId, _ := primitive.ObjectIDFromHex(id)
-	_, err := t.mongo.UpdateOne(ctx, bson.M{
-		"_id":      objectId,
-		"owner_id": ownerId,
-	}, bson.M{
+	objectId, err := ParseTaskID(id)
+	if err != nil {
+		return err
+	}
+	if !t.workflow.Valid(status) {
+		return ErrInvalidStatus
+	}
+
+	var task TaskDoc
+	if err := t.mongo.FindOne(ctx, bson.M{
+		"_id": objectId,
+		"$or": []bson.M{
+			{"owner_id": ownerId},
+			{"assignees": ownerId},
+		},
+	}).Decode(&task); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrTaskNotFound.Wrap(err)
+		}
+		return m.WrapError(err)
+	}
+	if version != nil && task.Version != *version {
+		return ErrVersionMismatch
+	}
+	if task.ArchiveDate != nil {
+		return ErrTaskArchived
+	}
+	if err := t.transition(ctx, id, &task, status, force); err != nil {
+		return err
+	}
+
+	// only update from the status the transition was checked against, of a
+	// task still active
+	filter := bson.M{
+		"_id":          objectId,
+		"status":       task.Status,
+		"archive_date": nil,
+	}
+	if version != nil {
+		filter["version"] = versionFilter(*version)
+	}
+	results, err := t.mongo.UpdateOne(ctx, filter, bson.M{
 		"$set": bson.M{
 			"status":      status,
 			"update_date": t.now().Unix(),
 		},
+		"$inc": bson.M{"version": 1},
 	})
 	if err != nil {
-		return err
+		return m.WrapError(err)
+	}
+	if results.MatchedCount == 0 {
+		if version != nil {
+			return ErrVersionMismatch
+		}
+		return ErrStatusChanged
+	}
+	t.record(ctx, ownerId, task.ID, history.Change{Field: "status", Old: task.Status, New: status})
+	return nil
+}
+
+// transition checks that task, of the given id, may move to status.
+func (t *TaskManager) transition(ctx context.Context, id string, task *TaskDoc, status int, force bool) error {
+	if !t.workflow.CanTransition(task.Status, status) {
+		return ErrIllegalTransition
+	}
+	if !force && task.Status == t.workflow.Initial() && status != task.Status && len(task.BlockedBy) > 0 {
+		blocked, err := t.blocked(ctx, task.BlockedBy)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			return ErrTaskBlocked
+		}
+	}
+	if t.workflow.RequireSubtasksDone() && t.workflow.IsTerminal(status) && !t.workflow.IsTerminal(task.Status) {
+		query := TaskFilter{ParentID: id}.query(t.now(), t.workflow.Terminal())
+		query["status"] = bson.M{"$nin": t.workflow.Terminal()}
+		open, err := t.mongo.CountDocuments(ctx, query)
+		if err != nil {
+			return m.WrapError(err)
+		}
+		if open > 0 {
+			return ErrOpenSubtasks
+		}
 	}
 	return nil
 }
 
-func (t *TaskManager) ArchiveTask(ctx context.Context, ownerId string, id string) (int, error) {
+// Workflow returns the workflow task statuses follow.
+func (t *TaskManager) Workflow() *Workflow {
+	return t.workflow
+}
+
+// UpdateTask changes the supplied fields, status included, in a single
+// write. When version is set the task must still be at that version.
+func (t *TaskManager) UpdateTask(ctx context.Context, ownerId string, id string, update TaskUpdate, version *int64) (int, error) {
+	// update only the supplied fields
+	fields := update.fields()
+	cleared := update.cleared()
+	if len(fields) == 0 && len(cleared) == 0 {
+		return 0, ErrNothingToUpdate
+	}
+	if !validSchedule(update.StartDate, update.DueDate) {
+		return 0, ErrInvalidSchedule
+	}
+	if update.Priority != nil && !ValidPriority(*update.Priority) {
+		return 0, ErrInvalidPriority
+	}
+	if update.Status != nil && !t.workflow.Valid(*update.Status) {
+		return 0, ErrInvalidStatus
+	}
+	fields["update_date"] = t.now().Unix()
+
+	objectId, err := ParseTaskID(id)
+	if err != nil {
+		return 0, err
+	}
+	guards := append([]guard{active}, update.scheduleGuard()...)
+	if update.Status != nil {
+		var task TaskDoc
+		if err := t.mongo.FindOne(ctx, bson.M{"_id": objectId, "owner_id": ownerId}).Decode(&task); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return 0, nil
+			}
+			return 0, m.WrapError(err)
+		}
+		if version != nil && task.Version != *version {
+			return 0, ErrVersionMismatch
+		}
+		if task.ArchiveDate != nil {
+			return 0, ErrTaskArchived
+		}
+		if err := t.transition(ctx, objectId.Hex(), &task, *update.Status, update.Force); err != nil {
+			return 0, err
+		}
+		// only update from the status the transition was checked against
+		guards = append(guards, guard{filter: bson.M{"status": task.Status}, err: ErrStatusChanged})
+	}
+	changes := bson.M{
+		"$set": fields,
+	}
+	if len(cleared) > 0 {
+		changes["$unset"] = cleared
+	}
+	before, err := t.update(ctx, objectId, ownerId, version, changes, guards...)
+	if err != nil || before == nil {
+		return 0, err
+	}
+	t.record(ctx, ownerId, before.ID, update.changes(before)...)
+	return 1, nil
+}
+
+func (t *TaskManager) ArchiveTask(ctx context.Context, ownerId string, id string, version *int64) (int, error) {
 	// archive task
-	objectId, _ := primitive.ObjectIDFromHex(id)
-	results, err := t.mongo.UpdateOne(ctx, bson.M{
+	objectId, err := ParseTaskID(id)
+	if err != nil {
+		return 0, err
+	}
+	now := t.now().Unix()
+	before, err := t.update(ctx, objectId, ownerId, version, bson.M{
+		"$set": bson.M{
+			"archive_date": now,
+			"update_date":  now,
+		},
+	}, active)
+	if err != nil || before == nil {
+		return 0, err
+	}
+	t.record(ctx, ownerId, before.ID, history.Change{Field: "archive_date", Old: before.ArchiveDate, New: &now})
+	return 1, nil
+}
+
+// UnarchiveTask moves an archived task back to the active tasks.
+func (t *TaskManager) UnarchiveTask(ctx context.Context, ownerId string, id string, version *int64) (int, error) {
+	objectId, err := ParseTaskID(id)
+	if err != nil {
+		return 0, err
+	}
+	before, err := t.update(ctx, objectId, ownerId, version, bson.M{
+		"$set": bson.M{
+			"archive_date": nil,
+			"update_date":  t.now().Unix(),
+		},
+	}, archived)
+	if err != nil || before == nil {
+		return 0, err
+	}
+	t.record(ctx, ownerId, before.ID, history.Change{Field: "archive_date", Old: before.ArchiveDate, New: (*int64)(nil)})
+	return 1, nil
+}
+
+// DeleteTask removes a task for good, only the owner can delete. It returns
+// the deleted task, nil when no task matched. Tasks referring to it as
+// parent or blocker are left as they are, a missing blocker no longer blocks.
+func (t *TaskManager) DeleteTask(ctx context.Context, ownerId string, id string) (*TaskDoc, error) {
+	objectId, err := ParseTaskID(id)
+	if err != nil {
+		return nil, err
+	}
+	var before TaskDoc
+	err = t.mongo.FindOneAndDelete(ctx, bson.M{
 		"_id":      objectId,
 		"owner_id": ownerId,
-	}, bson.M{
+	}).Decode(&before)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, m.WrapError(err)
+	}
+	t.record(ctx, ownerId, before.ID, history.Change{Field: history.FieldDeleted, Old: before.Topic})
+	return &before, nil
+}
+
+// ArchivedBefore returns the ids of up to limit tasks archived before the given time.
+func (t *TaskManager) ArchivedBefore(ctx context.Context, before int64, limit int64) ([]string, error) {
+	curr, err := t.mongo.Find(ctx, bson.M{
+		"archive_date": bson.M{"$ne": nil, "$lt": before},
+	}, options.Find().SetProjection(bson.M{"_id": 1}).SetLimit(limit))
+	if err != nil {
+		return nil, m.WrapError(err)
+	}
+	var tasks []TaskDoc
+	if err := curr.All(ctx, &tasks); err != nil {
+		return nil, m.WrapError(err)
+	}
+	ids := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	return ids, nil
+}
+
+// MissingTasks returns the ids, among the given ones, of tasks that no
+// longer exist. Malformed ids never belonged to a task and are left out.
+func (t *TaskManager) MissingTasks(ctx context.Context, ids []string) ([]string, error) {
+	objectIds := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if objectId, err := ParseTaskID(id); err == nil {
+			objectIds = append(objectIds, objectId)
+		}
+	}
+	curr, err := t.mongo.Find(ctx, bson.M{
+		"_id": bson.M{"$in": objectIds},
+	}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, m.WrapError(err)
+	}
+	var tasks []TaskDoc
+	if err := curr.All(ctx, &tasks); err != nil {
+		return nil, m.WrapError(err)
+	}
+	found := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		found[task.ID] = true
+	}
+	missing := make([]string, 0)
+	for _, objectId := range objectIds {
+		if !found[objectId.Hex()] {
+			missing = append(missing, objectId.Hex())
+		}
+	}
+	return missing, nil
+}
+
+// DeleteTasks removes archived tasks for good, active tasks are never
+// deleted. It returns the ids of the tasks actually removed.
+func (t *TaskManager) DeleteTasks(ctx context.Context, ids []string) ([]string, error) {
+	objectIds := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		objectId, err := ParseTaskID(id)
+		if err != nil {
+			return nil, err
+		}
+		objectIds = append(objectIds, objectId)
+	}
+	// one by one to know which tasks were still archived
+	deleted := make([]string, 0, len(objectIds))
+	for _, objectId := range objectIds {
+		var task TaskDoc
+		err := t.mongo.FindOneAndDelete(ctx, bson.M{
+			"_id":          objectId,
+			"archive_date": bson.M{"$ne": nil},
+		}, options.FindOneAndDelete().SetProjection(bson.M{"_id": 1})).Decode(&task)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return deleted, m.WrapError(err)
+		}
+		deleted = append(deleted, task.ID)
+		t.record(ctx, history.SystemActor, task.ID, history.Change{Field: history.FieldDeleted})
+	}
+	return deleted, nil
+}
+
+// AssignTask adds the given users to the assignees of a task, only the owner can assign.
+func (t *TaskManager) AssignTask(ctx context.Context, ownerId string, id string, assignees []string) (int, error) {
+	objectId, err := ParseTaskID(id)
+	if err != nil {
+		return 0, err
+	}
+	before, err := t.update(ctx, objectId, ownerId, nil, bson.M{
+		"$addToSet": bson.M{
+			"assignees": bson.M{"$each": assignees},
+		},
 		"$set": bson.M{
-			"archive_date": t.now().Unix(),
-			"update_date":  t.now().Unix(),
+			"update_date": t.now().Unix(),
 		},
+	}, active)
+	if err != nil || before == nil {
+		return 0, err
+	}
+	t.record(ctx, ownerId, before.ID, history.Change{Field: "assignees", Old: before.Assignees, New: union(before.Assignees, assignees)})
+	return 1, nil
+}
+
+// UnassignTask removes the given users from the assignees of a task, only the owner can unassign.
+func (t *TaskManager) UnassignTask(ctx context.Context, ownerId string, id string, assignees []string) (int, error) {
+	objectId, err := ParseTaskID(id)
+	if err != nil {
+		return 0, err
+	}
+	before, err := t.update(ctx, objectId, ownerId, nil, bson.M{
+		"$pull": bson.M{
+			"assignees": bson.M{"$in": assignees},
+		},
+		"$set": bson.M{
+			"update_date": t.now().Unix(),
+		},
+	}, active)
+	if err != nil || before == nil {
+		return 0, err
+	}
+	t.record(ctx, ownerId, before.ID, history.Change{Field: "assignees", Old: before.Assignees, New: without(before.Assignees, assignees)})
+	return 1, nil
+}
+
+// AddLabels attaches labels to a task, only the owner can label.
+func (t *TaskManager) AddLabels(ctx context.Context, ownerId string, id string, labels []string) (int, error) {
+	objectId, err := ParseTaskID(id)
+	if err != nil {
+		return 0, err
+	}
+	before, err := t.update(ctx, objectId, ownerId, nil, bson.M{
+		"$addToSet": bson.M{
+			"labels": bson.M{"$each": labels},
+		},
+		"$set": bson.M{
+			"update_date": t.now().Unix(),
+		},
+	}, active)
+	if err != nil || before == nil {
+		return 0, err
+	}
+	t.record(ctx, ownerId, before.ID, history.Change{Field: "labels", Old: before.Labels, New: union(before.Labels, labels)})
+	return 1, nil
+}
+
+// RemoveLabels detaches labels from a task, only the owner can unlabel.
+func (t *TaskManager) RemoveLabels(ctx context.Context, ownerId string, id string, labels []string) (int, error) {
+	objectId, err := ParseTaskID(id)
+	if err != nil {
+		return 0, err
+	}
+	before, err := t.update(ctx, objectId, ownerId, nil, bson.M{
+		"$pull": bson.M{
+			"labels": bson.M{"$in": labels},
+		},
+		"$set": bson.M{
+			"update_date": t.now().Unix(),
+		},
+	}, active)
+	if err != nil || before == nil {
+		return 0, err
+	}
+	t.record(ctx, ownerId, before.ID, history.Change{Field: "labels", Old: before.Labels, New: without(before.Labels, labels)})
+	return 1, nil
+}
+
+// GetLabels counts the tasks using each label, most used first. An empty
+// ownerId counts labels over all tasks. Archived tasks are not counted.
+func (t *TaskManager) GetLabels(ctx context.Context, ownerId string) ([]LabelCount, error) {
+	match := TaskFilter{OwnerID: ownerId}.query(t.now(), t.workflow.Terminal())
+	match["labels"] = bson.M{"$exists": true}
+	curr, err := t.mongo.Aggregate(ctx, []bson.M{
+		{"$match": match},
+		{"$unwind": "$labels"},
+		{"$group": bson.M{"_id": "$labels", "count": bson.M{"$sum": 1}}},
+		{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
 	})
+	if err != nil {
+		return nil, m.WrapError(err)
+	}
+	var labels = make([]LabelCount, 0)
+	if err := curr.All(ctx, &labels); err != nil {
+		return nil, m.WrapError(err)
+	}
+	return labels, nil
+}
+
+// AddDependency records that the task is blocked by another task. Only the
+// owner of the blocked task can add it and links that would close a cycle
+// are rejected.
+func (t *TaskManager) AddDependency(ctx context.Context, ownerId string, id string, blockerId string) (int, error) {
+	objectId, err := ParseTaskID(id)
+	if err != nil {
+		return 0, err
+	}
+	blocker, err := t.FindTask(ctx, blockerId)
+	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) || errors.Is(err, ErrInvalidTaskID) {
+			return 0, ErrInvalidBlocker.Wrap(err)
+		}
+		return 0, err
+	}
+	if blocker.ID == objectId.Hex() {
+		return 0, ErrDependencyCycle
+	}
+	cycle, err := t.dependsOn(ctx, blocker, objectId.Hex())
 	if err != nil {
 		return 0, err
 	}
-	return int(results.MatchedCount), nil
+	if cycle {
+		return 0, ErrDependencyCycle
+	}
+
+	before, err := t.update(ctx, objectId, ownerId, nil, bson.M{
+		"$addToSet": bson.M{
+			"blocked_by": blocker.ID,
+		},
+		"$set": bson.M{
+			"update_date": t.now().Unix(),
+		},
+	}, active)
+	if err != nil || before == nil {
+		return 0, err
+	}
+	t.record(ctx, ownerId, before.ID, history.Change{Field: "blocked_by", Old: before.BlockedBy, New: union(before.BlockedBy, []string{blocker.ID})})
+	return 1, nil
+}
+
+// RemoveDependency removes a blocking task, only the owner of the blocked task can remove it.
+func (t *TaskManager) RemoveDependency(ctx context.Context, ownerId string, id string, blockerId string) (int, error) {
+	objectId, err := ParseTaskID(id)
+	if err != nil {
+		return 0, err
+	}
+	blockerObjectId, err := ParseTaskID(blockerId)
+	if err != nil {
+		return 0, ErrInvalidBlocker.Wrap(err)
+	}
+	blockerId = blockerObjectId.Hex()
+	before, err := t.update(ctx, objectId, ownerId, nil, bson.M{
+		"$pull": bson.M{
+			"blocked_by": blockerId,
+		},
+		"$set": bson.M{
+			"update_date": t.now().Unix(),
+		},
+	}, active)
+	if err != nil || before == nil {
+		return 0, err
+	}
+	t.record(ctx, ownerId, before.ID, history.Change{Field: "blocked_by", Old: before.BlockedBy, New: without(before.BlockedBy, []string{blockerId})})
+	return 1, nil
+}
+
+// dependsOn reports whether task is blocked by target, directly or through
+// other blockers, by walking blocked_by breadth first.
+func (t *TaskManager) dependsOn(ctx context.Context, task *TaskDoc, target string) (bool, error) {
+	visited := map[string]bool{task.ID: true}
+	for _, id := range task.BlockedBy {
+		visited[id] = true
+	}
+	frontier := task.BlockedBy
+	for len(frontier) > 0 {
+		ids := make([]primitive.ObjectID, 0, len(frontier))
+		for _, id := range frontier {
+			if id == target {
+				return true, nil
+			}
+			if objectId, err := primitive.ObjectIDFromHex(id); err == nil {
+				ids = append(ids, objectId)
+			}
+		}
+
+		curr, err := t.mongo.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetProjection(bson.M{"blocked_by": 1}))
+		if err != nil {
+			return false, m.WrapError(err)
+		}
+		var tasks []TaskDoc
+		if err := curr.All(ctx, &tasks); err != nil {
+			return false, m.WrapError(err)
+		}
+		frontier = nil
+		for _, task := range tasks {
+			for _, id := range task.BlockedBy {
+				if !visited[id] {
+					visited[id] = true
+					frontier = append(frontier, id)
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+// blocked reports whether any of the blocking tasks is unfinished, archived
+// blockers no longer block.
+func (t *TaskManager) blocked(ctx context.Context, blockerIds []string) (bool, error) {
+	ids := make([]primitive.ObjectID, 0, len(blockerIds))
+	for _, id := range blockerIds {
+		if objectId, err := primitive.ObjectIDFromHex(id); err == nil {
+			ids = append(ids, objectId)
+		}
+	}
+	query := TaskFilter{}.query(t.now(), t.workflow.Terminal())
+	query["_id"] = bson.M{"$in": ids}
+	query["status"] = bson.M{"$nin": t.workflow.Terminal()}
+	open, err := t.mongo.CountDocuments(ctx, query)
+	if err != nil {
+		return false, m.WrapError(err)
+	}
+	return open > 0, nil
+}
+
+// guard is a condition a task must meet to be updated, with the error
+// returned when the task exists but does not meet it.
+type guard struct {
+	filter bson.M
+	err    error
+}
+
+// active and archived are the guards of changes allowed only on active or
+// on archived tasks.
+var (
+	active   = guard{filter: bson.M{"archive_date": nil}, err: ErrTaskArchived}
+	archived = guard{filter: bson.M{"archive_date": bson.M{"$ne": nil}}, err: ErrTaskNotArchived}
+)
+
+// versionFilter matches tasks at version, counting tasks created before
+// versions existed, which have no version field, as version 0.
+func versionFilter(version int64) interface{} {
+	if version == 0 {
+		return bson.M{"$in": bson.A{int64(0), nil}}
+	}
+	return version
+}
+
+// update applies changes to a task of the owner, bumping its version, and
+// returns the task as it was before, nil when no task matched. When version
+// is set the task must still be at that version, and it must meet guards.
+func (t *TaskManager) update(ctx context.Context, id primitive.ObjectID, ownerId string, version *int64, changes bson.M, guards ...guard) (*TaskDoc, error) {
+	filter := bson.M{
+		"_id":      id,
+		"owner_id": ownerId,
+	}
+	if version != nil {
+		filter["version"] = versionFilter(*version)
+	}
+	for _, g := range guards {
+		for k, v := range g.filter {
+			filter[k] = v
+		}
+	}
+	changes["$inc"] = bson.M{"version": 1}
+	var before TaskDoc
+	err := t.mongo.FindOneAndUpdate(ctx, filter, changes).Decode(&before)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if version == nil && len(guards) == 0 {
+			return nil, nil
+		}
+		return nil, t.mismatch(ctx, id, ownerId, version, guards)
+	}
+	if err != nil {
+		return nil, m.WrapError(err)
+	}
+	return &before, nil
+}
+
+// mismatch tells why an update matched no task: a missing task returns no
+// error, a task failing a guard the error of the guard and a task of another
+// version ErrVersionMismatch.
+func (t *TaskManager) mismatch(ctx context.Context, id primitive.ObjectID, ownerId string, version *int64, guards []guard) error {
+	count, err := t.mongo.CountDocuments(ctx, bson.M{"_id": id, "owner_id": ownerId})
+	if err != nil {
+		return m.WrapError(err)
+	}
+	if count == 0 {
+		return nil
+	}
+	for _, g := range guards {
+		filter := bson.M{"_id": id, "owner_id": ownerId}
+		for k, v := range g.filter {
+			filter[k] = v
+		}
+		count, err := t.mongo.CountDocuments(ctx, filter)
+		if err != nil {
+			return m.WrapError(err)
+		}
+		if count == 0 {
+			return g.err
+		}
+	}
+	if version != nil {
+		return ErrVersionMismatch
+	}
+	return nil
+}
+
+// record appends changes made by actor to the history of a task. The task
+// is already changed at this point so a failure is logged, not returned.
+func (t *TaskManager) record(ctx context.Context, actor string, taskId string, changes ...history.Change) {
+	if t.history == nil || len(changes) == 0 {
+		return
+	}
+	for i := range changes {
+		changes[i].TaskID = taskId
+		changes[i].Actor = actor
+	}
+	if err := t.history.Record(ctx, changes...); err != nil {
+		log.Printf("record history of task %v: %v", taskId, err)
+	}
+}
+
+// union returns values followed by the added values it lacks, as $addToSet does.
+func union(values []string, added []string) []string {
+	result := append([]string(nil), values...)
+	for _, value := range added {
+		if !contains(result, value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// without returns values except the removed ones, as $pull does.
+func without(values []string, removed []string) []string {
+	var result []string
+	for _, value := range values {
+		if !contains(removed, value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTaskID converts a hex task id to an ObjectID, rejecting malformed ids
+// instead of silently falling back to the zero ObjectID.
+func ParseTaskID(id string) (primitive.ObjectID, error) {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidTaskID.Wrap(err)
+	}
+	return objectId, nil
 }
 
 func (t *TaskManager) now() time.Time {
@@ -4,6 +4,10 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"strings"
+	"task-manager-api/config"
+	"task-manager-api/internal/history"
+	m "task-manager-api/internal/mongo"
 	mock "task-manager-api/internal/mongo/mock"
 	mock_taskmanager "task-manager-api/internal/taskmanager/mock"
 	"testing"
@@ -58,13 +62,38 @@ func (t *TaskManagerTestSuite) TestCreateTask() {
 			Status:      1,
 			CreateDate:  t.service.now().Unix(),
 			OwnerID:     "owner_id",
+			Version:     1,
 		}).Return(nil, errors.New("insert one error"))
-		taskDoc, err := t.service.CreateTask(context.Background(), "owner_id", "topic", "description")
+		taskDoc, err := t.service.CreateTask(context.Background(), "owner_id", NewTask{Topic: "topic", Description: "description"})
 		t.Error(err)
 		t.Nil(taskDoc)
 		t.EqualError(err, "insert one error")
 	})
 
+	t.Run("create occurrence created before should return conflict", func() {
+		occurrence := int64(1569130951)
+		t.mockMongo.EXPECT().InsertOne(context.Background(), TaskDoc{
+			Topic:        "topic",
+			Description:  "description",
+			Status:       1,
+			CreateDate:   t.service.now().Unix(),
+			OwnerID:      "owner_id",
+			DueDate:      &occurrence,
+			RecurrenceID: "645b9183fcfbc11433e23ab3",
+			Occurrence:   &occurrence,
+			Version:      1,
+		}).Return(nil, mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}})
+		taskDoc, err := t.service.CreateTask(context.Background(), "owner_id", NewTask{
+			Topic:        "topic",
+			Description:  "description",
+			DueDate:      &occurrence,
+			RecurrenceID: "645b9183fcfbc11433e23ab3",
+			Occurrence:   &occurrence,
+		})
+		t.Nil(taskDoc)
+		t.ErrorIs(err, ErrOccurrenceExists)
+	})
+
 	t.Run("create task success but can not convert _id", func() {
 		t.mockMongo.EXPECT().InsertOne(context.Background(), TaskDoc{
 			Topic:       "topic",
@@ -72,10 +101,11 @@ func (t *TaskManagerTestSuite) TestCreateTask() {
 			Status:      1,
 			CreateDate:  t.service.now().Unix(),
 			OwnerID:     "owner_id",
+			Version:     1,
 		}).Return(&mongo.InsertOneResult{
 			InsertedID: "5ad9a913478c26d220afb681",
 		}, nil)
-		taskDoc, err := t.service.CreateTask(context.Background(), "owner_id", "topic", "description")
+		taskDoc, err := t.service.CreateTask(context.Background(), "owner_id", NewTask{Topic: "topic", Description: "description"})
 		t.NotNil(err)
 		t.EqualError(err, "cannot convert inserted id to object id")
 		t.Nil(taskDoc)
@@ -89,10 +119,11 @@ func (t *TaskManagerTestSuite) TestCreateTask() {
 			Status:      1,
 			CreateDate:  t.service.now().Unix(),
 			OwnerID:     "owner_id",
+			Version:     1,
 		}).Return(&mongo.InsertOneResult{
 			InsertedID: objId,
 		}, nil)
-		taskDoc, err := t.service.CreateTask(context.Background(), "owner_id", "topic", "description")
+		taskDoc, err := t.service.CreateTask(context.Background(), "owner_id", NewTask{Topic: "topic", Description: "description"})
 		t.Nil(err)
 		t.NotNil(taskDoc)
 		t.Equal("5ad9a913478c26d220afb681", taskDoc.ID)
@@ -103,6 +134,110 @@ func (t *TaskManagerTestSuite) TestCreateTask() {
 		t.Equal("owner_id", taskDoc.OwnerID)
 		t.NoError(err)
 	})
+
+	t.Run("create task with dates should store them", func() {
+		objId, _ := primitive.ObjectIDFromHex("5ad9a913478c26d220afb681")
+		start, due := int64(1569200000), int64(1569300000)
+		t.mockMongo.EXPECT().InsertOne(context.Background(), TaskDoc{
+			Topic:       "topic",
+			Description: "description",
+			Status:      1,
+			CreateDate:  t.service.now().Unix(),
+			OwnerID:     "owner_id",
+			StartDate:   &start,
+			DueDate:     &due,
+			Version:     1,
+		}).Return(&mongo.InsertOneResult{
+			InsertedID: objId,
+		}, nil)
+		taskDoc, err := t.service.CreateTask(context.Background(), "owner_id", NewTask{Topic: "topic", Description: "description", StartDate: &start, DueDate: &due})
+		t.NoError(err)
+		t.Equal(&due, taskDoc.DueDate)
+		t.Equal(&start, taskDoc.StartDate)
+	})
+
+	t.Run("create subtask of another owner's task should return error", func() {
+		t.mockMongo.EXPECT().FindOne(context.Background(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).DoAndReturn(func(doc *TaskDoc) error {
+			doc.ID = "6041c3a6cfcba2fb9c4a4fd2"
+			doc.OwnerID = "someone_else"
+			return nil
+		})
+		taskDoc, err := t.service.CreateTask(context.Background(), "owner_id", NewTask{Topic: "topic", Description: "description", ParentID: "6041c3a6cfcba2fb9c4a4fd2"})
+		t.Nil(taskDoc)
+		t.ErrorIs(err, ErrInvalidParent)
+	})
+
+	t.Run("create subtask of missing task should return error", func() {
+		t.mockMongo.EXPECT().FindOne(context.Background(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).Return(mongo.ErrNoDocuments)
+		taskDoc, err := t.service.CreateTask(context.Background(), "owner_id", NewTask{Topic: "topic", Description: "description", ParentID: "6041c3a6cfcba2fb9c4a4fd2"})
+		t.Nil(taskDoc)
+		t.ErrorIs(err, ErrInvalidParent)
+	})
+
+	t.Run("create subtask success should store parent id", func() {
+		objId, _ := primitive.ObjectIDFromHex("5ad9a913478c26d220afb681")
+		t.mockMongo.EXPECT().FindOne(context.Background(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).DoAndReturn(func(doc *TaskDoc) error {
+			doc.ID = "6041c3a6cfcba2fb9c4a4fd2"
+			doc.OwnerID = "owner_id"
+			return nil
+		})
+		t.mockMongo.EXPECT().InsertOne(context.Background(), TaskDoc{
+			Topic:       "topic",
+			Description: "description",
+			Status:      1,
+			CreateDate:  t.service.now().Unix(),
+			OwnerID:     "owner_id",
+			ParentID:    "6041c3a6cfcba2fb9c4a4fd2",
+			Version:     1,
+		}).Return(&mongo.InsertOneResult{
+			InsertedID: objId,
+		}, nil)
+		taskDoc, err := t.service.CreateTask(context.Background(), "owner_id", NewTask{Topic: "topic", Description: "description", ParentID: "6041c3a6cfcba2fb9c4a4fd2"})
+		t.NoError(err)
+		t.Equal("6041c3a6cfcba2fb9c4a4fd2", taskDoc.ParentID)
+	})
+
+	t.Run("create task with unknown priority should return error", func() {
+		taskDoc, err := t.service.CreateTask(context.Background(), "owner_id", NewTask{Topic: "topic", Description: "description", Priority: 7})
+		t.Nil(taskDoc)
+		t.ErrorIs(err, ErrInvalidPriority)
+	})
+
+	t.Run("create task starting after its due date should return error", func() {
+		start, due := int64(1569300000), int64(1569200000)
+		taskDoc, err := t.service.CreateTask(context.Background(), "owner_id", NewTask{Topic: "topic", Description: "description", StartDate: &start, DueDate: &due})
+		t.Nil(taskDoc)
+		t.ErrorIs(err, ErrInvalidSchedule)
+	})
+}
+
+func (t *TaskManagerTestSuite) TestMalformedTaskID() {
+	t.Run("get task should reject malformed id", func() {
+		taskDoc, err := t.service.GetTask(context.Background(), "not-an-object-id")
+		t.Nil(taskDoc)
+		t.ErrorIs(err, ErrInvalidTaskID)
+	})
+
+	t.Run("update task status should reject malformed id", func() {
+		err := t.service.UpdateTaskStatus(context.Background(), "owner_id", "6041c3a6", 2, false, nil)
+		t.ErrorIs(err, ErrInvalidTaskID)
+	})
+
+	t.Run("update task should reject malformed id", func() {
+		topic := "topic"
+		c, err := t.service.UpdateTask(context.Background(), "owner_id", "", TaskUpdate{Topic: &topic}, nil)
+		t.Equal(0, c)
+		t.ErrorIs(err, ErrInvalidTaskID)
+	})
+
+	t.Run("archive task should reject malformed id", func() {
+		c, err := t.service.ArchiveTask(context.Background(), "owner_id", "zzzzzzzzzzzzzzzzzzzzzzzz", nil)
+		t.Equal(0, c)
+		t.ErrorIs(err, ErrInvalidTaskID)
+	})
 }
 
 func (t *TaskManagerTestSuite) TestGetTask() {
@@ -156,6 +291,7 @@ func (t *TaskManagerTestSuite) TestGetTask() {
 		t.Error(err)
 		t.Nil(taskDoc)
 		t.EqualError(err, "mongo: no documents in result")
+		t.ErrorIs(err, ErrTaskNotFound)
 	})
 
 	t.Run("get task but decode error", func() {
@@ -212,6 +348,10 @@ func (t *TaskManagerTestSuite) TestGetTask() {
 			doc.CreateDate = 1614962551
 			return nil
 		}).Times(1)
+		t.mockMongo.EXPECT().Aggregate(context.Background(), gomock.Any()).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).Return(nil)
+		t.mockMongo.EXPECT().Find(context.Background(), gomock.Any(), gomock.Any()).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).Return(nil)
 		taskDoc, err := t.service.GetTask(context.Background(), "6041c3a6cfcba2fb9c4a4fd2")
 		t.NotNil(taskDoc)
 		t.NoError(err)
@@ -220,22 +360,123 @@ func (t *TaskManagerTestSuite) TestGetTask() {
 		t.Equal("description", taskDoc.Description)
 		t.Equal(1, taskDoc.Status)
 		t.Equal(int64(1614962551), taskDoc.CreateDate)
+		t.Nil(taskDoc.Progress)
+	})
+
+	t.Run("get task with subtasks should report progress", func() {
+		t.mockMongo.EXPECT().FindOne(context.Background(), gomock.Any(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).DoAndReturn(func(doc *TaskDoc) error {
+			doc.ID = "6041c3a6cfcba2fb9c4a4fd2"
+			return nil
+		})
+		t.mockMongo.EXPECT().Aggregate(context.Background(), []bson.M{
+			{"$match": bson.M{
+				"$or": []bson.M{
+					{
+						"archive_date": bson.M{
+							"$exists": false,
+						},
+					},
+					{
+						"archive_date": nil,
+					},
+				},
+				"parent_id": "6041c3a6cfcba2fb9c4a4fd2",
+			}},
+			{"$group": bson.M{
+				"_id":   nil,
+				"total": bson.M{"$sum": 1},
+				"done": bson.M{"$sum": bson.M{
+					"$cond": bson.A{bson.M{"$in": bson.A{"$status", []int{TaskStatusDone}}}, 1, 0},
+				}},
+			}},
+		}).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, result interface{}) error {
+			reflect.ValueOf(result).Elem().Set(reflect.ValueOf([]Progress{{Total: 3, Done: 1}}))
+			return nil
+		})
+		t.mockMongo.EXPECT().Find(context.Background(), gomock.Any(), gomock.Any()).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).Return(nil)
+		taskDoc, err := t.service.GetTask(context.Background(), "6041c3a6cfcba2fb9c4a4fd2")
+		t.NoError(err)
+		t.Equal(&Progress{Total: 3, Done: 1, Percent: 33}, taskDoc.Progress)
+	})
+
+	t.Run("get task should list the tasks it blocks", func() {
+		t.mockMongo.EXPECT().FindOne(context.Background(), gomock.Any(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).DoAndReturn(func(doc *TaskDoc) error {
+			doc.ID = "6041c3a6cfcba2fb9c4a4fd2"
+			doc.BlockedBy = []string{"6041c3a6cfcba2fb9c4a4fd1"}
+			return nil
+		})
+		t.mockMongo.EXPECT().Aggregate(context.Background(), gomock.Any()).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).Return(nil)
+		t.mockMongo.EXPECT().Find(context.Background(), bson.M{
+			"$or": []bson.M{
+				{
+					"archive_date": bson.M{
+						"$exists": false,
+					},
+				},
+				{
+					"archive_date": nil,
+				},
+			},
+			"blocked_by": "6041c3a6cfcba2fb9c4a4fd2",
+		}, options.Find().SetProjection(bson.M{"_id": 1})).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, result interface{}) error {
+			reflect.ValueOf(result).Elem().Set(reflect.ValueOf([]TaskDoc{{ID: "6041c3a6cfcba2fb9c4a4fd3"}}))
+			return nil
+		})
+		taskDoc, err := t.service.GetTask(context.Background(), "6041c3a6cfcba2fb9c4a4fd2")
+		t.NoError(err)
+		t.Equal([]string{"6041c3a6cfcba2fb9c4a4fd1"}, taskDoc.BlockedBy)
+		t.Equal([]string{"6041c3a6cfcba2fb9c4a4fd3"}, taskDoc.Blocks)
+	})
+}
+
+func (t *TaskManagerTestSuite) TestFindTask() {
+	objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+
+	t.Run("find task but not found should return error", func() {
+		t.mockMongo.EXPECT().FindOne(context.Background(), bson.M{"_id": objectId}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).Return(mongo.ErrNoDocuments)
+		taskDoc, err := t.service.FindTask(context.Background(), "6041c3a6cfcba2fb9c4a4fd2")
+		t.Nil(taskDoc)
+		t.ErrorIs(err, ErrTaskNotFound)
+	})
+
+	t.Run("find task should return archived task", func() {
+		t.mockMongo.EXPECT().FindOne(context.Background(), bson.M{"_id": objectId}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).DoAndReturn(func(doc *TaskDoc) error {
+			archiveDate := int64(1614962551)
+			doc.ID = "6041c3a6cfcba2fb9c4a4fd2"
+			doc.ArchiveDate = &archiveDate
+			return nil
+		})
+		taskDoc, err := t.service.FindTask(context.Background(), "6041c3a6cfcba2fb9c4a4fd2")
+		t.NoError(err)
+		t.Equal("6041c3a6cfcba2fb9c4a4fd2", taskDoc.ID)
+		t.NotNil(taskDoc.ArchiveDate)
 	})
 }
 
 func (t *TaskManagerTestSuite) TestArchiveTask() {
-	t.Run("archive task but update one got error should return error", func() {
+	t.Run("archive task but update got error should return error", func() {
 		objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
-		t.mockMongo.EXPECT().UpdateOne(context.Background(), bson.M{
-			"_id":      objectId,
-			"owner_id": "owner_id",
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), bson.M{
+			"_id":          objectId,
+			"owner_id":     "owner_id",
+			"archive_date": nil,
 		}, bson.M{
 			"$set": bson.M{
 				"archive_date": t.service.now().Unix(),
 				"update_date":  t.service.now().Unix(),
 			},
-		}).Return(nil, errors.New("update one error"))
-		c, err := t.service.ArchiveTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2")
+			"$inc": bson.M{"version": 1},
+		}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(errors.New("update one error"))
+		c, err := t.service.ArchiveTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", nil)
 		t.Error(err)
 		t.Equal(0, c)
 		t.EqualError(err, "update one error")
@@ -243,106 +484,328 @@ func (t *TaskManagerTestSuite) TestArchiveTask() {
 
 	t.Run("archive task success", func() {
 		objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
-		t.mockMongo.EXPECT().UpdateOne(context.Background(), bson.M{
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), bson.M{
+			"_id":          objectId,
+			"owner_id":     "owner_id",
+			"archive_date": nil,
+		}, bson.M{
+			"$set": bson.M{
+				"archive_date": t.service.now().Unix(),
+				"update_date":  t.service.now().Unix(),
+			},
+			"$inc": bson.M{"version": 1},
+		}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(nil)
+		count, err := t.service.ArchiveTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", nil)
+		t.Equal(1, count)
+		t.NoError(err)
+	})
+
+	t.Run("archive archived task should return conflict", func() {
+		objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), gomock.Any(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(mongo.ErrNoDocuments)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), bson.M{
 			"_id":      objectId,
 			"owner_id": "owner_id",
+		}).Return(int64(1), nil)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), bson.M{
+			"_id":          objectId,
+			"owner_id":     "owner_id",
+			"archive_date": nil,
+		}).Return(int64(0), nil)
+		count, err := t.service.ArchiveTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", nil)
+		t.Equal(0, count)
+		t.ErrorIs(err, ErrTaskArchived)
+	})
+}
+
+func (t *TaskManagerTestSuite) TestUnarchiveTask() {
+	t.Run("unarchive task with malformed id should return error", func() {
+		c, err := t.service.UnarchiveTask(context.Background(), "owner_id", "task_id", nil)
+		t.Equal(0, c)
+		t.ErrorIs(err, ErrInvalidTaskID)
+	})
+
+	t.Run("unarchive task success", func() {
+		objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), bson.M{
+			"_id":          objectId,
+			"owner_id":     "owner_id",
+			"archive_date": bson.M{"$ne": nil},
 		}, bson.M{
 			"$set": bson.M{
-				"archive_date": t.service.now().Unix(),
+				"archive_date": nil,
 				"update_date":  t.service.now().Unix(),
 			},
-		}).Return(&mongo.UpdateResult{
-			MatchedCount: 1,
-		}, nil)
-		count, err := t.service.ArchiveTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2")
+			"$inc": bson.M{"version": 1},
+		}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(nil)
+		count, err := t.service.UnarchiveTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", nil)
 		t.Equal(1, count)
 		t.NoError(err)
 	})
+
+	t.Run("unarchive active task should return conflict", func() {
+		objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), gomock.Any(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(mongo.ErrNoDocuments)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), bson.M{
+			"_id":      objectId,
+			"owner_id": "owner_id",
+		}).Return(int64(1), nil)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), bson.M{
+			"_id":          objectId,
+			"owner_id":     "owner_id",
+			"archive_date": bson.M{"$ne": nil},
+		}).Return(int64(0), nil)
+		count, err := t.service.UnarchiveTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", nil)
+		t.Equal(0, count)
+		t.ErrorIs(err, ErrTaskNotArchived)
+	})
+}
+
+func (t *TaskManagerTestSuite) TestDeleteTask() {
+	objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+
+	t.Run("delete task but delete one got error should return error", func() {
+		t.mockMongo.EXPECT().FindOneAndDelete(context.Background(), bson.M{
+			"_id":      objectId,
+			"owner_id": "owner_id",
+		}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(errors.New("delete error"))
+		task, err := t.service.DeleteTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2")
+		t.Nil(task)
+		t.EqualError(err, "delete error")
+	})
+
+	t.Run("delete task not owned should delete nothing", func() {
+		t.mockMongo.EXPECT().FindOneAndDelete(context.Background(), bson.M{
+			"_id":      objectId,
+			"owner_id": "owner_id",
+		}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(mongo.ErrNoDocuments)
+		task, err := t.service.DeleteTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2")
+		t.Nil(task)
+		t.NoError(err)
+	})
+
+	t.Run("delete task success", func() {
+		t.mockMongo.EXPECT().FindOneAndDelete(context.Background(), bson.M{
+			"_id":      objectId,
+			"owner_id": "owner_id",
+		}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).SetArg(0, TaskDoc{ID: "6041c3a6cfcba2fb9c4a4fd2"}).Return(nil)
+		task, err := t.service.DeleteTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2")
+		t.NoError(err)
+		t.Equal("6041c3a6cfcba2fb9c4a4fd2", task.ID)
+	})
+}
+
+func (t *TaskManagerTestSuite) TestPurgeArchived() {
+	objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+
+	t.Run("archived before should return the ids of expired tasks", func() {
+		t.mockMongo.EXPECT().Find(context.Background(), bson.M{
+			"archive_date": bson.M{"$ne": nil, "$lt": int64(1614962551)},
+		}, options.Find().SetProjection(bson.M{"_id": 1}).SetLimit(100)).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).SetArg(1, []TaskDoc{{ID: "6041c3a6cfcba2fb9c4a4fd2"}}).Return(nil)
+		ids, err := t.service.ArchivedBefore(context.Background(), 1614962551, 100)
+		t.NoError(err)
+		t.Equal([]string{"6041c3a6cfcba2fb9c4a4fd2"}, ids)
+	})
+
+	t.Run("delete tasks only deletes archived tasks", func() {
+		other, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd3")
+		t.mockMongo.EXPECT().FindOneAndDelete(context.Background(), bson.M{
+			"_id":          objectId,
+			"archive_date": bson.M{"$ne": nil},
+		}, options.FindOneAndDelete().SetProjection(bson.M{"_id": 1})).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).SetArg(0, TaskDoc{ID: "6041c3a6cfcba2fb9c4a4fd2"}).Return(nil)
+		// unarchived since it was listed
+		t.mockMongo.EXPECT().FindOneAndDelete(context.Background(), bson.M{
+			"_id":          other,
+			"archive_date": bson.M{"$ne": nil},
+		}, gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).Return(mongo.ErrNoDocuments)
+		deleted, err := t.service.DeleteTasks(context.Background(), []string{"6041c3a6cfcba2fb9c4a4fd2", "6041c3a6cfcba2fb9c4a4fd3"})
+		t.NoError(err)
+		t.Equal([]string{"6041c3a6cfcba2fb9c4a4fd2"}, deleted)
+	})
+
+	t.Run("delete tasks with malformed id should return error", func() {
+		_, err := t.service.DeleteTasks(context.Background(), []string{"task_id"})
+		t.ErrorIs(err, ErrInvalidTaskID)
+	})
+
+	t.Run("missing tasks should return the ids of tasks that no longer exist", func() {
+		other, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd3")
+		t.mockMongo.EXPECT().Find(context.Background(), bson.M{
+			"_id": bson.M{"$in": []primitive.ObjectID{objectId, other}},
+		}, options.Find().SetProjection(bson.M{"_id": 1})).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).SetArg(1, []TaskDoc{{ID: "6041c3a6cfcba2fb9c4a4fd2"}}).Return(nil)
+		missing, err := t.service.MissingTasks(context.Background(), []string{"6041c3a6cfcba2fb9c4a4fd2", "task_id", "6041c3a6cfcba2fb9c4a4fd3"})
+		t.NoError(err)
+		t.Equal([]string{"6041c3a6cfcba2fb9c4a4fd3"}, missing)
+	})
+}
+
+func (t *TaskManagerTestSuite) TestFindOccurrence() {
+	t.Run("find occurrence should return the task of the occurrence", func() {
+		t.mockMongo.EXPECT().FindOne(context.Background(), bson.M{
+			"recurrence_id": "645b9183fcfbc11433e23ab3",
+			"occurrence":    int64(1683684000),
+		}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).SetArg(0, TaskDoc{ID: "6041c3a6cfcba2fb9c4a4fd2"}).Return(nil)
+		task, err := t.service.FindOccurrence(context.Background(), "645b9183fcfbc11433e23ab3", 1683684000)
+		t.NoError(err)
+		t.Equal("6041c3a6cfcba2fb9c4a4fd2", task.ID)
+	})
+
+	t.Run("find occurrence not created should return not found", func() {
+		t.mockMongo.EXPECT().FindOne(context.Background(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).Return(mongo.ErrNoDocuments)
+		task, err := t.service.FindOccurrence(context.Background(), "645b9183fcfbc11433e23ab3", 1683684000)
+		t.Nil(task)
+		t.ErrorIs(err, ErrTaskNotFound)
+	})
 }
 
 func (t *TaskManagerTestSuite) TestUpdateTaskStatus() {
+	objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+	findFilter := bson.M{
+		"_id": objectId,
+		"$or": []bson.M{
+			{"owner_id": "owner_id"},
+			{"assignees": "owner_id"},
+		},
+	}
+	expectCurrent := func(status int) {
+		t.mockMongo.EXPECT().FindOne(context.Background(), findFilter).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).DoAndReturn(func(v interface{}) error {
+			v.(*TaskDoc).Status = status
+			return nil
+		})
+	}
+
+	t.Run("update task status to unknown status should return error", func() {
+		err := t.service.UpdateTaskStatus(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", 9, false, nil)
+		t.ErrorIs(err, ErrInvalidStatus)
+	})
+
+	t.Run("update task status by someone neither owner nor assignee should return not found", func() {
+		t.mockMongo.EXPECT().FindOne(context.Background(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).Return(mongo.ErrNoDocuments)
+		err := t.service.UpdateTaskStatus(context.Background(), "stranger", "6041c3a6cfcba2fb9c4a4fd2", 2, false, nil)
+		t.ErrorIs(err, ErrTaskNotFound)
+	})
+
+	t.Run("update status of archived task should return conflict", func() {
+		archiveDate := int64(1)
+		t.mockMongo.EXPECT().FindOne(context.Background(), findFilter).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).SetArg(0, TaskDoc{Status: TaskStatusOpen, ArchiveDate: &archiveDate}).Return(nil)
+		err := t.service.UpdateTaskStatus(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", TaskStatusInProgress, false, nil)
+		t.ErrorIs(err, ErrTaskArchived)
+	})
+
 	t.Run("update task status but update one got error should return error", func() {
-		objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+		expectCurrent(TaskStatusInProgress)
 		t.mockMongo.EXPECT().UpdateOne(context.Background(), bson.M{
-			"_id":      objectId,
-			"owner_id": "owner_id",
+			"_id":          objectId,
+			"status":       TaskStatusInProgress,
+			"archive_date": nil,
 		}, bson.M{
 			"$set": bson.M{
 				"status":      1,
 				"update_date": t.service.now().Unix(),
 			},
+			"$inc": bson.M{"version": 1},
 		}).Return(nil, errors.New("update one error"))
-		err := t.service.UpdateTaskStatus(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", 1)
+		err := t.service.UpdateTaskStatus(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", 1, false, nil)
 		t.Error(err)
 		t.EqualError(err, "update one error")
 	})
 
 	t.Run("update task status success", func() {
-		objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+		expectCurrent(TaskStatusOpen)
 		t.mockMongo.EXPECT().UpdateOne(context.Background(), bson.M{
-			"_id":      objectId,
-			"owner_id": "owner_id",
+			"_id":          objectId,
+			"status":       TaskStatusOpen,
+			"archive_date": nil,
 		}, bson.M{
 			"$set": bson.M{
 				"status":      2,
 				"update_date": t.service.now().Unix(),
 			},
+			"$inc": bson.M{"version": 1},
 		}).Return(&mongo.UpdateResult{
 			MatchedCount: 1,
 		}, nil)
-		err := t.service.UpdateTaskStatus(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", 2)
+		err := t.service.UpdateTaskStatus(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", 2, false, nil)
 		t.NoError(err)
 	})
-}
 
-func (t *TaskManagerTestSuite) TestGetAllTask() {
-	l := int64(10)
-	skip := int64(1*10 - 10)
-	fOpt := &options.FindOptions{Limit: &l, Skip: &skip}
+	t.Run("update task status changed concurrently should return conflict", func() {
+		expectCurrent(TaskStatusOpen)
+		t.mockMongo.EXPECT().UpdateOne(context.Background(), gomock.Any(), gomock.Any()).Return(&mongo.UpdateResult{
+			MatchedCount: 0,
+		}, nil)
+		err := t.service.UpdateTaskStatus(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", 2, false, nil)
+		t.ErrorIs(err, ErrStatusChanged)
+	})
 
-	t.Run("get all task but find got error should return error", func() {
-		t.mockMongo.EXPECT().Find(context.Background(), bson.M{
-			"$or": []bson.M{
-				{
-					"archive_date": bson.M{
-						"$exists": false,
-					},
-				},
-				{
-					"archive_date": nil,
-				},
-			},
-		}, fOpt).Return(t.cursor, errors.New("find error"))
-		_, err := t.service.GetAllTask(context.Background(), 1, 10)
-		t.Error(err)
-		t.EqualError(err, "find error")
+	t.Run("update task status with a stale version should return precondition error", func() {
+		expectCurrent(TaskStatusOpen)
+		version := int64(2)
+		err := t.service.UpdateTaskStatus(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", 2, false, &version)
+		t.ErrorIs(err, ErrVersionMismatch)
 	})
 
-	t.Run("get all task success but cursor decode error", func() {
-		t.mockMongo.EXPECT().Find(context.Background(), bson.M{
-			"$or": []bson.M{
-				{
-					"archive_date": bson.M{
-						"$exists": false,
-					},
-				},
-				{
-					"archive_date": nil,
-				},
-			},
-		}, fOpt).Return(t.cursor, nil)
-		taskDocs := make([]TaskDoc, 0)
-		t.cursor.EXPECT().All(context.Background(), &taskDocs).DoAndReturn(func(ctx context.Context, result interface{}) error {
-			return errors.New("cursor decode error")
-		}).Times(1)
-		tasks, err := t.service.GetAllTask(context.Background(), 1, 10)
-		t.NotNil(err)
-		t.EqualError(err, "cursor decode error")
-		t.Nil(tasks)
+	t.Run("update task status with the current version should filter on it", func() {
+		t.mockMongo.EXPECT().FindOne(context.Background(), findFilter).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).SetArg(0, TaskDoc{Status: TaskStatusOpen, Version: 2}).Return(nil)
+		t.mockMongo.EXPECT().UpdateOne(context.Background(), bson.M{
+			"_id":          objectId,
+			"status":       TaskStatusOpen,
+			"archive_date": nil,
+			"version":      int64(2),
+		}, gomock.Any()).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+		version := int64(2)
+		err := t.service.UpdateTaskStatus(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", 2, false, &version)
+		t.NoError(err)
 	})
 
-	t.Run("get all task success", func() {
-		t.mockMongo.EXPECT().Find(context.Background(), bson.M{
+	t.Run("update task status with version 0 should match a task without a version field", func() {
+		t.mockMongo.EXPECT().FindOne(context.Background(), findFilter).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).DoAndReturn(func(v interface{}) error {
+			raw, _ := bson.Marshal(bson.M{"_id": objectId, "owner_id": "owner_id", "status": TaskStatusOpen})
+			return bson.Unmarshal(raw, v)
+		})
+		t.mockMongo.EXPECT().UpdateOne(context.Background(), bson.M{
+			"_id":          objectId,
+			"status":       TaskStatusOpen,
+			"archive_date": nil,
+			"version":      bson.M{"$in": bson.A{int64(0), nil}},
+		}, gomock.Any()).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+		version := int64(0)
+		err := t.service.UpdateTaskStatus(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", 2, false, &version)
+		t.NoError(err)
+	})
+
+	t.Run("update task status modified concurrently with a version should return precondition error", func() {
+		expectCurrent(TaskStatusOpen)
+		t.mockMongo.EXPECT().UpdateOne(context.Background(), gomock.Any(), gomock.Any()).Return(&mongo.UpdateResult{MatchedCount: 0}, nil)
+		version := int64(0)
+		err := t.service.UpdateTaskStatus(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", 2, false, &version)
+		t.ErrorIs(err, ErrVersionMismatch)
+	})
+
+	t.Run("update task status to done with open subtasks should return conflict", func() {
+		workflow, _ := NewWorkflow(config.Workflow{RequireSubtasksDone: true})
+		service := NewTaskManager(t.mockMongo, WithWorkflow(workflow))
+		service.time = t.service.time
+		expectCurrent(TaskStatusInProgress)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), bson.M{
 			"$or": []bson.M{
 				{
 					"archive_date": bson.M{
@@ -353,17 +816,568 @@ func (t *TaskManagerTestSuite) TestGetAllTask() {
 					"archive_date": nil,
 				},
 			},
-		}, fOpt).Return(t.cursor, nil)
-		taskDocs := make([]TaskDoc, 0)
-		t.cursor.EXPECT().All(context.Background(), &taskDocs).DoAndReturn(func(ctx context.Context, result interface{}) error {
-			taskDocs = append(taskDocs, TaskDoc{
-				ID:          "6041c3a6cfcba2fb9c4a4fd2",
-				Topic:       "topic",
-				Description: "description",
-				Status:      1,
-				CreateDate:  1614962551,
-			})
-			taskDocs = append(taskDocs, TaskDoc{
+			"parent_id": "6041c3a6cfcba2fb9c4a4fd2",
+			"status":    bson.M{"$nin": []int{TaskStatusDone}},
+		}).Return(int64(2), nil)
+		err := service.UpdateTaskStatus(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", TaskStatusDone, false, nil)
+		t.ErrorIs(err, ErrOpenSubtasks)
+	})
+
+	t.Run("update task status to done without open subtasks should succeed", func() {
+		workflow, _ := NewWorkflow(config.Workflow{RequireSubtasksDone: true})
+		service := NewTaskManager(t.mockMongo, WithWorkflow(workflow))
+		expectCurrent(TaskStatusInProgress)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), gomock.Any()).Return(int64(0), nil)
+		t.mockMongo.EXPECT().UpdateOne(context.Background(), gomock.Any(), gomock.Any()).Return(&mongo.UpdateResult{
+			MatchedCount: 1,
+		}, nil)
+		err := service.UpdateTaskStatus(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", TaskStatusDone, false, nil)
+		t.NoError(err)
+	})
+
+	t.Run("update task status along a transition outside the workflow should return conflict", func() {
+		workflow, err := NewWorkflow(config.Workflow{States: []config.WorkflowState{
+			{ID: 1, Name: "todo", Transitions: []int{2}},
+			{ID: 2, Name: "review", Transitions: []int{3}},
+			{ID: 3, Name: "closed", Terminal: true},
+		}})
+		t.Require().NoError(err)
+		service := NewTaskManager(t.mockMongo, WithWorkflow(workflow))
+		expectCurrent(1)
+		err = service.UpdateTaskStatus(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", 3, false, nil)
+		t.ErrorIs(err, ErrIllegalTransition)
+	})
+}
+
+func (t *TaskManagerTestSuite) TestDependencies() {
+	taskA, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd1")
+	taskB, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+	taskC, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd3")
+	expectTask := func(id primitive.ObjectID, blockedBy ...string) {
+		t.mockMongo.EXPECT().FindOne(context.Background(), bson.M{"_id": id}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).DoAndReturn(func(doc *TaskDoc) error {
+			doc.ID = id.Hex()
+			doc.BlockedBy = blockedBy
+			return nil
+		})
+	}
+
+	t.Run("add dependency on itself should return cycle error", func() {
+		expectTask(taskB)
+		_, err := t.service.AddDependency(context.Background(), "owner_id", taskB.Hex(), taskB.Hex())
+		t.ErrorIs(err, ErrDependencyCycle)
+	})
+
+	t.Run("add dependency on missing task should return error", func() {
+		t.mockMongo.EXPECT().FindOne(context.Background(), bson.M{"_id": taskA}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).Return(mongo.ErrNoDocuments)
+		_, err := t.service.AddDependency(context.Background(), "owner_id", taskB.Hex(), taskA.Hex())
+		t.ErrorIs(err, ErrInvalidBlocker)
+	})
+
+	t.Run("add dependency closing a transitive cycle should return error", func() {
+		// C is blocked by A which is blocked by B, so B cannot be blocked by C
+		expectTask(taskC, taskA.Hex())
+		t.mockMongo.EXPECT().Find(context.Background(), bson.M{"_id": bson.M{"$in": []primitive.ObjectID{taskA}}}, options.Find().SetProjection(bson.M{"blocked_by": 1})).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, result interface{}) error {
+			reflect.ValueOf(result).Elem().Set(reflect.ValueOf([]TaskDoc{{ID: taskA.Hex(), BlockedBy: []string{taskB.Hex()}}}))
+			return nil
+		})
+		_, err := t.service.AddDependency(context.Background(), "owner_id", taskB.Hex(), taskC.Hex())
+		t.ErrorIs(err, ErrDependencyCycle)
+	})
+
+	t.Run("add dependency success", func() {
+		expectTask(taskA)
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), bson.M{
+			"_id":          taskB,
+			"owner_id":     "owner_id",
+			"archive_date": nil,
+		}, bson.M{
+			"$addToSet": bson.M{
+				"blocked_by": taskA.Hex(),
+			},
+			"$set": bson.M{
+				"update_date": t.service.now().Unix(),
+			},
+			"$inc": bson.M{"version": 1},
+		}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(nil)
+		c, err := t.service.AddDependency(context.Background(), "owner_id", taskB.Hex(), taskA.Hex())
+		t.NoError(err)
+		t.Equal(1, c)
+	})
+
+	t.Run("remove dependency success", func() {
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), bson.M{
+			"_id":          taskB,
+			"owner_id":     "owner_id",
+			"archive_date": nil,
+		}, bson.M{
+			"$pull": bson.M{
+				"blocked_by": taskA.Hex(),
+			},
+			"$set": bson.M{
+				"update_date": t.service.now().Unix(),
+			},
+			"$inc": bson.M{"version": 1},
+		}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(nil)
+		c, err := t.service.RemoveDependency(context.Background(), "owner_id", taskB.Hex(), taskA.Hex())
+		t.NoError(err)
+		t.Equal(1, c)
+	})
+
+	t.Run("remove dependency with malformed blocker id should return error", func() {
+		c, err := t.service.RemoveDependency(context.Background(), "owner_id", taskB.Hex(), "blocker")
+		t.Equal(0, c)
+		t.ErrorIs(err, ErrInvalidBlocker)
+	})
+
+	t.Run("add dependency to archived task should return conflict", func() {
+		expectTask(taskA)
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), gomock.Any(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(mongo.ErrNoDocuments)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), bson.M{
+			"_id":      taskB,
+			"owner_id": "owner_id",
+		}).Return(int64(1), nil)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), bson.M{
+			"_id":          taskB,
+			"owner_id":     "owner_id",
+			"archive_date": nil,
+		}).Return(int64(0), nil)
+		c, err := t.service.AddDependency(context.Background(), "owner_id", taskB.Hex(), taskA.Hex())
+		t.Equal(0, c)
+		t.ErrorIs(err, ErrTaskArchived)
+	})
+
+	blockedTask := func(status int) {
+		t.mockMongo.EXPECT().FindOne(context.Background(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).DoAndReturn(func(doc *TaskDoc) error {
+			doc.Status = status
+			doc.BlockedBy = []string{taskA.Hex()}
+			return nil
+		})
+	}
+
+	t.Run("start task with unfinished blocker should return conflict", func() {
+		blockedTask(TaskStatusOpen)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), bson.M{
+			"$or": []bson.M{
+				{
+					"archive_date": bson.M{
+						"$exists": false,
+					},
+				},
+				{
+					"archive_date": nil,
+				},
+			},
+			"_id":    bson.M{"$in": []primitive.ObjectID{taskA}},
+			"status": bson.M{"$nin": []int{TaskStatusDone}},
+		}).Return(int64(1), nil)
+		err := t.service.UpdateTaskStatus(context.Background(), "owner_id", taskB.Hex(), TaskStatusInProgress, false, nil)
+		t.ErrorIs(err, ErrTaskBlocked)
+	})
+
+	t.Run("start task with finished blockers should succeed", func() {
+		blockedTask(TaskStatusOpen)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), gomock.Any()).Return(int64(0), nil)
+		t.mockMongo.EXPECT().UpdateOne(context.Background(), gomock.Any(), gomock.Any()).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+		t.NoError(t.service.UpdateTaskStatus(context.Background(), "owner_id", taskB.Hex(), TaskStatusInProgress, false, nil))
+	})
+
+	t.Run("forced start should skip blockers", func() {
+		blockedTask(TaskStatusOpen)
+		t.mockMongo.EXPECT().UpdateOne(context.Background(), gomock.Any(), gomock.Any()).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+		t.NoError(t.service.UpdateTaskStatus(context.Background(), "owner_id", taskB.Hex(), TaskStatusInProgress, true, nil))
+	})
+
+	t.Run("task already started should not check blockers", func() {
+		blockedTask(TaskStatusInProgress)
+		t.mockMongo.EXPECT().UpdateOne(context.Background(), gomock.Any(), gomock.Any()).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+		t.NoError(t.service.UpdateTaskStatus(context.Background(), "owner_id", taskB.Hex(), TaskStatusDone, false, nil))
+	})
+}
+
+func (t *TaskManagerTestSuite) TestAssignTask() {
+	objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+
+	t.Run("assign task but update got error should return error", func() {
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), gomock.Any(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(errors.New("update one error"))
+		c, err := t.service.AssignTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", []string{"user_1"})
+		t.Equal(0, c)
+		t.EqualError(err, "update one error")
+	})
+
+	t.Run("assign task success", func() {
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), bson.M{
+			"_id":          objectId,
+			"owner_id":     "owner_id",
+			"archive_date": nil,
+		}, bson.M{
+			"$addToSet": bson.M{
+				"assignees": bson.M{"$each": []string{"user_1", "user_2"}},
+			},
+			"$set": bson.M{
+				"update_date": t.service.now().Unix(),
+			},
+			"$inc": bson.M{"version": 1},
+		}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(nil)
+		c, err := t.service.AssignTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", []string{"user_1", "user_2"})
+		t.NoError(err)
+		t.Equal(1, c)
+	})
+
+	t.Run("unassign task success", func() {
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), bson.M{
+			"_id":          objectId,
+			"owner_id":     "owner_id",
+			"archive_date": nil,
+		}, bson.M{
+			"$pull": bson.M{
+				"assignees": bson.M{"$in": []string{"user_1"}},
+			},
+			"$set": bson.M{
+				"update_date": t.service.now().Unix(),
+			},
+			"$inc": bson.M{"version": 1},
+		}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(nil)
+		c, err := t.service.UnassignTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", []string{"user_1"})
+		t.NoError(err)
+		t.Equal(1, c)
+	})
+
+	t.Run("unassign task with malformed id should return error", func() {
+		_, err := t.service.UnassignTask(context.Background(), "owner_id", "bad", []string{"user_1"})
+		t.ErrorIs(err, ErrInvalidTaskID)
+	})
+}
+
+func (t *TaskManagerTestSuite) TestUpdateTask() {
+	topic := "new topic"
+	description := "new description"
+
+	t.Run("update task without fields should return error", func() {
+		c, err := t.service.UpdateTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", TaskUpdate{}, nil)
+		t.ErrorIs(err, ErrNothingToUpdate)
+		t.Equal(0, c)
+	})
+
+	t.Run("update task but update got error should return error", func() {
+		objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), bson.M{
+			"_id":          objectId,
+			"owner_id":     "owner_id",
+			"archive_date": nil,
+		}, bson.M{
+			"$set": bson.M{
+				"topic":       topic,
+				"update_date": t.service.now().Unix(),
+			},
+			"$inc": bson.M{"version": 1},
+		}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(errors.New("update one error"))
+		c, err := t.service.UpdateTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", TaskUpdate{Topic: &topic}, nil)
+		t.Equal(0, c)
+		t.EqualError(err, "update one error")
+	})
+
+	t.Run("update task with a stale version should return precondition error", func() {
+		objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), bson.M{
+			"_id":          objectId,
+			"owner_id":     "owner_id",
+			"archive_date": nil,
+			"version":      int64(3),
+		}, gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(mongo.ErrNoDocuments)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), bson.M{
+			"_id":      objectId,
+			"owner_id": "owner_id",
+		}).Return(int64(1), nil)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), bson.M{
+			"_id":          objectId,
+			"owner_id":     "owner_id",
+			"archive_date": nil,
+		}).Return(int64(1), nil)
+		version := int64(3)
+		c, err := t.service.UpdateTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", TaskUpdate{Topic: &topic}, &version)
+		t.ErrorIs(err, ErrVersionMismatch)
+		t.Equal(0, c)
+	})
+
+	t.Run("update task with version 0 should match a task without a version field", func() {
+		objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), bson.M{
+			"_id":          objectId,
+			"owner_id":     "owner_id",
+			"archive_date": nil,
+			"version":      bson.M{"$in": bson.A{int64(0), nil}},
+		}, gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).DoAndReturn(func(v interface{}) error {
+			raw, _ := bson.Marshal(bson.M{"_id": objectId, "owner_id": "owner_id", "topic": "old topic"})
+			return bson.Unmarshal(raw, v)
+		})
+		version := int64(0)
+		c, err := t.service.UpdateTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", TaskUpdate{Topic: &topic}, &version)
+		t.NoError(err)
+		t.Equal(1, c)
+	})
+
+	t.Run("update missing task with a version should match nothing", func() {
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), gomock.Any(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(mongo.ErrNoDocuments)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), gomock.Any()).Return(int64(0), nil)
+		version := int64(3)
+		c, err := t.service.UpdateTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", TaskUpdate{Topic: &topic}, &version)
+		t.NoError(err)
+		t.Equal(0, c)
+	})
+
+	t.Run("update task should set only supplied fields", func() {
+		objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), bson.M{
+			"_id":          objectId,
+			"owner_id":     "owner_id",
+			"archive_date": nil,
+		}, bson.M{
+			"$set": bson.M{
+				"topic":       topic,
+				"description": description,
+				"update_date": t.service.now().Unix(),
+			},
+			"$inc": bson.M{"version": 1},
+		}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(nil)
+		c, err := t.service.UpdateTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", TaskUpdate{Topic: &topic, Description: &description}, nil)
+		t.NoError(err)
+		t.Equal(1, c)
+	})
+
+	t.Run("update task priority should set it", func() {
+		objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+		priority := PriorityUrgent
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), bson.M{
+			"_id":          objectId,
+			"owner_id":     "owner_id",
+			"archive_date": nil,
+		}, bson.M{
+			"$set": bson.M{
+				"priority":    PriorityUrgent,
+				"update_date": t.service.now().Unix(),
+			},
+			"$inc": bson.M{"version": 1},
+		}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(nil)
+		c, err := t.service.UpdateTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", TaskUpdate{Priority: &priority}, nil)
+		t.NoError(err)
+		t.Equal(1, c)
+	})
+
+	t.Run("update task with zero date should unset it", func() {
+		objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+		due, start := int64(1569300000), int64(0)
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), bson.M{
+			"_id":          objectId,
+			"owner_id":     "owner_id",
+			"archive_date": nil,
+		}, bson.M{
+			"$set": bson.M{
+				"due_date":    due,
+				"update_date": t.service.now().Unix(),
+			},
+			"$unset": bson.M{
+				"start_date": "",
+			},
+			"$inc": bson.M{"version": 1},
+		}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(nil)
+		c, err := t.service.UpdateTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", TaskUpdate{StartDate: &start, DueDate: &due}, nil)
+		t.NoError(err)
+		t.Equal(1, c)
+	})
+
+	t.Run("update task starting after its due date should return error", func() {
+		start, due := int64(1569300000), int64(1569200000)
+		c, err := t.service.UpdateTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", TaskUpdate{StartDate: &start, DueDate: &due}, nil)
+		t.Equal(0, c)
+		t.ErrorIs(err, ErrInvalidSchedule)
+	})
+
+	t.Run("update task status with fields should save them in one write", func() {
+		objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+		status := TaskStatusInProgress
+		t.mockMongo.EXPECT().FindOne(context.Background(), bson.M{"_id": objectId, "owner_id": "owner_id"}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).SetArg(0, TaskDoc{Status: TaskStatusOpen, Version: 2}).Return(nil)
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), bson.M{
+			"_id":          objectId,
+			"owner_id":     "owner_id",
+			"archive_date": nil,
+			"version":      int64(2),
+			"status":       TaskStatusOpen,
+		}, bson.M{
+			"$set": bson.M{
+				"topic":       topic,
+				"status":      status,
+				"update_date": t.service.now().Unix(),
+			},
+			"$inc": bson.M{"version": 1},
+		}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(nil)
+		version := int64(2)
+		c, err := t.service.UpdateTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", TaskUpdate{Topic: &topic, Status: &status}, &version)
+		t.NoError(err)
+		t.Equal(1, c)
+	})
+
+	t.Run("update task with an illegal transition should not write anything", func() {
+		status := TaskStatusOpen
+		t.mockMongo.EXPECT().FindOne(context.Background(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).SetArg(0, TaskDoc{Status: TaskStatusInProgress}).Return(nil)
+		workflow, _ := NewWorkflow(config.Workflow{States: []config.WorkflowState{
+			{ID: 1, Name: "open", Transitions: []int{2}},
+			{ID: 2, Name: "in_progress", Transitions: []int{3}},
+			{ID: 3, Name: "done", Terminal: true},
+		}})
+		service := NewTaskManager(t.mockMongo, WithWorkflow(workflow))
+		c, err := service.UpdateTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", TaskUpdate{Topic: &topic, Status: &status}, nil)
+		t.ErrorIs(err, ErrIllegalTransition)
+		t.Equal(0, c)
+	})
+
+	t.Run("update task with an unknown status should return error", func() {
+		status := 9
+		c, err := t.service.UpdateTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", TaskUpdate{Topic: &topic, Status: &status}, nil)
+		t.ErrorIs(err, ErrInvalidStatus)
+		t.Equal(0, c)
+	})
+
+	t.Run("update start date should require it not after the stored due date", func() {
+		objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+		start := int64(1569300000)
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), bson.M{
+			"_id":          objectId,
+			"owner_id":     "owner_id",
+			"archive_date": nil,
+			"due_date":     bson.M{"$not": bson.M{"$lt": start}},
+		}, bson.M{
+			"$set": bson.M{
+				"start_date":  start,
+				"update_date": t.service.now().Unix(),
+			},
+			"$inc": bson.M{"version": 1},
+		}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(nil)
+		c, err := t.service.UpdateTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", TaskUpdate{StartDate: &start}, nil)
+		t.NoError(err)
+		t.Equal(1, c)
+	})
+
+	t.Run("update due date before the stored start date should return error", func() {
+		objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+		due := int64(1569200000)
+		guard := bson.M{"$not": bson.M{"$gt": due}}
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), bson.M{
+			"_id":          objectId,
+			"owner_id":     "owner_id",
+			"archive_date": nil,
+			"start_date":   guard,
+		}, gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(mongo.ErrNoDocuments)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), bson.M{
+			"_id":      objectId,
+			"owner_id": "owner_id",
+		}).Return(int64(1), nil)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), bson.M{
+			"_id":          objectId,
+			"owner_id":     "owner_id",
+			"archive_date": nil,
+		}).Return(int64(1), nil)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), bson.M{
+			"_id":        objectId,
+			"owner_id":   "owner_id",
+			"start_date": guard,
+		}).Return(int64(0), nil)
+		c, err := t.service.UpdateTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", TaskUpdate{DueDate: &due}, nil)
+		t.Equal(0, c)
+		t.ErrorIs(err, ErrInvalidSchedule)
+	})
+}
+
+func (t *TaskManagerTestSuite) TestGetAllTask() {
+	l := int64(11)
+	skip := int64(1*10 - 10)
+	fOpt := &options.FindOptions{Limit: &l, Skip: &skip}
+
+	t.Run("get all task but find got error should return error", func() {
+		t.mockMongo.EXPECT().Find(context.Background(), bson.M{
+			"$or": []bson.M{
+				{
+					"archive_date": bson.M{
+						"$exists": false,
+					},
+				},
+				{
+					"archive_date": nil,
+				},
+			},
+		}, fOpt).Return(t.cursor, errors.New("find error"))
+		_, _, err := t.service.GetAllTask(context.Background(), TaskFilter{}, m.Pagination{Page: 1, Limit: 10})
+		t.Error(err)
+		t.EqualError(err, "find error")
+	})
+
+	t.Run("get all task success but cursor decode error", func() {
+		t.mockMongo.EXPECT().Find(context.Background(), bson.M{
+			"$or": []bson.M{
+				{
+					"archive_date": bson.M{
+						"$exists": false,
+					},
+				},
+				{
+					"archive_date": nil,
+				},
+			},
+		}, fOpt).Return(t.cursor, nil)
+		taskDocs := make([]TaskDoc, 0)
+		t.cursor.EXPECT().All(context.Background(), &taskDocs).DoAndReturn(func(ctx context.Context, result interface{}) error {
+			return errors.New("cursor decode error")
+		}).Times(1)
+		tasks, _, err := t.service.GetAllTask(context.Background(), TaskFilter{}, m.Pagination{Page: 1, Limit: 10})
+		t.NotNil(err)
+		t.EqualError(err, "cursor decode error")
+		t.Nil(tasks)
+	})
+
+	t.Run("get all task success", func() {
+		t.mockMongo.EXPECT().Find(context.Background(), bson.M{
+			"$or": []bson.M{
+				{
+					"archive_date": bson.M{
+						"$exists": false,
+					},
+				},
+				{
+					"archive_date": nil,
+				},
+			},
+		}, fOpt).Return(t.cursor, nil)
+		taskDocs := make([]TaskDoc, 0)
+		t.cursor.EXPECT().All(context.Background(), &taskDocs).DoAndReturn(func(ctx context.Context, result interface{}) error {
+			taskDocs = append(taskDocs, TaskDoc{
+				ID:          "6041c3a6cfcba2fb9c4a4fd2",
+				Topic:       "topic",
+				Description: "description",
+				Status:      1,
+				CreateDate:  1614962551,
+			})
+			taskDocs = append(taskDocs, TaskDoc{
 				ID:          "6041c3a6cfcba2fb9c4a4fd3",
 				Topic:       "topic2",
 				Description: "description2",
@@ -373,11 +1387,477 @@ func (t *TaskManagerTestSuite) TestGetAllTask() {
 			reflect.ValueOf(result).Elem().Set(reflect.ValueOf(taskDocs))
 			return nil
 		}).Times(1)
-		tasks, err := t.service.GetAllTask(context.Background(), 1, 10)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), bson.M{
+			"$or": []bson.M{
+				{
+					"archive_date": bson.M{
+						"$exists": false,
+					},
+				},
+				{
+					"archive_date": nil,
+				},
+			},
+		}).Return(int64(2), nil)
+		tasks, pageInfo, err := t.service.GetAllTask(context.Background(), TaskFilter{}, m.Pagination{Page: 1, Limit: 10})
 		t.NoError(err)
 		t.NotNil(tasks)
+		t.Equal(int64(2), *pageInfo.Total)
+		t.False(pageInfo.HasNext)
 		t.Equal(2, len(tasks))
 		t.Equal("6041c3a6cfcba2fb9c4a4fd2", tasks[0].ID)
 		t.Equal("topic", tasks[0].Topic)
 	})
 }
+
+func (t *TaskManagerTestSuite) TestGetAllTaskFilter() {
+	l := int64(11)
+	skip := int64(10)
+	createdFrom := int64(1614962551)
+	createdTo := int64(1614962999)
+	updatedFrom := int64(1614962600)
+
+	t.Run("get all task should build query and sort from filter", func() {
+		fOpt := &options.FindOptions{Limit: &l, Skip: &skip}
+		fOpt.SetSort(bson.D{
+			{Key: "status", Value: 1},
+			{Key: "create_date", Value: -1},
+			{Key: "_id", Value: -1},
+		})
+		t.mockMongo.EXPECT().Find(context.Background(), bson.M{
+			"$or": []bson.M{
+				{
+					"archive_date": bson.M{
+						"$exists": false,
+					},
+				},
+				{
+					"archive_date": nil,
+				},
+			},
+			"owner_id":    "owner_id",
+			"assignees":   "user_1",
+			"status":      bson.M{"$in": []int{1, 2}},
+			"create_date": bson.M{"$gte": createdFrom, "$lte": createdTo},
+			"update_date": bson.M{"$gte": updatedFrom},
+		}, fOpt).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).Return(nil)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), gomock.Any()).Return(int64(0), nil)
+		tasks, _, err := t.service.GetAllTask(context.Background(), TaskFilter{
+			OwnerID:     "owner_id",
+			Assignee:    "user_1",
+			Status:      []int{1, 2},
+			CreatedFrom: &createdFrom,
+			CreatedTo:   &createdTo,
+			UpdatedFrom: &updatedFrom,
+			Sort:        []SortField{{Field: "status"}, {Field: "create_date", Desc: true}},
+		}, m.Pagination{Page: 2, Limit: 10})
+		t.NoError(err)
+		t.NotNil(tasks)
+	})
+}
+
+func (t *TaskManagerTestSuite) TestTaskFilterPriority() {
+	t.Run("priority filter should match the priorities", func() {
+		query := TaskFilter{Priority: []int{PriorityHigh}}.query(t.service.now(), nil)
+		t.Equal(bson.M{"$in": []int{PriorityHigh}}, query["priority"])
+		t.NotContains(query, "$and")
+	})
+
+	t.Run("priority filter with none should match tasks without priority", func() {
+		query := TaskFilter{Priority: []int{PriorityNone, PriorityHigh}}.query(t.service.now(), nil)
+		t.NotContains(query, "priority")
+		t.Equal([]bson.M{{"$or": []bson.M{
+			{"priority": bson.M{"$in": []int{PriorityNone, PriorityHigh}}},
+			{"priority": bson.M{"$exists": false}},
+		}}}, query["$and"])
+	})
+}
+
+func (t *TaskManagerTestSuite) TestGetAllTaskArchived() {
+	l := int64(11)
+	skip := int64(0)
+
+	t.Run("get archived tasks should only match archived tasks", func() {
+		fOpt := &options.FindOptions{Limit: &l, Skip: &skip}
+		fOpt.SetSort(bson.D{
+			{Key: "archive_date", Value: -1},
+			{Key: "_id", Value: -1},
+		})
+		t.mockMongo.EXPECT().Find(context.Background(), bson.M{
+			"archive_date": bson.M{"$ne": nil},
+			"owner_id":     "owner_id",
+		}, fOpt).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).Return(nil)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), gomock.Any()).Return(int64(0), nil)
+		tasks, _, err := t.service.GetAllTask(context.Background(), TaskFilter{
+			OwnerID:  "owner_id",
+			Archived: true,
+			Sort:     []SortField{{Field: "archive_date", Desc: true}},
+		}, m.Pagination{Page: 1, Limit: 10})
+		t.NoError(err)
+		t.NotNil(tasks)
+	})
+}
+
+func (t *TaskManagerTestSuite) TestGetAllTaskDueFilter() {
+	archived := []bson.M{
+		{
+			"archive_date": bson.M{
+				"$exists": false,
+			},
+		},
+		{
+			"archive_date": nil,
+		},
+	}
+
+	t.Run("get all task with due range should filter due date", func() {
+		after, before := int64(1569000000), int64(1569500000)
+		query := bson.M{
+			"$or":      archived,
+			"due_date": bson.M{"$gte": after, "$lte": before},
+		}
+		t.mockMongo.EXPECT().Find(context.Background(), query, gomock.Any()).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).Return(nil)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), query).Return(int64(0), nil)
+		_, _, err := t.service.GetAllTask(context.Background(), TaskFilter{DueAfter: &after, DueBefore: &before}, m.Pagination{Page: 1, Limit: 10})
+		t.NoError(err)
+	})
+
+	t.Run("get all overdue task should exclude done tasks", func() {
+		query := bson.M{
+			"$or":      archived,
+			"status":   bson.M{"$in": []int{1}, "$nin": []int{TaskStatusDone}},
+			"due_date": bson.M{"$lt": t.service.now().Unix()},
+		}
+		t.mockMongo.EXPECT().Find(context.Background(), query, gomock.Any()).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).Return(nil)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), query).Return(int64(0), nil)
+		_, _, err := t.service.GetAllTask(context.Background(), TaskFilter{Status: []int{1}, Overdue: true}, m.Pagination{Page: 1, Limit: 10})
+		t.NoError(err)
+	})
+}
+
+func (t *TaskManagerTestSuite) TestGetAllTaskKeyset() {
+	query := bson.M{
+		"$or": []bson.M{
+			{
+				"archive_date": bson.M{
+					"$exists": false,
+				},
+			},
+			{
+				"archive_date": nil,
+			},
+		},
+	}
+
+	t.Run("get all task first keyset page should return next cursor", func() {
+		fOpt := options.Find().SetSort(bson.D{{Key: "create_date", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(3)
+		t.mockMongo.EXPECT().Find(context.Background(), query, fOpt).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, result interface{}) error {
+			reflect.ValueOf(result).Elem().Set(reflect.ValueOf([]TaskDoc{
+				{ID: "6041c3a6cfcba2fb9c4a4fd4", CreateDate: 1614962554},
+				{ID: "6041c3a6cfcba2fb9c4a4fd3", CreateDate: 1614962553},
+				{ID: "6041c3a6cfcba2fb9c4a4fd2", CreateDate: 1614962552},
+			}))
+			return nil
+		})
+		tasks, pageInfo, err := t.service.GetAllTask(context.Background(), TaskFilter{
+			Sort: []SortField{{Field: "create_date", Desc: true}},
+		}, m.Pagination{Limit: 2, SkipCount: true})
+		t.NoError(err)
+		t.Len(tasks, 2)
+		t.Nil(pageInfo.Total)
+		t.True(pageInfo.HasNext)
+		t.NotEmpty(pageInfo.NextCursor)
+	})
+
+	t.Run("get all task but count got error should return error", func() {
+		t.mockMongo.EXPECT().Find(context.Background(), query, gomock.Any()).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).Return(nil)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), query).Return(int64(0), errors.New("count error"))
+		tasks, _, err := t.service.GetAllTask(context.Background(), TaskFilter{}, m.Pagination{Limit: 2})
+		t.Nil(tasks)
+		t.EqualError(err, "count error")
+	})
+
+	t.Run("get all task with malformed cursor should return error", func() {
+		tasks, _, err := t.service.GetAllTask(context.Background(), TaskFilter{}, m.Pagination{Limit: 2, Cursor: "%%%"})
+		t.Nil(tasks)
+		t.ErrorIs(err, m.ErrInvalidCursor)
+	})
+}
+
+func (t *TaskManagerTestSuite) TestParseSort() {
+	t.Run("parse sort should read direction prefix", func() {
+		fields, err := ParseSort("-update_date, topic")
+		t.NoError(err)
+		t.Equal([]SortField{{Field: "update_date", Desc: true}, {Field: "topic"}}, fields)
+	})
+
+	t.Run("parse sort empty should return no fields", func() {
+		fields, err := ParseSort("")
+		t.NoError(err)
+		t.Nil(fields)
+	})
+
+	t.Run("parse sort triage should expand to priority then due date", func() {
+		fields, err := ParseSort("triage,-create_date")
+		t.NoError(err)
+		t.Equal([]SortField{
+			{Field: "priority", Desc: true},
+			{Field: "due_date"},
+			{Field: "create_date", Desc: true},
+		}, fields)
+	})
+
+	t.Run("parse sort should reject fields outside whitelist", func() {
+		_, err := ParseSort("owner_id")
+		t.ErrorIs(err, ErrInvalidSort)
+		_, err = ParseSort("$where")
+		t.ErrorIs(err, ErrInvalidSort)
+	})
+}
+
+func (t *TaskManagerTestSuite) TestLabels() {
+	objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+
+	t.Run("add labels success", func() {
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), bson.M{
+			"_id":          objectId,
+			"owner_id":     "owner_id",
+			"archive_date": nil,
+		}, bson.M{
+			"$addToSet": bson.M{
+				"labels": bson.M{"$each": []string{"bug", "backend"}},
+			},
+			"$set": bson.M{
+				"update_date": t.service.now().Unix(),
+			},
+			"$inc": bson.M{"version": 1},
+		}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(nil)
+		c, err := t.service.AddLabels(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", []string{"bug", "backend"})
+		t.NoError(err)
+		t.Equal(1, c)
+	})
+
+	t.Run("remove labels but update got error should return error", func() {
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), bson.M{
+			"_id":          objectId,
+			"owner_id":     "owner_id",
+			"archive_date": nil,
+		}, bson.M{
+			"$pull": bson.M{
+				"labels": bson.M{"$in": []string{"bug"}},
+			},
+			"$set": bson.M{
+				"update_date": t.service.now().Unix(),
+			},
+			"$inc": bson.M{"version": 1},
+		}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(gomock.Any()).Return(errors.New("update one error"))
+		c, err := t.service.RemoveLabels(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", []string{"bug"})
+		t.Equal(0, c)
+		t.EqualError(err, "update one error")
+	})
+
+	t.Run("get labels should aggregate usage counts", func() {
+		t.mockMongo.EXPECT().Aggregate(context.Background(), []bson.M{
+			{"$match": bson.M{
+				"$or": []bson.M{
+					{
+						"archive_date": bson.M{
+							"$exists": false,
+						},
+					},
+					{
+						"archive_date": nil,
+					},
+				},
+				"owner_id": "owner_id",
+				"labels":   bson.M{"$exists": true},
+			}},
+			{"$unwind": "$labels"},
+			{"$group": bson.M{"_id": "$labels", "count": bson.M{"$sum": 1}}},
+			{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+		}).Return(t.cursor, nil)
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, result interface{}) error {
+			reflect.ValueOf(result).Elem().Set(reflect.ValueOf([]LabelCount{{Label: "bug", Count: 3}}))
+			return nil
+		})
+		labels, err := t.service.GetLabels(context.Background(), "owner_id")
+		t.NoError(err)
+		t.Equal([]LabelCount{{Label: "bug", Count: 3}}, labels)
+	})
+
+	t.Run("get labels but aggregate got error should return error", func() {
+		t.mockMongo.EXPECT().Aggregate(context.Background(), gomock.Any()).Return(nil, errors.New("aggregate error"))
+		labels, err := t.service.GetLabels(context.Background(), "")
+		t.Nil(labels)
+		t.EqualError(err, "aggregate error")
+	})
+
+	t.Run("get all task should match any or all labels", func() {
+		t.mockMongo.EXPECT().Find(context.Background(), gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (m.Cursor, error) {
+			t.Equal(bson.M{"$all": []string{"bug", "ui"}}, filter.(bson.M)["labels"])
+			return t.cursor, nil
+		})
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).Return(nil)
+		_, _, err := t.service.GetAllTask(context.Background(), TaskFilter{Labels: []string{"bug", "ui"}, AllLabels: true}, m.Pagination{Page: 1, Limit: 10, SkipCount: true})
+		t.NoError(err)
+
+		t.mockMongo.EXPECT().Find(context.Background(), gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (m.Cursor, error) {
+			t.Equal(bson.M{"$in": []string{"bug", "ui"}}, filter.(bson.M)["labels"])
+			return t.cursor, nil
+		})
+		t.cursor.EXPECT().All(context.Background(), gomock.Any()).Return(nil)
+		_, _, err = t.service.GetAllTask(context.Background(), TaskFilter{Labels: []string{"bug", "ui"}}, m.Pagination{Page: 1, Limit: 10, SkipCount: true})
+		t.NoError(err)
+	})
+
+	t.Run("normalize labels should trim, lower case and dedupe", func() {
+		labels, err := NormalizeLabels([]string{" Bug", "bug ", "UI"})
+		t.NoError(err)
+		t.Equal([]string{"bug", "ui"}, labels)
+
+		_, err = NormalizeLabels([]string{"  "})
+		t.ErrorIs(err, ErrInvalidLabel)
+		_, err = NormalizeLabels([]string{strings.Repeat("x", 51)})
+		t.ErrorIs(err, ErrInvalidLabel)
+	})
+}
+
+func (t *TaskManagerTestSuite) TestParsePriority() {
+	t.Run("parse priority should accept names and levels", func() {
+		for value, want := range map[string]int{"none": PriorityNone, "Urgent": PriorityUrgent, "2": PriorityMedium, " high ": PriorityHigh} {
+			priority, err := ParsePriority(value)
+			t.NoError(err)
+			t.Equal(want, priority, value)
+		}
+	})
+
+	t.Run("parse priority should reject unknown values", func() {
+		_, err := ParsePriority("critical")
+		t.ErrorIs(err, ErrInvalidPriority)
+		_, err = ParsePriority("5")
+		t.ErrorIs(err, ErrInvalidPriority)
+	})
+}
+
+func (t *TaskManagerTestSuite) TestEnsureIndexes() {
+	t.Run("ensure indexes but create got error should return error", func() {
+		t.mockMongo.EXPECT().CreateIndexes(context.Background(), gomock.Any()).Return(nil, errors.New("create indexes error"))
+		t.EqualError(t.service.EnsureIndexes(context.Background()), "create indexes error")
+	})
+
+	t.Run("ensure indexes success", func() {
+		t.mockMongo.EXPECT().CreateIndexes(context.Background(), gomock.Any()).Return([]string{"create_date_1__id_1"}, nil)
+		t.NoError(t.service.EnsureIndexes(context.Background()))
+	})
+}
+
+func (t *TaskManagerTestSuite) TestHistory() {
+	objectId, _ := primitive.ObjectIDFromHex("6041c3a6cfcba2fb9c4a4fd2")
+	owned := bson.M{"_id": objectId, "owner_id": "owner_id", "archive_date": nil}
+	recorder := mock_taskmanager.NewMockIHistory(t.ctrl)
+	t.service.history = recorder
+
+	t.Run("create task records the creation", func() {
+		t.mockMongo.EXPECT().InsertOne(context.Background(), gomock.Any()).Return(&mongo.InsertOneResult{InsertedID: objectId}, nil)
+		recorder.EXPECT().Record(context.Background(), history.Change{
+			TaskID: "6041c3a6cfcba2fb9c4a4fd2", Actor: "owner_id", Field: history.FieldCreated, New: "topic",
+		}).Return(nil)
+		_, err := t.service.CreateTask(context.Background(), "owner_id", NewTask{Topic: "topic", Description: "description"})
+		t.NoError(err)
+	})
+
+	t.Run("update task status records the old and new status", func() {
+		t.mockMongo.EXPECT().FindOne(context.Background(), gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).SetArg(0, TaskDoc{ID: "6041c3a6cfcba2fb9c4a4fd2", Status: TaskStatusInProgress}).Return(nil)
+		t.mockMongo.EXPECT().UpdateOne(context.Background(), gomock.Any(), gomock.Any()).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+		recorder.EXPECT().Record(context.Background(), history.Change{
+			TaskID: "6041c3a6cfcba2fb9c4a4fd2", Actor: "assignee", Field: "status", Old: TaskStatusInProgress, New: TaskStatusOpen,
+		}).Return(nil)
+		t.NoError(t.service.UpdateTaskStatus(context.Background(), "assignee", "6041c3a6cfcba2fb9c4a4fd2", TaskStatusOpen, false, nil))
+	})
+
+	t.Run("update task records the supplied fields against the task before", func() {
+		due := int64(1614962551)
+		topic := "new topic"
+		cleared := int64(0)
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), owned, gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).SetArg(0, TaskDoc{ID: "6041c3a6cfcba2fb9c4a4fd2", Topic: "topic", DueDate: &due}).Return(nil)
+		recorder.EXPECT().Record(context.Background(),
+			history.Change{TaskID: "6041c3a6cfcba2fb9c4a4fd2", Actor: "owner_id", Field: "topic", Old: "topic", New: "new topic"},
+			history.Change{TaskID: "6041c3a6cfcba2fb9c4a4fd2", Actor: "owner_id", Field: "due_date", Old: &due, New: (*int64)(nil)},
+		).Return(nil)
+		c, err := t.service.UpdateTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", TaskUpdate{Topic: &topic, DueDate: &cleared}, nil)
+		t.NoError(err)
+		t.Equal(1, c)
+	})
+
+	t.Run("assign task records the assignees before and after", func() {
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), owned, gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).SetArg(0, TaskDoc{ID: "6041c3a6cfcba2fb9c4a4fd2", Assignees: []string{"user_1"}}).Return(nil)
+		recorder.EXPECT().Record(context.Background(), history.Change{
+			TaskID: "6041c3a6cfcba2fb9c4a4fd2", Actor: "owner_id", Field: "assignees",
+			Old: []string{"user_1"}, New: []string{"user_1", "user_2"},
+		}).Return(nil)
+		_, err := t.service.AssignTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", []string{"user_1", "user_2"})
+		t.NoError(err)
+	})
+
+	t.Run("remove labels records the labels left", func() {
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), owned, gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).SetArg(0, TaskDoc{ID: "6041c3a6cfcba2fb9c4a4fd2", Labels: []string{"bug"}}).Return(nil)
+		recorder.EXPECT().Record(context.Background(), history.Change{
+			TaskID: "6041c3a6cfcba2fb9c4a4fd2", Actor: "owner_id", Field: "labels", Old: []string{"bug"}, New: []string(nil),
+		}).Return(nil)
+		_, err := t.service.RemoveLabels(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", []string{"bug"})
+		t.NoError(err)
+	})
+
+	t.Run("archive task not owned records nothing", func() {
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), owned, gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).Return(mongo.ErrNoDocuments)
+		t.mockMongo.EXPECT().CountDocuments(context.Background(), bson.M{"_id": objectId, "owner_id": "owner_id"}).Return(int64(0), nil)
+		c, err := t.service.ArchiveTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", nil)
+		t.NoError(err)
+		t.Equal(0, c)
+	})
+
+	t.Run("archive task succeeds when recording fails", func() {
+		t.mockMongo.EXPECT().FindOneAndUpdate(context.Background(), owned, gomock.Any()).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).SetArg(0, TaskDoc{ID: "6041c3a6cfcba2fb9c4a4fd2"}).Return(nil)
+		recorder.EXPECT().Record(context.Background(), gomock.Any()).Return(errors.New("insert many error"))
+		c, err := t.service.ArchiveTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2", nil)
+		t.NoError(err)
+		t.Equal(1, c)
+	})
+
+	t.Run("delete task records the deleted topic", func() {
+		t.mockMongo.EXPECT().FindOneAndDelete(context.Background(), bson.M{"_id": objectId, "owner_id": "owner_id"}).Return(t.singleResult)
+		t.singleResult.EXPECT().Decode(&TaskDoc{}).SetArg(0, TaskDoc{ID: "6041c3a6cfcba2fb9c4a4fd2", Topic: "topic"}).Return(nil)
+		recorder.EXPECT().Record(context.Background(), history.Change{
+			TaskID: "6041c3a6cfcba2fb9c4a4fd2", Actor: "owner_id", Field: history.FieldDeleted, Old: "topic",
+		}).Return(nil)
+		_, err := t.service.DeleteTask(context.Background(), "owner_id", "6041c3a6cfcba2fb9c4a4fd2")
+		t.NoError(err)
+	})
+
+	t.Run("purge records deletions by the system of removed tasks only", func() {
+		t.mockMongo.EXPECT().FindOneAndDelete(context.Background(), gomock.Any(), gomock.Any()).Return(t.singleResult).Times(2)
+		gomock.InOrder(
+			t.singleResult.EXPECT().Decode(&TaskDoc{}).SetArg(0, TaskDoc{ID: "6041c3a6cfcba2fb9c4a4fd2"}).Return(nil),
+			t.singleResult.EXPECT().Decode(&TaskDoc{}).Return(mongo.ErrNoDocuments),
+		)
+		recorder.EXPECT().Record(context.Background(), history.Change{
+			TaskID: "6041c3a6cfcba2fb9c4a4fd2", Actor: history.SystemActor, Field: history.FieldDeleted,
+		}).Return(nil)
+		_, err := t.service.DeleteTasks(context.Background(), []string{"6041c3a6cfcba2fb9c4a4fd2", "6041c3a6cfcba2fb9c4a4fd3"})
+		t.NoError(err)
+	})
+}
@@ -0,0 +1,128 @@
+package taskmanager
+
+import (
+	"fmt"
+	"task-manager-api/config"
+	"task-manager-api/internal/apperror"
+)
+
+var (
+	ErrInvalidStatus     = apperror.Validation("invalid_status", "Invalid status")
+	ErrIllegalTransition = apperror.Conflict("illegal_transition", "Status transition not allowed")
+	ErrStatusChanged     = apperror.Conflict("status_changed", "Task status was changed by another request")
+	ErrOpenSubtasks      = apperror.Conflict("open_subtasks", "Task has unfinished subtasks")
+)
+
+type State struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Terminal    bool   `json:"terminal"`
+	Transitions []int  `json:"transitions"`
+}
+
+// Workflow holds the task states and the transitions allowed between them.
+type Workflow struct {
+	states              []State
+	byID                map[int]State
+	requireSubtasksDone bool
+}
+
+// DefaultWorkflow is the historical open, in progress and done statuses
+// where any status can move to any other and done is terminal.
+func DefaultWorkflow() *Workflow {
+	w, _ := NewWorkflow(config.Workflow{States: []config.WorkflowState{
+		{ID: TaskStatusOpen, Name: "open", Transitions: []int{TaskStatusInProgress, TaskStatusDone}},
+		{ID: TaskStatusInProgress, Name: "in_progress", Transitions: []int{TaskStatusOpen, TaskStatusDone}},
+		{ID: TaskStatusDone, Name: "done", Terminal: true, Transitions: []int{TaskStatusOpen, TaskStatusInProgress}},
+	}})
+	return w
+}
+
+// NewWorkflow validates a configured workflow, a configuration without
+// states uses the states of DefaultWorkflow.
+func NewWorkflow(conf config.Workflow) (*Workflow, error) {
+	if len(conf.States) == 0 {
+		w := DefaultWorkflow()
+		w.requireSubtasksDone = conf.RequireSubtasksDone
+		return w, nil
+	}
+
+	w := &Workflow{byID: map[int]State{}, requireSubtasksDone: conf.RequireSubtasksDone}
+	names := map[string]bool{}
+	for _, s := range conf.States {
+		if s.ID < 1 {
+			return nil, fmt.Errorf("workflow state %q: id must be positive", s.Name)
+		}
+		if _, ok := w.byID[s.ID]; ok {
+			return nil, fmt.Errorf("workflow state %v: duplicate id", s.ID)
+		}
+		if s.Name == "" || names[s.Name] {
+			return nil, fmt.Errorf("workflow state %v: name must be unique and not empty", s.ID)
+		}
+		names[s.Name] = true
+		state := State{ID: s.ID, Name: s.Name, Terminal: s.Terminal, Transitions: s.Transitions}
+		if state.Transitions == nil {
+			state.Transitions = []int{}
+		}
+		w.byID[s.ID] = state
+		w.states = append(w.states, state)
+	}
+	for _, s := range w.states {
+		for _, to := range s.Transitions {
+			if _, ok := w.byID[to]; !ok {
+				return nil, fmt.Errorf("workflow state %v: transition to unknown state %v", s.ID, to)
+			}
+		}
+	}
+	return w, nil
+}
+
+// States returns the states in configuration order.
+func (w *Workflow) States() []State {
+	return w.states
+}
+
+// Initial is the state new tasks start in.
+func (w *Workflow) Initial() int {
+	return w.states[0].ID
+}
+
+func (w *Workflow) Valid(status int) bool {
+	_, ok := w.byID[status]
+	return ok
+}
+
+// CanTransition reports whether a task may move from one status to another.
+// Staying in the same status is always allowed.
+func (w *Workflow) CanTransition(from int, to int) bool {
+	if from == to {
+		return w.Valid(to)
+	}
+	for _, next := range w.byID[from].Transitions {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether a status counts as finished.
+func (w *Workflow) IsTerminal(status int) bool {
+	return w.byID[status].Terminal
+}
+
+// RequireSubtasksDone reports whether a task can only finish after its subtasks.
+func (w *Workflow) RequireSubtasksDone() bool {
+	return w.requireSubtasksDone
+}
+
+// Terminal returns the ids of the states that count as finished.
+func (w *Workflow) Terminal() []int {
+	terminal := make([]int, 0)
+	for _, s := range w.states {
+		if s.Terminal {
+			terminal = append(terminal, s.ID)
+		}
+	}
+	return terminal
+}
@@ -0,0 +1,56 @@
+package taskmanager
+
+import (
+	"task-manager-api/config"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type WorkflowTestSuite struct {
+	suite.Suite
+}
+
+func TestWorkflowTestSuite(t *testing.T) {
+	suite.Run(t, new(WorkflowTestSuite))
+}
+
+func (t *WorkflowTestSuite) TestNewWorkflow() {
+	t.Run("empty configuration should use default workflow", func() {
+		w, err := NewWorkflow(config.Workflow{})
+		t.NoError(err)
+		t.Equal(TaskStatusOpen, w.Initial())
+		t.Equal([]int{TaskStatusDone}, w.Terminal())
+		t.True(w.CanTransition(TaskStatusDone, TaskStatusOpen))
+	})
+
+	t.Run("configured workflow should only allow listed transitions", func() {
+		w, err := NewWorkflow(config.Workflow{States: []config.WorkflowState{
+			{ID: 10, Name: "backlog", Transitions: []int{20}},
+			{ID: 20, Name: "doing", Transitions: []int{30}},
+			{ID: 30, Name: "shipped", Terminal: true},
+		}})
+		t.NoError(err)
+		t.Equal(10, w.Initial())
+		t.True(w.CanTransition(10, 20))
+		t.True(w.CanTransition(20, 20))
+		t.False(w.CanTransition(10, 30))
+		t.False(w.CanTransition(30, 10))
+		t.False(w.CanTransition(1, 10))
+		t.False(w.Valid(1))
+		t.Equal([]int{30}, w.Terminal())
+	})
+
+	t.Run("invalid configuration should return error", func() {
+		cases := map[string][]config.WorkflowState{
+			"duplicate id":       {{ID: 1, Name: "a"}, {ID: 1, Name: "b"}},
+			"duplicate name":     {{ID: 1, Name: "a"}, {ID: 2, Name: "a"}},
+			"zero id":            {{ID: 0, Name: "a"}},
+			"unknown transition": {{ID: 1, Name: "a", Transitions: []int{2}}},
+		}
+		for name, states := range cases {
+			_, err := NewWorkflow(config.Workflow{States: states})
+			t.Error(err, name)
+		}
+	})
+}
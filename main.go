@@ -7,11 +7,20 @@ import (
 	"os/signal"
 	"syscall"
 	"task-manager-api/config"
+	"task-manager-api/internal/auth"
 	"task-manager-api/internal/comment"
 	"task-manager-api/internal/handler"
+	"task-manager-api/internal/history"
+	"task-manager-api/internal/mention"
 	"task-manager-api/internal/mongo"
 	"task-manager-api/internal/profile"
+	"task-manager-api/internal/reaction"
+	"task-manager-api/internal/recurrence"
+	"task-manager-api/internal/retention"
+	"task-manager-api/internal/scheduler"
 	"task-manager-api/internal/taskmanager"
+	"time"
+	_ "time/tzdata"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -36,31 +45,107 @@ func main() {
 	mongoTaskCollection := mongoDB.GetCollection(config.Conf.MongoDB.Collections.Tasks)
 	profileCollection := mongoDB.GetCollection(config.Conf.MongoDB.Collections.Profiles)
 	commentCollection := mongoDB.GetCollection(config.Conf.MongoDB.Collections.Comments)
+	recurrenceCollection := mongoDB.GetCollection(config.Conf.MongoDB.Collections.Recurrences)
+	historyCollection := mongoDB.GetCollection(config.Conf.MongoDB.Collections.History)
+	mentionCollection := mongoDB.GetCollection(config.Conf.MongoDB.Collections.Mentions)
+	reactionCollection := mongoDB.GetCollection(config.Conf.MongoDB.Collections.Reactions)
 
-	// Initialize services and handlers
-	taskService := taskmanager.NewTaskManager(mongo.NewCollectionHelper(mongoTaskCollection))
-	pfService := profile.NewProfileService(mongo.NewCollectionHelper(profileCollection))
-	commentService := comment.NewCommentService(mongo.NewCollectionHelper(commentCollection))
-	handler := handler.NewHandler(taskService, commentService, pfService)
+	// Initialize token verifier
+	verifier, err := auth.NewVerifier(config.Conf.Auth)
+	if err != nil {
+		log.Fatalf("failed to initialize token verifier: %v", err)
+	}
 
 	// Initialize Fiber app
 	app := fiber.New(fiber.Config{
-		ErrorHandler: errorHandler,
+		ErrorHandler: handler.ErrorHandler,
 	})
+	app.Use(handler.CacheControl(config.Conf.Cache))
+
+	// Initialize services and handlers
+	workflow, err := taskmanager.NewWorkflow(config.Conf.Workflow)
+	if err != nil {
+		log.Fatalf("invalid workflow configuration: %v", err)
+	}
+	historyService := history.NewHistoryService(mongo.NewCollectionHelper(historyCollection))
+	if err := historyService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("failed to create history indexes: %v", err)
+	}
+	taskService := taskmanager.NewTaskManager(mongo.NewCollectionHelper(mongoTaskCollection),
+		taskmanager.WithWorkflow(workflow), taskmanager.WithHistory(historyService))
+	if err := taskService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("failed to create task indexes: %v", err)
+	}
+	pfService := profile.NewProfileService(mongo.NewCollectionHelper(profileCollection))
+	mentionService := mention.NewMentionService(mongo.NewCollectionHelper(mentionCollection))
+	if err := mentionService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("failed to create mention indexes: %v", err)
+	}
+	commentService := comment.NewCommentService(mongo.NewCollectionHelper(commentCollection), taskService, historyService, pfService, mentionService)
+	if err := commentService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("failed to create comment indexes: %v", err)
+	}
+	reactionService := reaction.NewReactionService(mongo.NewCollectionHelper(reactionCollection), taskService, commentService, config.Conf.Reactions.Emojis)
+	if err := reactionService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("failed to create reaction indexes: %v", err)
+	}
+	recurrenceService := recurrence.NewRecurrenceService(mongo.NewCollectionHelper(recurrenceCollection), taskService)
+	if err := recurrenceService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("failed to create recurrence indexes: %v", err)
+	}
+	handler := handler.NewHandler(taskService, commentService, pfService, verifier, recurrenceService, historyService, mentionService, reactionService)
+
+	// Start background jobs
+	purger := retention.NewPurger(taskService, commentService, reactionService, mentionService, config.Conf.Retention.ArchivedTasks*time.Second)
+	schedulers := []*scheduler.Scheduler{
+		scheduler.NewScheduler(recurrenceService,
+			config.Conf.Recurrence.PollInterval*time.Second, config.Conf.Recurrence.RunTimeout*time.Second),
+		scheduler.NewScheduler(purger,
+			config.Conf.Retention.PollInterval*time.Second, config.Conf.Retention.RunTimeout*time.Second),
+	}
+	for _, s := range schedulers {
+		s.Start()
+	}
 
 	// Define routes
 	app.Get("/tasks", handler.GetAllTask)
 	app.Get("/tasks/:taskId", handler.GetTask)
+	app.Get("/workflow", handler.GetWorkflow)
+	app.Get("/labels", handler.GetLabels)
 	app.Get("/profiles/:ownerId", handler.GetProfile)
 	app.Get("/profiles", handler.GetProfileList)
 	app.Get("/tasks/:taskId/comments", handler.GetTopicComments)
-
-	customerGroup := app.Group("/account")
-	customerGroup.Use(authInterceptor)
-	customerGroup.Post(":ownerId/tasks", handler.CreateTask)
-	customerGroup.Post(":ownerId/tasks/:taskId/comments", handler.CreateComment)
-	customerGroup.Patch(":ownerId/tasks/:taskId", handler.UpdateTask)
-	customerGroup.Patch(":ownerId/tasks/:taskId/archive", handler.ArchiveTask)
+	app.Get("/tasks/:taskId/comments/:commentId/replies", handler.GetCommentReplies)
+	app.Get("/tasks/:taskId/subtasks", handler.GetSubtasks)
+	app.Get("/tasks/:taskId/history", handler.GetTaskHistory)
+
+	customerGroup := app.Group("/account/:ownerId")
+	customerGroup.Use(handler.Authorize)
+	customerGroup.Post("/tasks", handler.CreateTask)
+	customerGroup.Get("/tasks/archived", handler.GetArchivedTasks)
+	customerGroup.Post("/tasks/:taskId/comments", handler.CreateComment)
+	customerGroup.Patch("/tasks/:taskId/comments/:commentId", handler.UpdateComment)
+	customerGroup.Delete("/tasks/:taskId/comments/:commentId", handler.DeleteComment)
+	customerGroup.Get("/tasks/:taskId/comments/:commentId/revisions", handler.GetCommentRevisions)
+	customerGroup.Post("/tasks/:taskId/comments/:commentId/reactions", handler.AddReaction)
+	customerGroup.Delete("/tasks/:taskId/comments/:commentId/reactions/:emoji", handler.RemoveReaction)
+	customerGroup.Patch("/tasks/:taskId", handler.UpdateTask)
+	customerGroup.Patch("/tasks/:taskId/archive", handler.ArchiveTask)
+	customerGroup.Patch("/tasks/:taskId/unarchive", handler.UnarchiveTask)
+	customerGroup.Delete("/tasks/:taskId", handler.DeleteTask)
+	customerGroup.Post("/tasks/:taskId/assignees", handler.AssignTask)
+	customerGroup.Delete("/tasks/:taskId/assignees/:assigneeId", handler.UnassignTask)
+	customerGroup.Post("/tasks/:taskId/labels", handler.AddLabels)
+	customerGroup.Delete("/tasks/:taskId/labels/:label", handler.RemoveLabel)
+	customerGroup.Post("/tasks/:taskId/dependencies", handler.AddDependency)
+	customerGroup.Delete("/tasks/:taskId/dependencies/:blockerId", handler.RemoveDependency)
+	customerGroup.Post("/tasks/:taskId/reactions", handler.AddReaction)
+	customerGroup.Delete("/tasks/:taskId/reactions/:emoji", handler.RemoveReaction)
+	customerGroup.Post("/recurrences", handler.CreateRecurrence)
+	customerGroup.Get("/recurrences", handler.GetRecurrences)
+	customerGroup.Delete("/recurrences/:recurrenceId", handler.EndRecurrence)
+	customerGroup.Get("/mentions", handler.GetMentions)
+	customerGroup.Patch("/mentions/:mentionId/read", handler.MarkMentionRead)
 
 	// Start HTTP server
 	go func() {
@@ -70,28 +155,10 @@ func main() {
 	}()
 
 	// Wait for SIGTERM or SIGINT signal
-	gracefully(app, mongoDB)
-}
-
-func authInterceptor(ctx *fiber.Ctx) error {
-	// TODO: validate Authorization header
-	return ctx.Next()
+	gracefully(app, schedulers, mongoDB)
 }
 
-func errorHandler(ctx *fiber.Ctx, err error) error {
-	code := fiber.StatusInternalServerError
-	if e, ok := err.(*fiber.Error); ok {
-		code = e.Code
-	}
-	log.Printf("error: %v", err)
-	msg := map[string]interface{}{
-		"status":    code,
-		"error_msg": err.Error(),
-	}
-	return ctx.Status(code).JSON(msg)
-}
-
-func gracefully(app *fiber.App, mongoDB *mongo.MongoDB) {
+func gracefully(app *fiber.App, schedulers []*scheduler.Scheduler, mongoDB *mongo.MongoDB) {
 	// Make SIGINT send context cancel for graceful stop
 	gfs := make(chan os.Signal, 1)
 	signal.Notify(gfs, syscall.SIGTERM, syscall.SIGINT)
@@ -102,6 +169,11 @@ func gracefully(app *fiber.App, mongoDB *mongo.MongoDB) {
 		log.Fatal(err)
 	}
 
+	// Stop background jobs before their database goes away
+	for _, s := range schedulers {
+		s.Stop()
+	}
+
 	// Stop mongo db
 	if err := mongoDB.Close(context.Background()); err != nil {
 		log.Fatal(err)